@@ -0,0 +1,465 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/rhyrak/go-schedule/internal/csvio"
+	"github.com/rhyrak/go-schedule/internal/report"
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/internal/timetable"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Exit codes of the commands
+const (
+	exitOK      = 0 // Done, the schedule or the inputs are valid
+	exitInvalid = 1 // The schedule or the inputs have problems
+	exitUsage   = 2 // Invalid flags, arguments or configuration
+	exitError   = 3 // Files couldn't be read, parsed or written
+)
+
+type command struct {
+	name    string
+	args    string // Arguments after the flags
+	summary string
+	run     func(args []string) int
+}
+
+func commandList() []*command {
+	return []*command{
+		{"generate", "", "generate a schedule from the inputs, the default command", runGenerate},
+		{"validate", "<schedule>", "check a schedule export against the inputs", runValidate},
+		{"lint", "", "check the inputs without generating a schedule", runLint},
+		{"export", "<schedule>", "convert a schedule export to another format", runExport},
+		{"diff", "<previous schedule> <current schedule>", "show the changes between two schedule exports", runDiff},
+		{"stats", "<schedule>", "show session counts and teaching hours of a schedule export", runStats},
+	}
+}
+
+// Run the command named by the first argument, generate if there is none. Returns the
+// exit code.
+func run(args []string) int {
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && !isHelp(args[0])) {
+		return runGenerate(args)
+	}
+	if isHelp(args[0]) || args[0] == "help" {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+	for _, cmd := range commandList() {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "unknown command "+args[0])
+	printUsage(os.Stderr)
+	return exitUsage
+}
+
+func isHelp(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+func printUsage(w *os.File) {
+	fmt.Fprintln(w, "Usage: cli <command> [flags] [arguments]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commandList() {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nEvery command takes the configuration flags, see cli <command> -h.")
+	fmt.Fprintln(w, "Exit codes: 0 done, 1 problems found, 2 invalid usage or configuration, 3 file errors.")
+}
+
+// Flag set of the command with the configuration flags, defaulting to the configuration
+// of the config file and the environment
+func newCommand(name string, args []string) (*flag.FlagSet, bool) {
+	if err := loadConfig(cfg, configPath(args)); err != nil {
+		fmt.Println("Fatal Error\n" + err.Error())
+		return nil, false
+	}
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	configFlags(flags, cfg)
+	flags.Bool("print-config", false, "print the configuration as a JSON config file and exit")
+	for _, cmd := range commandList() {
+		if cmd.name == name {
+			flags.Usage = func() {
+				fmt.Fprintf(flags.Output(), "Usage: cli %s [flags] %s\n%s\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+				flags.PrintDefaults()
+			}
+		}
+	}
+	return flags, true
+}
+
+// Parse the arguments of the command and check the configuration. Returns false with the
+// exit code if the command shouldn't run, e.g. for -print-config.
+func parseCommand(flags *flag.FlagSet, args []string, argCount int) (bool, int) {
+	flags.Parse(args)
+	if errorExists, errorString := scheduler.CheckConfiguration(cfg); errorExists {
+		fmt.Println("Fatal Error\nInvalid configuration:\n" + errorString)
+		return false, exitUsage
+	}
+	if icsDir := flags.Lookup("ics-dir"); icsDir != nil && icsDir.Value.String() != "" && (cfg.SemesterStart == "" || cfg.SemesterEnd == "") {
+		fmt.Println("Fatal Error\nInvalid configuration:\n-ics-dir needs SemesterStart and SemesterEnd")
+		return false, exitUsage
+	}
+	if flags.Lookup("print-config").Value.String() == "true" {
+		if err := printConfig(os.Stdout, cfg); err != nil {
+			fmt.Println(err)
+			return false, exitError
+		}
+		return false, exitOK
+	}
+	if flags.NArg() != argCount {
+		flags.Usage()
+		return false, exitUsage
+	}
+	return true, exitOK
+}
+
+// Check the -format flag against the formats of the command
+func checkFormat(flags *flag.FlagSet, format string, formats ...string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	fmt.Fprintf(flags.Output(), "unknown format %s, use %s\n", format, strings.Join(formats, ", "))
+	flags.Usage()
+	return false
+}
+
+// Print the output of a command or write it to path, returns the exit code
+func writeOutput(path string, data []byte) int {
+	if path == "" {
+		os.Stdout.Write(data)
+		return exitOK
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Println("Err02")
+		fmt.Println(err)
+		return exitError
+	}
+	return exitOK
+}
+
+// Input files of the configuration
+type inputs struct {
+	classrooms           []*model.Classroom
+	courses              []*model.Course
+	labs                 []*model.Laboratory
+	reserved             []*model.Reserved
+	busy                 []*model.Busy
+	conflicts            []*model.Conflict
+	congestedDepartments map[string]int
+	uniqueDepartments    []string
+	locks                []*model.Lock // Not applied yet
+}
+
+// Load the input files of the configuration, locks from the locks file and given rows
+func loadInputs(lockRows []string) (*inputs, bool, string) {
+	var errorExists bool = false
+	var fileErrorString string = ""
+	in := &inputs{locks: []*model.Lock{}}
+
+	// Parse and instantiate classroom objects from CSV
+	classrooms, err, errorString := csvio.LoadClassrooms(cfg.ClassroomsFile, ';')
+	in.classrooms = classrooms
+	if err {
+		errorExists = true
+		fileErrorString = fileErrorString + errorString
+	}
+
+	// Parse and instantiate course objects from CSV (ignored courses are not loaded)
+	in.courses, in.labs, in.reserved, in.busy, in.conflicts, in.congestedDepartments, in.uniqueDepartments, err, errorString = csvio.LoadCourses(cfg, ';', cfg.IgnoredCourses)
+	if err {
+		errorExists = true
+		fileErrorString = fileErrorString + errorString
+	}
+
+	// Parse locked sessions from the locks file and the command line
+	if cfg.LocksFile != "" {
+		fileLocks, err, errorString := csvio.LoadLocks(cfg.LocksFile, ';')
+		if err {
+			errorExists = true
+			fileErrorString = fileErrorString + errorString
+		}
+		in.locks = append(in.locks, fileLocks...)
+	}
+	if len(lockRows) != 0 {
+		rowLocks, err := csvio.ParseLocks("Department;Course_Code;Section;Part;Lab;Day;Starting_Time;Classroom\n"+strings.Join(lockRows, "\n"), ';')
+		if err != nil {
+			fmt.Println("Err01")
+			errorExists = true
+			fileErrorString = fileErrorString + "Failed to parse -lock values. Please check the format.\n"
+		}
+		in.locks = append(in.locks, rowLocks...)
+	}
+
+	return in, errorExists, fileErrorString
+}
+
+// Define the repeatable -lock flag
+func lockFlag(flags *flag.FlagSet) *[]string {
+	lockRows := []string{}
+	flags.Func("lock", "lock a session, given as Department;Course_Code;Section;Part;Lab;Day;Starting_Time;Classroom (repeatable)", func(row string) error {
+		lockRows = append(lockRows, row)
+		return nil
+	})
+	return &lockRows
+}
+
+// Read a CSV or JSON schedule export
+func readSchedule(path string) (*model.ScheduleJSON, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Println("Err00")
+		fmt.Println("Failed to open " + path + " file. Please make sure the file exists.")
+		return nil, false
+	}
+	export, err := csvio.ParseScheduleExport(string(data), cfg.TimeSlotDuration, cfg.TimeSlotCount)
+	if err != nil {
+		fmt.Println("Err01")
+		fmt.Println("Failed to parse data from " + path + " file. Please check the data integrity and format.")
+		return nil, false
+	}
+	return export, true
+}
+
+// Result of validate in the JSON format
+type validation struct {
+	Valid           bool               `json:"valid"`
+	SufficientRooms bool               `json:"sufficient_rooms"`
+	Unassigned      int                `json:"unassigned"`
+	ImportErrors    string             `json:"import_errors"` // Rows that couldn't be placed
+	Message         string             `json:"message"`
+	Checks          []*scheduler.Check `json:"checks"`
+}
+
+// Place the schedule export on the inputs and validate it like a generated schedule
+func runValidate(args []string) int {
+	flags, ok := newCommand("validate", args)
+	if !ok {
+		return exitUsage
+	}
+	format := flags.String("format", "text", "output format: text or json")
+	outPath := flags.String("o", "", "write the result to given path instead of printing it")
+	if ok, code := parseCommand(flags, args, 1); !ok {
+		return code
+	}
+	if !checkFormat(flags, *format, "text", "json") {
+		return exitUsage
+	}
+
+	in, errorExists, fileErrorString := loadInputs(nil)
+	if errorExists {
+		fmt.Println("Fatal Error\n" + fileErrorString)
+		return exitError
+	}
+	// Conflicts between courses are set up as for a run, or collisions can't be found
+	in.courses, in.labs = scheduler.InitRuntimeProperties(cfg, in.courses, in.labs, 1, in.conflicts)
+	schedule, importErrors, importReport := csvio.ImportSchedule(flags.Arg(0), cfg, in.courses, in.labs, in.classrooms)
+	if schedule == nil {
+		fmt.Println("Fatal Error\n" + importReport)
+		return exitError
+	}
+	v := scheduler.ValidateSchedule(in.courses, in.labs, schedule)
+	valid, sufficientRooms, msg, uc := v.Valid, v.SufficientRooms, v.Message(), len(v.Unassigned)
+	result := &validation{Valid: valid && !importErrors, SufficientRooms: sufficientRooms, Unassigned: uc, ImportErrors: importReport, Message: msg, Checks: v.Checks}
+
+	var output []byte
+	if *format == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Println("Err03")
+			return exitError
+		}
+		output = append(data, '\n')
+	} else {
+		var reportString string
+		if importReport != "" {
+			reportString = reportString + "Schedule rows that couldn't be placed are as below:\n" + importReport + "\n"
+		}
+		if result.Valid {
+			reportString = reportString + "Passed all tests\n"
+		} else {
+			reportString = reportString + "Invalid schedule:\n"
+		}
+		reportString = reportString + "Unassigned: " + fmt.Sprint(uc) + "\n\n" + msg
+		output = []byte(reportString)
+	}
+	if code := writeOutput(*outPath, output); code != exitOK {
+		return code
+	}
+	if !result.Valid || !sufficientRooms {
+		return exitInvalid
+	}
+	return exitOK
+}
+
+// Load and check the inputs: file errors, locks that can't be applied, courses that no
+// classroom can seat, courses longer than a day and lecturers busy on every day
+func runLint(args []string) int {
+	flags, ok := newCommand("lint", args)
+	if !ok {
+		return exitUsage
+	}
+	lockRows := lockFlag(flags)
+	format := flags.String("format", "text", "output format: text or json")
+	outPath := flags.String("o", "", "write the problems to given path instead of printing them")
+	if ok, code := parseCommand(flags, args, 0); !ok {
+		return code
+	}
+	if !checkFormat(flags, *format, "text", "json") {
+		return exitUsage
+	}
+
+	problems := []string{}
+	in, errorExists, fileErrorString := loadInputs(*lockRows)
+	if errorExists {
+		problems = append(problems, strings.Split(strings.TrimSpace(fileErrorString), "\n")...)
+	} else {
+		if len(in.locks) != 0 {
+			_, lockErrors, lockReport := csvio.ApplyLocks(in.locks, in.reserved, cfg, in.courses, in.labs, in.classrooms)
+			if lockErrors {
+				problems = append(problems, strings.Split(strings.TrimSpace(lockReport), "\n")...)
+			}
+		}
+		largest := 0
+		for _, r := range in.classrooms {
+			largest = max(largest, r.Capacity)
+		}
+		for _, c := range in.courses {
+			name := c.Department + " " + c.Course_Code
+			if c.NeedsRoom && int(float64(c.Number_of_Students)*cfg.RoomOccupancyRatio) > largest {
+				problems = append(problems, fmt.Sprintf("- %s: %d students don't fit into any classroom (largest seats %d)", name, c.Number_of_Students, largest))
+			}
+			if slots := int(math.Ceil(float64(c.Duration) / float64(cfg.TimeSlotDuration))); slots > cfg.TimeSlotCount {
+				problems = append(problems, fmt.Sprintf("- %s: %d time slots don't fit into a day of %d", name, slots, cfg.TimeSlotCount))
+			}
+		}
+		for _, b := range in.busy {
+			busyDays := map[int]bool{}
+			for _, d := range b.Day {
+				if d >= 0 && d < cfg.NumberOfDays {
+					busyDays[d] = true
+				}
+			}
+			if len(busyDays) == cfg.NumberOfDays {
+				problems = append(problems, "- "+b.Lecturer+": busy on every day")
+			}
+		}
+	}
+
+	var output []byte
+	if *format == "json" {
+		data, err := json.MarshalIndent(map[string][]string{"problems": problems}, "", "  ")
+		if err != nil {
+			fmt.Println("Err03")
+			return exitError
+		}
+		output = append(data, '\n')
+	} else if len(problems) == 0 {
+		output = []byte("No problems found\n")
+	} else {
+		output = []byte(fmt.Sprintf("%d problems found:\n%s\n", len(problems), strings.Join(problems, "\n")))
+	}
+	if code := writeOutput(*outPath, output); code != exitOK {
+		return code
+	}
+	if len(problems) != 0 {
+		return exitInvalid
+	}
+	return exitOK
+}
+
+// Convert a schedule export to CSV, JSON, XLSX, HTML or iCalendar feeds
+func runExport(args []string) int {
+	flags, ok := newCommand("export", args)
+	if !ok {
+		return exitUsage
+	}
+	format := flags.String("format", "csv", "output format: csv, json, xlsx, html or ics")
+	outPath := flags.String("o", "", "write the schedule to given path instead of printing it, a directory for ics")
+	if ok, code := parseCommand(flags, args, 1); !ok {
+		return code
+	}
+	if !checkFormat(flags, *format, "csv", "json", "xlsx", "html", "ics") {
+		return exitUsage
+	}
+	if *format == "ics" && *outPath == "" {
+		fmt.Fprintln(flags.Output(), "ics feeds need an output directory given with -o")
+		return exitUsage
+	}
+	export, ok := readSchedule(flags.Arg(0))
+	if !ok {
+		return exitError
+	}
+	rows := export.Rows()
+
+	var buf bytes.Buffer
+	var err error
+	switch *format {
+	case "csv":
+		var data string
+		data, err = csvio.ExportRowsString(rows)
+		buf.WriteString(data)
+	case "json":
+		err = json.NewEncoder(&buf).Encode(export)
+	case "xlsx":
+		err = csvio.WriteWorkbook(&buf, rows, cfg.NumberOfDays, export.TimeSlotDuration, export.TimeSlotCount)
+	case "html":
+		err = csvio.RenderHTML(&buf, rows, cfg.NumberOfDays, export.TimeSlotDuration, export.TimeSlotCount, csvio.RenderMeta{})
+	case "ics":
+		err = exportCalendars(timetable.FromRows(rows, cfg.NumberOfDays, export.TimeSlotDuration, export.TimeSlotCount), *outPath)
+		if err != nil {
+			fmt.Println("Err09")
+			fmt.Println(err)
+			return exitError
+		}
+		return exitOK
+	}
+	if err != nil {
+		fmt.Println("Err03")
+		fmt.Println(err)
+		return exitError
+	}
+	return writeOutput(*outPath, buf.Bytes())
+}
+
+// Print session counts and teaching hours of a schedule export
+func runStats(args []string) int {
+	flags, ok := newCommand("stats", args)
+	if !ok {
+		return exitUsage
+	}
+	format := flags.String("format", "text", "output format: text or json")
+	outPath := flags.String("o", "", "write the statistics to given path instead of printing them")
+	if ok, code := parseCommand(flags, args, 1); !ok {
+		return code
+	}
+	if !checkFormat(flags, *format, "text", "json") {
+		return exitUsage
+	}
+	export, ok := readSchedule(flags.Arg(0))
+	if !ok {
+		return exitError
+	}
+
+	stats := report.NewScheduleStats(timetable.FromRows(export.Rows(), cfg.NumberOfDays, export.TimeSlotDuration, export.TimeSlotCount))
+	if *format == "json" {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			fmt.Println("Err03")
+			return exitError
+		}
+		return writeOutput(*outPath, append(data, '\n'))
+	}
+	return writeOutput(*outPath, []byte(stats.String()))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Input files of two courses taught by Dr A, written into dir. Returns the flags
+// pointing the commands at them.
+func writeDataset(t *testing.T, dir string) []string {
+	files := map[string]string{
+		"courses": "Section;Course_Code;Course_Name;Number_of_Students;Course_Environment;T+U;AKTS;Class;Depertmant;Lecturer;Room_Stability\n" +
+			"1;CS101;Intro;20;classroom;2+0;5;1;CS;Dr A;\n" +
+			"1;CS201;Data;20;classroom;2+0;5;2;CS;Dr A;\n",
+		"classrooms": "floor_number;classroom_id;capacity;available_days\n" +
+			"0;R1;40;Monday-Tuesday-Wednesday-Thursday-Friday\n" +
+			"0;R2;40;Monday-Tuesday-Wednesday-Thursday-Friday\n",
+		"reserved":  "Department;Course_Code;Day;Starting_Time\n",
+		"busy":      "Lecturer;Busy_Day\n",
+		"mandatory": "Course_Code\nCS101\nCS201\n",
+		"conflicts": "Department1;Course_Code1;Department2;Course_Code2\n",
+		"splits":    "Department;Course_Code;Half_Duration;Durations\n",
+		"externals": "Section;Course_Code;Course_Name;Number_of_Students;Course_Environment;T+U;AKTS;Class;Department;Lecturer;Starting_Time;Day\n",
+		"limits":    "Lecturer;Max_Daily_Hours;Max_Consecutive_Hours;Max_Days;Min_Days;No_Early_After_Late\n",
+	}
+	flags := []string{}
+	for kind, data := range files {
+		path := filepath.Join(dir, kind+".csv")
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		flags = append(flags, "-"+kind, path)
+	}
+	return flags
+}
+
+func TestValidateExitCodes(t *testing.T) {
+	header := "course_code,day,time,duration,classroom,grade,department,course_name,lecturer\n"
+	tests := []struct {
+		name     string
+		schedule string
+		want     int
+	}{
+		{"lecturer teaches one course at a time", header +
+			"CS101,0,0,120,R1,1,CS,Intro,Dr A\n" +
+			"CS201,1,0,120,R1,2,CS,Data,Dr A\n", exitOK},
+		{"lecturer double-booked", header +
+			"CS101,0,0,120,R1,1,CS,Intro,Dr A\n" +
+			"CS201,0,0,120,R2,2,CS,Data,Dr A\n", exitInvalid},
+		{"course left out", header +
+			"CS101,0,0,120,R1,1,CS,Intro,Dr A\n", exitInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			schedulePath := filepath.Join(dir, "schedule.csv")
+			if err := os.WriteFile(schedulePath, []byte(tt.schedule), 0644); err != nil {
+				t.Fatal(err)
+			}
+			args := append([]string{"validate", "-o", filepath.Join(dir, "result.txt")}, writeDataset(t, dir)...)
+			if code := run(append(args, schedulePath)); code != tt.want {
+				result, _ := os.ReadFile(filepath.Join(dir, "result.txt"))
+				t.Errorf("exit code %d, want %d:\n%s", code, tt.want, result)
+			}
+		})
+	}
+}
+
+func TestGenerateExitCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags func(dir string) []string
+		want  int
+	}{
+		{"schedule written", func(dir string) []string {
+			return []string{"-report", filepath.Join(dir, "report.json")}
+		}, exitOK},
+		{"report can't be written", func(dir string) []string {
+			return []string{"-report", filepath.Join(dir, "missing", "report.json")}
+		}, exitError},
+		{"calendars written", func(dir string) []string {
+			return []string{"-ics-dir", dir, "-semester-start", "2026-09-28", "-semester-end", "2027-01-08"}
+		}, exitOK},
+		{"calendars without semester dates", func(dir string) []string {
+			return []string{"-ics-dir", dir, "-semester-start", "", "-semester-end", ""}
+		}, exitUsage},
+		{"room report can't be written", func(dir string) []string {
+			return []string{"-room-report", dir}
+		}, exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			args := append([]string{"generate", "-seed", "1", "-out", filepath.Join(dir, "schedule.csv")}, writeDataset(t, dir)...)
+			if code := run(append(args, tt.flags(dir)...)); code != tt.want {
+				t.Errorf("exit code %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration value that can be set from a config file, an environment variable and a
+// flag, in this order of precedence from lowest
+type setting struct {
+	key   string // Key in config files, the flag is the key with dashes
+	usage string
+	value any // Pointer to the field of the configuration
+}
+
+// Settings of the configuration, input file keys match the input kinds of the server
+func settings(cfg *scheduler.Configuration) []*setting {
+	return []*setting{
+		{"courses", "courses CSV path", &cfg.CoursesFile},
+		{"classrooms", "classrooms CSV path", &cfg.ClassroomsFile},
+		{"reserved", "reserved courses CSV path", &cfg.PriorityFile},
+		{"busy", "busy lecturers CSV path", &cfg.BlacklistFile},
+		{"mandatory", "mandatory courses CSV path", &cfg.MandatoryFile},
+		{"conflicts", "conflicting courses CSV path", &cfg.ConflictsFile},
+		{"splits", "split courses CSV path", &cfg.SplitFile},
+		{"externals", "external courses CSV path", &cfg.ExternalFile},
+		{"limits", "lecturer limits CSV path", &cfg.LecturerLimitsFile},
+		{"locks", "lock sessions listed in given CSV file to their day, time and classroom", &cfg.LocksFile},
+		{"out", "schedule output path, a .json path exports the JSON schema instead of CSV", &cfg.ExportFile},
+		{"number_of_days", "number of days in a week", &cfg.NumberOfDays},
+		{"time_slot_duration", "duration of a time slot in minutes", &cfg.TimeSlotDuration},
+		{"time_slot_count", "number of time slots in a day", &cfg.TimeSlotCount},
+		{"relative_conflict_probability", "probability of placing conflicting courses together, from 0 to 2", &cfg.RelativeConflictProbability},
+		{"iter_soft_limit", "iterations before the scheduler relaxes its constraints", &cfg.IterSoftLimit},
+		{"department_congestion_limit", "course count of a department that makes it congested", &cfg.DepartmentCongestionLimit},
+		{"activity_day", "day kept free in the afternoon, 0 is Monday", &cfg.ActivityDay},
+		{"max_lecturer_daily_hours", "default maximum hours of a lecturer in a day", &cfg.MaxLecturerDailyHours},
+		{"max_lecturer_consecutive_hours", "default maximum consecutive hours of a lecturer", &cfg.MaxLecturerConsecutiveHours},
+		{"max_lecturer_days", "default maximum teaching days of a lecturer", &cfg.MaxLecturerDays},
+		{"min_lecturer_days", "default minimum teaching days of a lecturer", &cfg.MinLecturerDays},
+		{"no_early_after_late", "don't give lecturers an early course after a late one", &cfg.NoEarlyAfterLate},
+		{"room_occupancy_ratio", "share of the students a classroom must seat", &cfg.RoomOccupancyRatio},
+		{"semester_start", "first day of the semester for iCalendar feeds (YYYY-MM-DD)", &cfg.SemesterStart},
+		{"semester_end", "last day of the semester for iCalendar feeds (YYYY-MM-DD)", &cfg.SemesterEnd},
+		{"seed", "random seed, 0 picks one", &cfg.Seed},
+		{"ignored_courses", "comma separated course codes that aren't loaded", &cfg.IgnoredCourses},
+	}
+}
+
+// Environment variable of the setting, e.g. SCHEDULE_NUMBER_OF_DAYS
+func (s *setting) env() string {
+	return "SCHEDULE_" + strings.ToUpper(s.key)
+}
+
+func (s *setting) String() string {
+	switch v := s.value.(type) {
+	case *string:
+		return *v
+	case *int:
+		return strconv.Itoa(*v)
+	case *int64:
+		return strconv.FormatInt(*v, 10)
+	case *float64:
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	case *bool:
+		return strconv.FormatBool(*v)
+	case *[]string:
+		return strings.Join(*v, ",")
+	}
+	return ""
+}
+
+func (s *setting) Set(text string) error {
+	var err error
+	switch v := s.value.(type) {
+	case *string:
+		*v = text
+	case *int:
+		*v, err = strconv.Atoi(text)
+	case *int64:
+		*v, err = strconv.ParseInt(text, 10, 64)
+	case *float64:
+		*v, err = strconv.ParseFloat(text, 64)
+	case *bool:
+		*v, err = strconv.ParseBool(text)
+	case *[]string:
+		*v = []string{}
+		for _, code := range strings.Split(text, ",") {
+			if code = strings.TrimSpace(code); code != "" {
+				*v = append(*v, code)
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %s", text, s.key)
+	}
+	return nil
+}
+
+// Set the setting from a decoded config file value
+func (s *setting) setValue(value any) error {
+	switch v := value.(type) {
+	case nil:
+		return s.Set("")
+	case []any:
+		codes, ok := s.value.(*[]string)
+		if !ok {
+			return fmt.Errorf("%s is not a list", s.key)
+		}
+		*codes = []string{}
+		for _, code := range v {
+			*codes = append(*codes, fmt.Sprint(code))
+		}
+		return nil
+	case json.Number:
+		return s.Set(v.String())
+	case float64:
+		return s.Set(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return s.Set(fmt.Sprint(v))
+	}
+}
+
+// Path of the config file given with -config or SCHEDULE_CONFIG, looked up before the
+// flags are parsed so that they override the file
+func configPath(args []string) string {
+	path := os.Getenv("SCHEDULE_CONFIG")
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, found := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if found {
+			path = value
+		} else if i+1 < len(args) {
+			path = args[i+1]
+		}
+	}
+	return path
+}
+
+// Load the configuration from the config file at path (.json, .yaml, .yml or .toml) and
+// the environment, an empty path loads the environment only
+func loadConfig(cfg *scheduler.Configuration, path string) error {
+	byKey := map[string]*setting{}
+	for _, s := range settings(cfg) {
+		byKey[s.key] = s
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		values := map[string]any{}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			// Numbers are kept as text, seeds don't fit into a float64
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.UseNumber()
+			err = decoder.Decode(&values)
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, &values)
+		case ".toml":
+			err = toml.Unmarshal(data, &values)
+		default:
+			return fmt.Errorf("unknown config file format %s, use .json, .yaml, .yml or .toml", filepath.Ext(path))
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		for key, value := range values {
+			s, found := byKey[key]
+			if !found {
+				return fmt.Errorf("%s: unknown key %s", path, key)
+			}
+			if err := s.setValue(value); err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+		}
+	}
+
+	for _, s := range settings(cfg) {
+		if text, found := os.LookupEnv(s.env()); found {
+			if err := s.Set(text); err != nil {
+				return fmt.Errorf("%s: %v", s.env(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Define a flag for every setting on the flag set, defaulting to the loaded configuration
+func configFlags(flags *flag.FlagSet, cfg *scheduler.Configuration) {
+	flags.String("config", "", "load the configuration from given JSON, YAML or TOML file (or SCHEDULE_CONFIG)")
+	for _, s := range settings(cfg) {
+		flags.Var(s, strings.ReplaceAll(s.key, "_", "-"), s.usage+" (or "+s.env()+")")
+	}
+}
+
+// Write the configuration as a JSON config file, keys in the order of the settings
+func printConfig(w io.Writer, cfg *scheduler.Configuration) error {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	list := settings(cfg)
+	for i, s := range list {
+		value, err := json.Marshal(s.value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "  %q: %s", s.key, value)
+		if i < len(list)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
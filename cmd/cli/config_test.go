@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+)
+
+func TestNewConfiguration(t *testing.T) {
+	want := scheduler.NewDefaultConfiguration()
+	want.CoursesFile = "./res/private/courses1.csv"
+	if got := newConfiguration(); !reflect.DeepEqual(got, want) {
+		t.Errorf("CLI configuration %+v differs from the defaults %+v beyond its input paths", *got, *want)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	files := map[string]string{
+		"run.json": `{"number_of_days": 4, "seed": 9007199254740993, "room_occupancy_ratio": 0.75, "ignored_courses": ["ENGR450"], "no_early_after_late": true}`,
+		"run.yaml": "number_of_days: 4\nseed: 9007199254740993\nroom_occupancy_ratio: 0.75\nignored_courses: [ENGR450]\nno_early_after_late: true\n",
+		"run.toml": "number_of_days = 4\nseed = 9007199254740993\nroom_occupancy_ratio = 0.75\nignored_courses = [\"ENGR450\"]\nno_early_after_late = true\n",
+	}
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg := newConfiguration()
+			if err := loadConfig(cfg, path); err != nil {
+				t.Fatal(err)
+			}
+			if cfg.NumberOfDays != 4 || cfg.Seed != 9007199254740993 || cfg.RoomOccupancyRatio != 0.75 ||
+				!reflect.DeepEqual(cfg.IgnoredCourses, []string{"ENGR450"}) || !cfg.NoEarlyAfterLate {
+				t.Errorf("loaded %+v", *cfg)
+			}
+			if cfg.TimeSlotCount != 9 {
+				t.Errorf("time slot count %d, want the default 9 for a key missing from the file", cfg.TimeSlotCount)
+			}
+		})
+	}
+}
+
+func TestLoadConfigEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	if err := os.WriteFile(path, []byte(`{"number_of_days": 4, "time_slot_count": 8}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SCHEDULE_NUMBER_OF_DAYS", "3")
+	t.Setenv("SCHEDULE_IGNORED_COURSES", " IE101, ,CENG404")
+
+	cfg := newConfiguration()
+	if err := loadConfig(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.NumberOfDays != 3 {
+		t.Errorf("number of days %d, want 3 from the environment over the file", cfg.NumberOfDays)
+	}
+	if cfg.TimeSlotCount != 8 {
+		t.Errorf("time slot count %d, want 8 from the file", cfg.TimeSlotCount)
+	}
+	if !reflect.DeepEqual(cfg.IgnoredCourses, []string{"IE101", "CENG404"}) {
+		t.Errorf("ignored courses %q", cfg.IgnoredCourses)
+	}
+
+	t.Setenv("SCHEDULE_NUMBER_OF_DAYS", "five")
+	if err := loadConfig(newConfiguration(), ""); err == nil || !strings.Contains(err.Error(), "SCHEDULE_NUMBER_OF_DAYS") {
+		t.Errorf("got %v for a number of days that isn't a number", err)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"run.json", `{"number_of_dayz": 4}`, "unknown key number_of_dayz"},
+		{"run.json", `{"ignored_courses": "ENGR450", "seed": "x"}`, "invalid value"},
+		{"run.yaml", "seed: [1, 2]\n", "seed is not a list"},
+		{"run.toml", "seed = \n", "run.toml"},
+		{"run.ini", "seed=1\n", "unknown config file format .ini"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.name)
+		if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := loadConfig(newConfiguration(), path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %q: got error %v, want one containing %q", tt.name, tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	t.Setenv("SCHEDULE_CONFIG", "env.json")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, "env.json"},
+		{[]string{"-config", "a.json"}, "a.json"},
+		{[]string{"--config=b.yaml", "-seed", "1"}, "b.yaml"},
+		{[]string{"-seed", "1", "--", "-config", "c.toml"}, "env.json"},
+		{[]string{"-config"}, "env.json"},
+	}
+	for _, tt := range tests {
+		if got := configPath(tt.args); got != tt.want {
+			t.Errorf("configPath(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestPrintConfigLoadsBack(t *testing.T) {
+	printed := newConfiguration()
+	printed.Seed = 9007199254740993
+	printed.SemesterStart = "2026-09-28"
+	printed.IgnoredCourses = []string{}
+	var buf bytes.Buffer
+	if err := printConfig(&buf, printed); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "printed.json")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := scheduler.NewDefaultConfiguration()
+	if err := loadConfig(loaded, path); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, printed) {
+		t.Errorf("loaded %+v, want the printed %+v", *loaded, *printed)
+	}
+}
@@ -1,267 +1,289 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
-	"math/rand"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rhyrak/go-schedule/internal/csvio"
+	"github.com/rhyrak/go-schedule/internal/report"
 	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/internal/timetable"
 	"github.com/rhyrak/go-schedule/pkg/model"
 )
 
-// Program parameters
-var cfg = &scheduler.Configuration{
-	ClassroomsFile:              "./res/private/classrooms.csv",
-	CoursesFile:                 "./res/private/courses1.csv",
-	PriorityFile:                "./res/private/reserved.csv",
-	BlacklistFile:               "./res/private/busy.csv",
-	MandatoryFile:               "./res/private/mandatory.csv",
-	ConflictsFile:               "./res/private/conflict.csv",
-	SplitFile:                   "./res/private/split.csv",
-	ExternalFile:                "./res/private/external.csv",
-	ExportFile:                  "schedule.csv",
-	NumberOfDays:                5,
-	TimeSlotDuration:            60,
-	TimeSlotCount:               9,
-	RelativeConflictProbability: 0.7 * 2, // 70%
-	IterSoftLimit:               5000,    // Feasible limit up until state 1
-	DepartmentCongestionLimit:   11,
-	ActivityDay:                 3,
+// Program parameters, overridden by the config file, the environment and the flags
+var cfg = newConfiguration()
+
+// Default configuration of the scheduler with the input paths of the CLI
+func newConfiguration() *scheduler.Configuration {
+	cfg := scheduler.NewDefaultConfiguration()
+	cfg.CoursesFile = "./res/private/courses1.csv"
+	return cfg
 }
 
 func main() {
-	var errorExists bool = false
-	var fileErrorString string = ""
-	var reportString string = ""
-
-	// Parse and instantiate classroom objects from CSV
-	classrooms, err, errorString := csvio.LoadClassrooms(cfg.ClassroomsFile, ';')
+	os.Exit(run(os.Args[1:]))
+}
 
-	if err {
-		errorExists = true
-		fileErrorString = fileErrorString + errorString
+// Generate a schedule from the inputs and print the report, returns the exit code
+func runGenerate(args []string) int {
+	flags, ok := newCommand("generate", args)
+	if !ok {
+		return exitUsage
 	}
-
-	// Don't load these
-	ignoredCourses := []string{"ENGR450", "IE101", "CENG404"}
-
-	// Parse and instantiate course objects from CSV (ignored courses are not loaded)
-	courses, labs, reserved, busy, conflicts, congestedDepartments, uniqueDepartments, err, errorString := csvio.LoadCourses(cfg, ';', ignoredCourses)
-
-	if err {
-		errorExists = true
-		fileErrorString = fileErrorString + errorString
+	roomReportPath := flags.String("room-report", "", "write classroom utilisation report as JSON to given path")
+	var timetableViews []string
+	flags.Func("timetable", "print weekly timetable of lecturer:<name>, classroom:<id> or cohort:<department>:<grade> (repeatable)", func(view string) error {
+		timetableViews = append(timetableViews, view)
+		return nil
+	})
+	htmlPath := flags.String("html", "", "write printable HTML timetables to given path")
+	xlsxPath := flags.String("xlsx", "", "write schedule as an XLSX workbook to given path")
+	icsDir := flags.String("ics-dir", "", "write iCalendar feeds of every lecturer, classroom and cohort into given directory")
+	repairPath := flags.String("repair", "", "repair the schedule at given path for the current inputs instead of generating a new one")
+	reportFormat := flags.String("report-format", "text", "format of the printed report: text, json or markdown")
+	reportPath := flags.String("report", "", "write the report as JSON to given path")
+	lockRows := lockFlag(flags)
+	if ok, code := parseCommand(flags, args, 0); !ok {
+		return code
 	}
-
-	if errorExists {
-		reportString = "Fatal Error\n" + fileErrorString
-		/* POST/print reportString */
-		fmt.Println(reportString)
-		return
+	if !checkFormat(flags, *reportFormat, "text", "json", "markdown") {
+		return exitUsage
 	}
 
-	fmt.Println("Loading...")
-
-	reportString = reportString + "Departments are as below:\n"
-	for _, d := range uniqueDepartments {
-		reportString = reportString + d + "\n"
+	in, errorExists, fileErrorString := loadInputs(*lockRows)
+	if errorExists {
+		fmt.Println(report.NewFatalReport(fileErrorString).Format(*reportFormat))
+		return exitError
 	}
-	reportString = reportString + "\n"
+	classrooms, courses, labs, reserved, busy, conflicts := in.classrooms, in.courses, in.labs, in.reserved, in.busy, in.conflicts
+	congestedDepartments, uniqueDepartments, locks := in.congestedDepartments, in.uniqueDepartments, in.locks
 
-	if len(ignoredCourses) != 0 {
-		reportString = reportString + "Ignored courses are as below:\n"
-		for _, g := range ignoredCourses {
-			reportString = reportString + g + " is ignored.\n"
-		}
-		reportString = reportString + "\n"
+	if *reportFormat == "text" {
+		fmt.Println("Loading...")
 	}
 
-	if len(busy) != 0 {
-		reportString = reportString + "Professors with their busy schedules are as below:\n"
-		for _, b := range busy {
-			reportString = reportString + b.Lecturer + " "
-			reportString = reportString + "["
-			for _, d := range b.Day {
-				switch d {
-				case 0:
-					reportString = reportString + " Monday "
-				case 1:
-					reportString = reportString + " Tuesday "
-				case 2:
-					reportString = reportString + " Wednesday "
-				case 3:
-					reportString = reportString + " Thursday "
-				case 4:
-					reportString = reportString + " Friday "
-				}
-			}
-			reportString = reportString + "]\n"
-		}
-		reportString = reportString + "\n"
-	}
+	runReport := report.NewReport()
+	runReport.Inputs = report.NewInputSummary(classrooms, courses, labs, reserved, busy, conflicts, uniqueDepartments, cfg.IgnoredCourses)
 
-	if len(reserved) != 0 {
-		reportString = reportString + "Courses reserved to certain days and hours are as below:\n"
-		for _, c := range reserved {
-			reportString = reportString + c.CourseRef.Department + " " + c.CourseCodeSTR + " " + c.DaySTR + " " + c.StartingTimeSTR
+	if len(locks) != 0 {
+		var lockErrors bool
+		var lockReport string
+		reserved, lockErrors, lockReport = csvio.ApplyLocks(locks, reserved, cfg, courses, labs, classrooms)
+		if lockErrors {
+			runReport.AddWarnings(report.WarningLock, lockReport)
 		}
-		reportString = reportString + "\n"
 	}
 
-	reportString = reportString + "\n"
-
-	if len(conflicts) != 0 {
-		reportString = reportString + "Courses that explicitly won't conflict with each other are as below:\n"
-		for _, cc := range conflicts {
-			reportString = reportString + cc.Department1 + " " + cc.Course_Code1 + " <-> " + cc.Department2 + " " + cc.Course_Code2 + "\n"
-		}
-		reportString = reportString + "\n"
-	}
+	// Seed the random generator so the run can be reported and repeated
+	scheduler.SeedRandom(cfg)
 
 	// Start timer
 	start := time.Now().UnixNano()
-	var schedule *model.Schedule
-	var optimalSchedule *model.Schedule
-	var optimalCourses []*model.Course
-	var optimalLabs []*model.Laboratory
-	var iter int
-	var stateCount int = 2
-	var iterUpperLimit int = cfg.IterSoftLimit + 4999 // Extend final state by 5000 iterations (Doomsday)
-	var iterActivityDayDelta int = cfg.IterSoftLimit / stateCount
-	var state int = 0
-	var placementProbability = 0.1
-	unassignedCount := 21474836547
-	// Try to create a valid schedule upto iterLimit+1 times
-	for iter = 1; iter <= iterUpperLimit; iter++ {
-		// Increment state every iterState iterations and reset FreeDay fill probability
-		if iter%cfg.IterSoftLimit == 0 {
-			state++
-			placementProbability = 0.1
-		}
-
-		// Increment fill probabilty of Activity Day from 10% to 60% over the course of state iterations
-		placementProbability = placementProbability + (1 / float64(iterActivityDayDelta*4))
-
-		// Keep going in 2nd state, Also fully unlock Activity Day
-		if state >= stateCount-1 {
-			state = stateCount - 1
-			placementProbability = 1.0
-		}
-
-		for _, c := range classrooms {
-			// Initialize an empty classroom-oriented schedule to keep track of classroom utilization throughout the week
-			c.CreateSchedule(cfg.NumberOfDays, cfg.TimeSlotCount)
-		}
-
-		// Init and assign new conflict probabilities according to state
-		courses, labs = scheduler.InitRuntimeProperties(courses, labs, state, conflicts, cfg.RelativeConflictProbability)
-
-		// Shuffle around the courses vector randomly to allow for different output opportunities
-		rand.Shuffle(len(courses), func(i, j int) {
-			courses[i], courses[j] = courses[j], courses[i]
-		})
-
-		// Initialize an empty schedule to hold course data
-		schedule = model.NewSchedule(cfg.NumberOfDays, cfg.TimeSlotDuration, cfg.TimeSlotCount)
-
-		// Fill the empty schedule with course data and assign classrooms to courses
-		scheduler.PlaceReservedCourses(reserved, schedule, classrooms)
-		scheduler.FillCourses(courses, labs, schedule, classrooms, placementProbability, cfg.ActivityDay, congestedDepartments, cfg.DepartmentCongestionLimit, state)
-
-		// If schedule is valid, break, if not, shove everything out the window and try again (5dk)
-		_, valid, _, _, cnt := scheduler.Validate(courses, labs, schedule, classrooms, congestedDepartments, cfg.DepartmentCongestionLimit)
-		if valid {
-			optimalSchedule = schedule.DeepCopy()
-			optimalCourses = model.DeepCopyCourses(courses)
-			optimalLabs = model.DeepCopyLaboratories(labs)
-			break
+	var result *scheduler.Result
+	if *repairPath != "" {
+		// Keep the previous schedule and only move what no longer fits
+		previous, importErrors, importReport := csvio.ImportSchedule(*repairPath, cfg, courses, labs, classrooms)
+		if previous == nil {
+			fmt.Println(report.NewFatalReport(importReport).Format(*reportFormat))
+			return exitError
 		}
-		// Update least-faulty schedule
-		if cnt <= unassignedCount {
-			unassignedCount = cnt
-			optimalSchedule = schedule.DeepCopy()
-			optimalCourses = model.DeepCopyCourses(courses)
-			optimalLabs = model.DeepCopyLaboratories(labs)
+		if importErrors {
+			runReport.AddWarnings(report.WarningImport, importReport)
 		}
-
+		repaired := scheduler.Repair(cfg, previous, courses, labs, reserved, classrooms, conflicts, congestedDepartments)
+		runReport.Repair = report.NewRepairSummary(repaired)
+		result = &repaired.Result
+	} else {
+		result = scheduler.Generate(cfg, courses, labs, reserved, classrooms, conflicts, congestedDepartments)
 	}
+	optimalSchedule, optimalCourses, optimalLabs := result.Schedule, result.Courses, result.Labs
+	iter, state, placementProbability := result.Iteration, result.State, result.PlacementProbability
 	end := time.Now().UnixNano()
 
 	// Write newly created schedule to disk
-	outPath := csvio.ExportSchedule(optimalSchedule, cfg.ExportFile)
-
-	// Validate and print error messages
-	unassignedCourses, valid, sufficientRooms, msg, uc := scheduler.Validate(optimalCourses, optimalLabs, optimalSchedule, classrooms, congestedDepartments, cfg.DepartmentCongestionLimit)
-	if !valid {
-		errorExists = true
-		reportString = reportString + "Invalid schedule:\n"
+	optimalSchedule.CalculateCost()
+	var outPath string
+	if strings.HasSuffix(cfg.ExportFile, ".json") {
+		outPath = csvio.ExportScheduleJSON(optimalSchedule, cfg.ExportFile)
 	} else {
-		reportString = reportString + "Passed all tests\n"
+		outPath = csvio.ExportSchedule(optimalSchedule, cfg.ExportFile)
 	}
 
-	reportString = reportString + "Unassigned: " + strconv.Itoa(uc) + "\n\n"
+	// Validate the schedule
+	runReport.SetValidation(scheduler.ValidateSchedule(optimalCourses, optimalLabs, optimalSchedule))
+	errorExists = runReport.Status != report.StatusSuccess
+	runReport.Locked = report.NewLockedSessions(reserved)
 
-	if !sufficientRooms {
-		errorExists = true
+	c := cfg.RelativeConflictProbability / 2.0 * 100.0
+	if state == 1 {
+		c = 100
 	}
 
-	reportString = reportString + "Classrooms and their occuppied days are as below:\n"
+	// Show how evil the schedule is
+	optimalSchedule.CalculateCost()
+	runReport.Run = &report.RunSummary{
+		State:                state,
+		Cost:                 optimalSchedule.Cost,
+		Iteration:            iter,
+		Seed:                 cfg.Seed,
+		ConflictProbability:  c,
+		PlacementProbability: placementProbability * 100.0,
+		ElapsedMs:            float64(end-start) / 1000000.0,
+		NumberOfDays:         len(optimalSchedule.Days),
+		TimeSlotDuration:     optimalSchedule.TimeSlotDuration,
+		TimeSlotCount:        optimalSchedule.TimeSlotCount,
+	}
+	runReport.Exports = append(runReport.Exports, &report.Export{Kind: "output", Path: outPath})
+	if *xlsxPath != "" {
+		runReport.Exports = append(runReport.Exports, &report.Export{Kind: "workbook", Path: csvio.ExportWorkbook(optimalSchedule, *xlsxPath)})
+	}
+	if *htmlPath != "" {
+		meta := csvio.RenderMeta{Seed: cfg.Seed, Cost: optimalSchedule.Cost, Iteration: iter}
+		runReport.Exports = append(runReport.Exports, &report.Export{Kind: "timetables", Path: csvio.ExportHTML(optimalSchedule, meta, *htmlPath)})
+	}
 
-	for _, c := range classrooms {
-		reportString = reportString + c.ID + " "
-		reportString = reportString + "["
-		for _, d := range c.AvailabilityArray {
-			switch d {
-			case 0:
-				reportString = reportString + " Monday "
-			case 1:
-				reportString = reportString + " Tuesday "
-			case 2:
-				reportString = reportString + " Wednesday "
-			case 3:
-				reportString = reportString + " Thursday "
-			case 4:
-				reportString = reportString + " Friday "
-			}
+	// Show classroom utilisation and the classrooms unassigned courses would need
+	roomReport := report.NewRoomReport(optimalSchedule, classrooms, optimalCourses, optimalLabs, cfg.RoomOccupancyRatio)
+	runReport.Rooms = roomReport
+	tt := timetable.New(optimalSchedule)
+	runReport.Stats = report.NewScheduleStats(tt)
+
+	// Files that can't be written fail the command once everything else is done
+	writeErrors := false
+	fmt.Println(runReport.Format(*reportFormat))
+	if *reportPath != "" {
+		if err := os.WriteFile(*reportPath, []byte(runReport.JSON()), 0644); err != nil {
+			fmt.Println("Err03")
+			fmt.Println(err)
+			writeErrors = true
+		}
+	}
+
+	// Print requested timetable views
+	for _, view := range timetableViews {
+		grid, err := tt.View(view)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(grid)
+	}
+
+	if *icsDir != "" {
+		err := exportCalendars(tt, *icsDir)
+		if err != nil {
+			fmt.Println("Err09")
+			fmt.Println(err)
+			writeErrors = true
 		}
-		reportString = reportString + "]\n"
 	}
 
-	reportString = reportString + "\n"
+	if *roomReportPath != "" {
+		data, err := json.MarshalIndent(roomReport, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*roomReportPath, data, 0644)
+		}
+		if err != nil {
+			fmt.Println("Err03")
+			fmt.Println(err)
+			writeErrors = true
+		}
+	}
 
-	//fmt.Println(msg)
-	reportString = reportString + msg
+	if writeErrors {
+		return exitError
+	}
 	if errorExists {
-		reportString = "Scheduling Error\n" + reportString
+		return exitInvalid
 	}
+	return exitOK
+}
 
-	c := cfg.RelativeConflictProbability / 2.0 * 100.0
-	if state == 1 {
-		c = 100
+// Print or write the changes between two schedule exports, returns the exit code
+func runDiff(args []string) int {
+	flags, ok := newCommand("diff", args)
+	if !ok {
+		return exitUsage
+	}
+	format := flags.String("format", "text", "output format: text, json or html")
+	outPath := flags.String("o", "", "write the changes to given path instead of printing them")
+	if ok, code := parseCommand(flags, args, 2); !ok {
+		return code
+	}
+	if !checkFormat(flags, *format, "text", "json", "html") {
+		return exitUsage
 	}
 
-	// Show how evil the schedule is
-	optimalSchedule.CalculateCost()
-	reportString = reportString + fmt.Sprintf("State: %d\n", state)
-	reportString = reportString + fmt.Sprintf("Cost: %d\n", optimalSchedule.Cost)
-	reportString = reportString + fmt.Sprintf("Iteration: %d\n", iter)
-	reportString = reportString + fmt.Sprintf("Sibling Compulsory Conflict Probability: %1.2f%%\n", c)
-	reportString = reportString + fmt.Sprintf("Activity Day Placement Probability: %1.2f%%\n", placementProbability*100.0)
-	reportString = reportString + fmt.Sprintf("Elapsed Time: %f ms\n", float64(end-start)/1000000.0)
-	reportString = reportString + fmt.Sprint("Exported output to: "+outPath+"\n\n")
+	exports := []*model.ScheduleJSON{}
+	for _, path := range flags.Args() {
+		export, ok := readSchedule(path)
+		if !ok {
+			return exitError
+		}
+		exports = append(exports, export)
+	}
+	diff := report.NewScheduleDiff(exports[0], exports[1])
+
+	var sb strings.Builder
+	switch *format {
+	case "text":
+		sb.WriteString(diff.String())
+	case "json":
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			fmt.Println("Err03")
+			return exitError
+		}
+		sb.Write(data)
+		sb.WriteString("\n")
+	case "html":
+		if err := diff.RenderHTML(&sb); err != nil {
+			fmt.Println("Err03")
+			return exitError
+		}
+	}
+	return writeOutput(*outPath, []byte(sb.String()))
+}
 
-	if !sufficientRooms {
-		// do something useful
-		var capacityNeeded int = 0
-		for _, c := range unassignedCourses {
-			if c.Number_of_Students > capacityNeeded {
-				capacityNeeded = c.Number_of_Students
+// Write one iCalendar feed per lecturer, classroom and cohort
+func exportCalendars(tt *timetable.Timetable, dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	grids := []*timetable.Grid{}
+	for _, lecturer := range tt.Lecturers() {
+		grids = append(grids, tt.Lecturer(lecturer))
+	}
+	for _, classroom := range tt.Classrooms() {
+		grids = append(grids, tt.Classroom(classroom))
+	}
+	for _, cohort := range tt.Cohorts() {
+		i := strings.LastIndex(cohort, " ")
+		grade, _ := strconv.Atoi(cohort[i+1:])
+		grids = append(grids, tt.Cohort(cohort[:i], grade))
+	}
+	for _, grid := range grids {
+		calendar, err := csvio.ExportICalendar(grid, cfg.SemesterStart, cfg.SemesterEnd)
+		if err != nil {
+			return err
+		}
+		name := strings.Map(func(r rune) rune {
+			if r == ' ' || r == '/' || r == '\\' {
+				return '-'
 			}
+			return r
+		}, grid.Kind+"-"+grid.Name)
+		err = os.WriteFile(filepath.Join(dir, name+".ics"), []byte(calendar), 0644)
+		if err != nil {
+			return err
 		}
-		reportString = reportString + "New classroom of capacity " + strconv.Itoa(capacityNeeded) + " needed. Please add it and re-run the program.\n"
 	}
-
-	fmt.Println(reportString)
+	return nil
 }
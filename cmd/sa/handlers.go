@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/internal/store"
 )
 
 func handleGetSchedule(ctx *gin.Context) {
@@ -21,19 +22,17 @@ func handleGetSchedule(ctx *gin.Context) {
 		Report string `json:"report"`
 	}
 
-	rows, err := scheduleRepository.Query("SELECT id, status, report FROM schedule")
+	schedules, err := scheduleStore.ListSchedules(0)
 	if err != nil {
 		ctx.Status(http.StatusInternalServerError)
 		return
 	}
 	var allScheduless []ScheduleMeta = []ScheduleMeta{}
-	for rows.Next() {
-		var meta ScheduleMeta
-		rows.Scan(&meta.Id, &meta.Status, &meta.Report)
+	for _, s := range schedules {
 		allScheduless = append(allScheduless, ScheduleMeta{
-			Id:     meta.Id,
-			Status: meta.Status,
-			Report: meta.Report,
+			Id:     s.ID,
+			Status: s.Status,
+			Report: s.Report,
 		})
 	}
 
@@ -45,42 +44,39 @@ func handleGetSchedule(ctx *gin.Context) {
 func handleGetScheduleWithId(ctx *gin.Context) {
 	id := ctx.Param("id")
 
-	rows, err := scheduleRepository.Query("SELECT data FROM schedule where id = ?", id)
-	if err != nil {
-		ctx.Status(http.StatusInternalServerError)
+	stored, err := scheduleStore.GetSchedule(id)
+	if err == store.ErrNotFound {
+		ctx.Status(http.StatusNotFound)
 		return
 	}
-	var data string
-	if rows.Next() {
-		rows.Scan(&data)
-	} else {
-		ctx.Status(http.StatusNotFound)
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": data,
+		"data": stored.Data,
 	})
 }
 
 func handleDeleteScheduleWithId(ctx *gin.Context) {
 	id := ctx.Param("id")
 
-	rows, err := scheduleRepository.Query("DELETE FROM schedule where id = ?", id)
-	if err != nil {
-		ctx.Status(http.StatusInternalServerError)
-		return
+	stored, err := scheduleStore.GetSchedule(id)
+	if err == nil {
+		err = scheduleStore.DeleteSchedule(id)
 	}
-	var data string
-	if rows.Next() {
-		rows.Scan(&data)
-	} else {
+	if err == store.ErrNotFound {
 		ctx.Status(http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": data,
+		"data": stored.Data,
 	})
 }
 
@@ -102,8 +98,8 @@ func handlePostSchedule(ctx *gin.Context) {
 	}
 	coursesFile := form.File["courses"][0]
 	classroomsFile := form.File["classrooms"][0]
-	CoursesPath := "db/" + timestamp + coursesFile.Filename
-	ClassroomsPath := "db/" + timestamp + classroomsFile.Filename
+	CoursesPath := dataDir + "/" + timestamp + coursesFile.Filename
+	ClassroomsPath := dataDir + "/" + timestamp + classroomsFile.Filename
 	ctx.SaveUploadedFile(coursesFile, CoursesPath)
 	ctx.SaveUploadedFile(classroomsFile, ClassroomsPath)
 	cfg.CoursesFile = CoursesPath
@@ -111,43 +107,42 @@ func handlePostSchedule(ctx *gin.Context) {
 
 	if form.File["reserved"] != nil {
 		priorityFile := form.File["reserved"][0]
-		PriorityPath := "db/" + timestamp + priorityFile.Filename
+		PriorityPath := dataDir + "/" + timestamp + priorityFile.Filename
 		ctx.SaveUploadedFile(priorityFile, PriorityPath)
 		cfg.PriorityFile = PriorityPath
 	}
 	if form.File["busy"] != nil {
 		busyFile := form.File["busy"][0]
-		BusyPath := "db/" + timestamp + busyFile.Filename
+		BusyPath := dataDir + "/" + timestamp + busyFile.Filename
 		ctx.SaveUploadedFile(busyFile, BusyPath)
 		cfg.BlacklistFile = BusyPath
 	}
 	if form.File["conflicts"] != nil {
 		conflictsFile := form.File["conflicts"][0]
-		ConflictsPath := "db/" + timestamp + conflictsFile.Filename
+		ConflictsPath := dataDir + "/" + timestamp + conflictsFile.Filename
 		ctx.SaveUploadedFile(conflictsFile, ConflictsPath)
 		cfg.ConflictsFile = ConflictsPath
 	}
 	if form.File["splits"] != nil {
 		splitsFile := form.File["splits"][0]
-		SplitsPath := "db/" + timestamp + splitsFile.Filename
+		SplitsPath := dataDir + "/" + timestamp + splitsFile.Filename
 		ctx.SaveUploadedFile(splitsFile, SplitsPath)
 		cfg.SplitFile = SplitsPath
 	}
 	if form.File["externals"] != nil {
 		externalsFile := form.File["externals"][0]
-		ExternalsPath := "db/" + timestamp + externalsFile.Filename
+		ExternalsPath := dataDir + "/" + timestamp + externalsFile.Filename
 		ctx.SaveUploadedFile(externalsFile, ExternalsPath)
 		cfg.ExternalFile = ExternalsPath
 	}
 
-	cfg.ExportFile = "db/generated/" + timestamp + "-schedule.csv"
+	cfg.ExportFile = dataDir + "/generated/" + timestamp + "-schedule.csv"
 	log.Printf("Generating schedule with the configuration:\n%v\n", cfg)
 
-	stmt, _ := scheduleRepository.Prepare("INSERT INTO schedule (id, data, status, report) VALUES (?, ?, ?, ?)")
-	stmt.Exec(timestamp, "", "in progress", "")
+	scheduleStore.CreateSchedule(&store.Schedule{ID: timestamp, Status: "in progress"})
 
 	saConfig := *cfg
-	saConfig.ExportFile = "db/generated/" + timestamp + "-schedule.csv"
+	saConfig.ExportFile = dataDir + "/generated/" + timestamp + "-schedule.csv"
 	go func(cfg scheduler.Configuration) {
 		cmd := exec.Command("node",
 			"main.js",
@@ -168,16 +163,14 @@ func handlePostSchedule(ctx *gin.Context) {
 		log.Printf("spawned process exited with code: %v\n", state.ExitCode())
 		if err != nil || state.ExitCode() != 0 {
 			log.Printf("%v\n", err.Error())
-			stmt, _ := scheduleRepository.Prepare("UPDATE schedule SET data = ?, status = ?, report = ? WHERE ID = ?;")
-			stmt.Exec("invalid", "failed", err.Error(), timestamp)
+			scheduleStore.FinishSchedule(timestamp, "invalid", "failed", err.Error())
 		} else {
 			file, _ := os.Open(saConfig.ExportFile)
 			defer file.Close()
 			data, _ := io.ReadAll(file)
 			formatted := string(data)
 			formatted = strings.ReplaceAll(formatted, ";", ",") + "\n"
-			stmt, _ := scheduleRepository.Prepare("UPDATE schedule SET data = ?, status = ?, report = ? WHERE ID = ?;")
-			stmt.Exec(formatted, "success", "success", timestamp)
+			scheduleStore.FinishSchedule(timestamp, formatted, "success", "success")
 		}
 	}(saConfig)
 
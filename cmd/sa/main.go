@@ -1,24 +1,38 @@
 package main
 
 import (
-	"database/sql"
+	"flag"
+	"os"
+	"path/filepath"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/rhyrak/go-schedule/internal/store"
 )
 
 const (
 	port = ":3001"
 )
 
-var scheduleRepository *sql.DB
+var scheduleStore store.ScheduleStore
+
+// Directory of uploaded input files and generated schedules
+var dataDir string
 
 func main() {
+	storeConfig := store.Config{}
+	flag.StringVar(&storeConfig.Driver, "store", envOr("SCHEDULE_STORE", "sqlite"), "storage backend: sqlite, postgres, fs or memory")
+	flag.StringVar(&storeConfig.DSN, "store-dsn", os.Getenv("SCHEDULE_STORE_DSN"), "database file, postgres connection string or directory of the store")
+	flag.StringVar(&dataDir, "data-dir", envOr("SCHEDULE_DATA_DIR", "db"), "directory of uploaded input files and generated schedules")
+	flag.Parse()
+
 	var err error
-	scheduleRepository, err = initDB()
+	scheduleStore, err = store.Open(storeConfig)
 	if err != nil {
 		panic(err)
 	}
+	if err = os.MkdirAll(filepath.Join(dataDir, "generated"), 0755); err != nil {
+		panic(err)
+	}
 	r := gin.Default()
 
 	r.Use(corsMiddleware)
@@ -31,24 +45,12 @@ func main() {
 	r.Run(port)
 }
 
-func initDB() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", "./scheduler.db")
-	if err != nil {
-		return nil, err
+// Value of the environment variable, or def if it is unset
+func envOr(key string, def string) string {
+	if value, found := os.LookupEnv(key); found {
+		return value
 	}
-
-	if err = db.Ping(); err != nil {
-		return nil, err
-	}
-	statement, err := db.Prepare("CREATE TABLE IF NOT EXISTS schedule (id INTEGER PRIMARY KEY, data TEXT, status TEXT, report TEXT)")
-	if err != nil {
-		return nil, err
-	}
-	if _, err = statement.Exec(); err != nil {
-		return nil, err
-	}
-
-	return db, nil
+	return def
 }
 
 func corsMiddleware(c *gin.Context) {
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+)
+
+// Scheduler parameters a run can be given, unset ones keep their value. Input file paths
+// are set by the server.
+type runConfig struct {
+	NumberOfDays                *int     `json:"number_of_days"`
+	TimeSlotDuration            *int     `json:"time_slot_duration"`
+	TimeSlotCount               *int     `json:"time_slot_count"`
+	IterSoftLimit               *int     `json:"iter_soft_limit"`
+	DepartmentCongestionLimit   *int     `json:"department_congestion_limit"`
+	ActivityDay                 *int     `json:"activity_day"`
+	RelativeConflictProbability *float64 `json:"relative_conflict_probability"`
+	RoomOccupancyRatio          *float64 `json:"room_occupancy_ratio"`
+	MaxLecturerDailyHours       *int     `json:"max_lecturer_daily_hours"`
+	MaxLecturerConsecutiveHours *int     `json:"max_lecturer_consecutive_hours"`
+	MaxLecturerDays             *int     `json:"max_lecturer_days"`
+	MinLecturerDays             *int     `json:"min_lecturer_days"`
+	NoEarlyAfterLate            *bool    `json:"no_early_after_late"`
+	Seed                        *int64   `json:"seed"`
+	SemesterStart               *string  `json:"semester_start"` // YYYY-MM-DD
+	SemesterEnd                 *string  `json:"semester_end"`
+	IgnoredCourses              []string `json:"ignored_courses"` // An empty list ignores none
+}
+
+// Apply the JSON configuration of the config form value or file, if there is one, to cfg
+// and check the result
+func formConfig(ctx *gin.Context, cfg *scheduler.Configuration) bool {
+	data := []byte(ctx.PostForm("config"))
+	if header, err := ctx.FormFile("config"); err == nil {
+		file, err := header.Open()
+		if err != nil {
+			ctx.String(http.StatusBadRequest, err.Error())
+			return false
+		}
+		data, err = io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			ctx.String(http.StatusBadRequest, err.Error())
+			return false
+		}
+	}
+
+	if strings.TrimSpace(string(data)) != "" {
+		rc := &runConfig{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(rc); err != nil {
+			ctx.String(http.StatusBadRequest, "invalid configuration: "+err.Error())
+			return false
+		}
+		rc.apply(cfg)
+	}
+
+	if errorExists, errorString := scheduler.CheckConfiguration(cfg); errorExists {
+		ctx.String(http.StatusBadRequest, "invalid configuration:\n"+errorString)
+		return false
+	}
+	return true
+}
+
+func (rc *runConfig) apply(cfg *scheduler.Configuration) {
+	setInt(&cfg.NumberOfDays, rc.NumberOfDays)
+	setInt(&cfg.TimeSlotDuration, rc.TimeSlotDuration)
+	setInt(&cfg.TimeSlotCount, rc.TimeSlotCount)
+	setInt(&cfg.IterSoftLimit, rc.IterSoftLimit)
+	setInt(&cfg.DepartmentCongestionLimit, rc.DepartmentCongestionLimit)
+	setInt(&cfg.ActivityDay, rc.ActivityDay)
+	setInt(&cfg.MaxLecturerDailyHours, rc.MaxLecturerDailyHours)
+	setInt(&cfg.MaxLecturerConsecutiveHours, rc.MaxLecturerConsecutiveHours)
+	setInt(&cfg.MaxLecturerDays, rc.MaxLecturerDays)
+	setInt(&cfg.MinLecturerDays, rc.MinLecturerDays)
+	if rc.RelativeConflictProbability != nil {
+		cfg.RelativeConflictProbability = *rc.RelativeConflictProbability
+	}
+	if rc.RoomOccupancyRatio != nil {
+		cfg.RoomOccupancyRatio = *rc.RoomOccupancyRatio
+	}
+	if rc.NoEarlyAfterLate != nil {
+		cfg.NoEarlyAfterLate = *rc.NoEarlyAfterLate
+	}
+	if rc.Seed != nil {
+		cfg.Seed = *rc.Seed
+	}
+	if rc.SemesterStart != nil {
+		cfg.SemesterStart = *rc.SemesterStart
+	}
+	if rc.SemesterEnd != nil {
+		cfg.SemesterEnd = *rc.SemesterEnd
+	}
+	if rc.IgnoredCourses != nil {
+		cfg.IgnoredCourses = rc.IgnoredCourses
+	}
+}
+
+func setInt(field *int, value *int) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+// Configuration the schedule given by the id parameter was generated with
+func handleGetConfig(ctx *gin.Context) {
+	cfg, ok := loadConfig(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, &runConfig{
+		NumberOfDays:                &cfg.NumberOfDays,
+		TimeSlotDuration:            &cfg.TimeSlotDuration,
+		TimeSlotCount:               &cfg.TimeSlotCount,
+		IterSoftLimit:               &cfg.IterSoftLimit,
+		DepartmentCongestionLimit:   &cfg.DepartmentCongestionLimit,
+		ActivityDay:                 &cfg.ActivityDay,
+		RelativeConflictProbability: &cfg.RelativeConflictProbability,
+		RoomOccupancyRatio:          &cfg.RoomOccupancyRatio,
+		MaxLecturerDailyHours:       &cfg.MaxLecturerDailyHours,
+		MaxLecturerConsecutiveHours: &cfg.MaxLecturerConsecutiveHours,
+		MaxLecturerDays:             &cfg.MaxLecturerDays,
+		MinLecturerDays:             &cfg.MinLecturerDays,
+		NoEarlyAfterLate:            &cfg.NoEarlyAfterLate,
+		Seed:                        &cfg.Seed,
+		SemesterStart:               &cfg.SemesterStart,
+		SemesterEnd:                 &cfg.SemesterEnd,
+		IgnoredCourses:              cfg.IgnoredCourses,
+	})
+}
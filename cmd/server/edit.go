@@ -0,0 +1,368 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rhyrak/go-schedule/internal/csvio"
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/internal/store"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Session of a stored schedule, identified like a lock
+type sessionRef struct {
+	Department string `json:"department"`
+	CourseCode string `json:"course_code"`
+	Section    int    `json:"section"` // 0 matches any section
+	Part       int    `json:"part"`    // Split part starting from 1, 0 for the first
+	Lab        bool   `json:"lab"`
+}
+
+type editRequest struct {
+	Course       sessionRef  `json:"course"`
+	Other        *sessionRef `json:"other"`         // Session to swap with
+	Day          string      `json:"day"`           // Day to move to
+	StartingTime string      `json:"starting_time"` // HH:MM to move to
+	Classroom    string      `json:"classroom"`     // Classroom to move to, picked like the generator if empty
+	Confirm      bool        `json:"confirm"`       // Store the edited schedule
+}
+
+type editResult struct {
+	Action     string             `json:"action"`
+	Violations []string           `json:"violations"` // Constraints broken by the edited sessions
+	Valid      bool               `json:"valid"`
+	Validation string             `json:"validation"` // Validator messages of the edited schedule
+	Checks     []*scheduler.Check `json:"checks"`     // Validator checks of the edited schedule with their violations
+	Unassigned int                `json:"unassigned"`
+	Cost       int                `json:"cost"`
+	CostDelta  int                `json:"cost_delta"`
+	Committed  bool               `json:"committed"`
+}
+
+// Move a session to another day, time and classroom
+func handleMoveSession(ctx *gin.Context) {
+	editSchedule(ctx, "move")
+}
+
+// Swap the days, times and classrooms of two sessions
+func handleSwapSessions(ctx *gin.Context) {
+	editSchedule(ctx, "swap")
+}
+
+// Remove a session from the schedule, it can be moved back in later
+func handleUnassignSession(ctx *gin.Context) {
+	editSchedule(ctx, "unassign")
+}
+
+// Apply a manual edit to the schedule given by the id parameter and check the edited
+// sessions like the generator and the whole schedule like the validator does. The edit
+// is only stored if confirmed, the previous schedule is kept in the history to undo it.
+func editSchedule(ctx *gin.Context, action string) {
+	id := ctx.Param("id")
+	req := &editRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if action == "swap" && req.Other == nil {
+		ctx.String(http.StatusBadRequest, "other session to swap with is required")
+		return
+	}
+
+	data, ok := loadData(ctx, id)
+	if !ok {
+		return
+	}
+	cfg, ok := loadConfig(ctx, id)
+	if !ok {
+		return
+	}
+	locks, ok := loadLocks(ctx, id)
+	if !ok {
+		return
+	}
+	export, err := csvio.ParseScheduleExport(data, cfg.TimeSlotDuration, cfg.TimeSlotCount)
+	if err != nil {
+		ctx.String(http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	rows := export.Rows()
+	placements := exportPlacements(export)
+
+	day, slot := -1, -1
+	if action == "move" {
+		if day, slot, ok = csvio.ParseDayTime(req.Day, req.StartingTime, cfg); !ok {
+			ctx.String(http.StatusBadRequest, "invalid day "+req.Day+" or starting time "+req.StartingTime)
+			return
+		}
+	}
+
+	// Edited sessions are found in the schedule, moved ones may be unassigned
+	refs := []*sessionRef{&req.Course}
+	if action == "swap" {
+		refs = append(refs, req.Other)
+	}
+	edited := []int{}
+	for _, ref := range refs {
+		i := slices.IndexFunc(placements, func(p *model.PlacementJSON) bool { return matchesSession(p.Course, ref) })
+		if i < 0 && action != "move" {
+			ctx.String(http.StatusNotFound, sessionName(ref)+" is not in the schedule")
+			return
+		}
+		if i >= 0 && slices.Contains(edited, i) {
+			ctx.String(http.StatusBadRequest, "can't swap a session with itself")
+			return
+		}
+		edited = append(edited, i)
+	}
+	var room *model.Classroom
+
+	classrooms, errorExists, errorString := csvio.LoadClassrooms(cfg.ClassroomsFile, ';')
+	if !errorExists && req.Classroom != "" {
+		if room = findClassroom(classrooms, req.Classroom); room == nil {
+			ctx.String(http.StatusBadRequest, "unknown classroom "+req.Classroom)
+			return
+		}
+	}
+	courses, labs, _, _, conflicts, _, _, err2, errorString2 := csvio.LoadCourses(cfg, ';', cfg.IgnoredCourses)
+	if errorExists || err2 {
+		ctx.String(http.StatusInternalServerError, "Failed to load the inputs of the schedule:\n"+errorString+errorString2)
+		return
+	}
+	courses, labs = scheduler.InitRuntimeProperties(cfg, courses, labs, 1, conflicts)
+
+	// Cost of the stored schedule
+	before, importErrors, importReport := csvio.ImportRows(rows, cfg, courses, labs, classrooms)
+	unassignedBefore := len(scheduler.ValidateSchedule(courses, labs, before).Unassigned)
+	before.CalculateCost()
+
+	// Place the other sessions as they are, then the edited ones as requested
+	rest := []*model.ScheduleCSVRow{}
+	for i, row := range rows {
+		if !slices.Contains(edited, i) {
+			rest = append(rest, row)
+		}
+	}
+	schedule, _, _ := csvio.ImportRows(rest, cfg, courses, labs, classrooms)
+	violations := []string{}
+	if importErrors {
+		// Stored rows that no longer match the inputs stay unassigned
+		for _, line := range strings.Split(strings.TrimSpace(importReport), "\n") {
+			violations = append(violations, strings.TrimPrefix(line, "- "))
+		}
+	}
+	description := action
+	for n, i := range edited {
+		var course *model.Course
+		var lab *model.Laboratory
+		var name string
+		if i < 0 {
+			course, lab = unplacedSession(refs[n], courses, labs)
+			if course == nil {
+				ctx.String(http.StatusNotFound, sessionName(refs[n])+" is not in the schedule or its inputs")
+				return
+			}
+			name = course.DisplayName + " " + course.Department + " " + fmt.Sprint(course.Class)
+		} else {
+			course, lab = csvio.MatchRow(rows[i], courses, labs)
+			if course == nil {
+				ctx.String(http.StatusUnprocessableEntity, sessionName(refs[n])+" doesn't match the inputs of the schedule")
+				return
+			}
+			name = rows[i].CourseCode + " " + rows[i].Department + " " + fmt.Sprint(rows[i].Class)
+			if isLockedSession(locks, placements[i].Course) {
+				violations = append(violations, name+": session is locked")
+			}
+		}
+		description = description + " " + name
+
+		toDay, toSlot, toRoom := day, slot, room
+		switch action {
+		case "swap":
+			other := rows[edited[1-n]]
+			toDay, toSlot, toRoom = other.Day, other.Time/cfg.TimeSlotDuration, findClassroom(classrooms, other.Classrooms)
+		case "unassign":
+			continue
+		}
+		for _, v := range scheduler.PlaceEdited(course, lab, schedule, toDay, toSlot, toRoom, classrooms, cfg.RoomOccupancyRatio) {
+			violations = append(violations, name+": "+v)
+		}
+	}
+	if action == "move" {
+		description = description + " to " + req.Day + " " + req.StartingTime
+	}
+
+	validation := scheduler.ValidateSchedule(courses, labs, schedule)
+	valid, msg, unassigned := validation.Valid, validation.Message(), len(validation.Unassigned)
+	schedule.CalculateCost()
+	result := &editResult{
+		Action:     description,
+		Violations: violations,
+		Valid:      valid,
+		Validation: msg,
+		Checks:     validation.Checks,
+		Unassigned: unassigned,
+		Cost:       schedule.Cost,
+		CostDelta:  schedule.Cost - before.Cost,
+	}
+	if unassigned != unassignedBefore {
+		result.Validation = fmt.Sprintf("Unassigned: %d -> %d\n", unassignedBefore, unassigned) + result.Validation
+	}
+
+	if req.Confirm {
+		if err := scheduleStore.CommitEdit(id, data, csvio.ExportScheduleJSONString(schedule), description); err != nil {
+			ctx.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		result.Committed = true
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// Restore the schedule given by the id parameter to before its last confirmed edit
+func handleUndoEdit(ctx *gin.Context) {
+	entry, err := scheduleStore.UndoEdit(ctx.Param("id"))
+	if err == store.ErrNotFound {
+		ctx.String(http.StatusNotFound, "nothing to undo")
+		return
+	}
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"undone": entry.Action,
+	})
+}
+
+// Confirmed edits of the schedule given by the id parameter, the latest first
+func handleGetHistory(ctx *gin.Context) {
+	history, err := scheduleStore.ListHistory(ctx.Param("id"))
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"history": history,
+	})
+}
+
+// Load the configuration the schedule with given id was created with, it points to its inputs
+func loadConfig(ctx *gin.Context, id string) (*scheduler.Configuration, bool) {
+	stored, ok := loadSchedule(ctx, id)
+	if !ok {
+		return nil, false
+	}
+	if stored.Config == "" {
+		ctx.String(http.StatusConflict, "inputs of the schedule are not stored, generate or repair it again to edit")
+		return nil, false
+	}
+	return storedConfig(ctx, stored)
+}
+
+// Configuration the schedule was generated with, the defaults for schedules stored
+// without one
+func storedConfig(ctx *gin.Context, stored *store.Schedule) (*scheduler.Configuration, bool) {
+	cfg := scheduler.NewDefaultConfiguration()
+	if stored.Config == "" {
+		return cfg, true
+	}
+	if err := json.Unmarshal([]byte(stored.Config), cfg); err != nil {
+		ctx.String(http.StatusUnprocessableEntity, err.Error())
+		return nil, false
+	}
+	return cfg, true
+}
+
+// Load the configuration of the schedule with given id, see storedConfig
+func loadRunConfig(ctx *gin.Context, id string) (*scheduler.Configuration, bool) {
+	stored, ok := loadSchedule(ctx, id)
+	if !ok {
+		return nil, false
+	}
+	return storedConfig(ctx, stored)
+}
+
+// Format the configuration to be stored with a schedule
+func configString(cfg *scheduler.Configuration) string {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Placements of the export in the order of its rows
+func exportPlacements(export *model.ScheduleJSON) []*model.PlacementJSON {
+	placements := []*model.PlacementJSON{}
+	for _, day := range export.Days {
+		for _, slot := range day.Slots {
+			placements = append(placements, slot.Placements...)
+		}
+	}
+	return placements
+}
+
+func matchesSession(c *model.CourseJSON, ref *sessionRef) bool {
+	return c.CourseCode == ref.CourseCode && c.Department == ref.Department && c.Laboratory == ref.Lab &&
+		(ref.Section == 0 || c.Section == ref.Section) && (ref.Lab || c.PartIndex == max(ref.Part-1, 0))
+}
+
+// Find an unassigned course or laboratory of the inputs
+func unplacedSession(ref *sessionRef, courses []*model.Course, labs []*model.Laboratory) (*model.Course, *model.Laboratory) {
+	if ref.Lab {
+		for _, l := range labs {
+			if !l.Placed && l.Course_Code == ref.CourseCode && l.Department == ref.Department && (ref.Section == 0 || l.Section == ref.Section) {
+				return scheduler.LabCourse(l), l
+			}
+		}
+		return nil, nil
+	}
+	for _, c := range courses {
+		if !c.Placed && c.Course_Code == ref.CourseCode && c.Department == ref.Department &&
+			(ref.Section == 0 || c.Section == ref.Section) && c.PartIndex == max(ref.Part-1, 0) {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func isLockedSession(locks []*model.Lock, c *model.CourseJSON) bool {
+	for _, l := range locks {
+		lab := slices.Contains([]string{"yes", "true", "1"}, strings.ToLower(strings.TrimSpace(l.LabSTR)))
+		if matchesSession(c, &sessionRef{Department: l.Department, CourseCode: l.CourseCodeSTR, Section: l.Section, Part: l.Part, Lab: lab}) {
+			return true
+		}
+	}
+	return false
+}
+
+func findClassroom(rooms []*model.Classroom, id string) *model.Classroom {
+	for _, r := range rooms {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+func sessionName(ref *sessionRef) string {
+	name := ref.Department + " " + ref.CourseCode
+	if ref.Lab {
+		name = name + " lab"
+	}
+	if ref.Section != 0 {
+		name = name + fmt.Sprintf(" section %d", ref.Section)
+	}
+	if ref.Part > 1 {
+		name = name + fmt.Sprintf(" part %d", ref.Part)
+	}
+	return name
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"io"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/internal/store"
+)
+
+// Event sent to the listeners of a schedule, the stream ends after a final one
+type scheduleEvent struct {
+	name  string // progress or job
+	data  any
+	final bool
+}
+
+// Listeners of running schedules and the latest progress of each, for new listeners
+var events = struct {
+	mu        sync.Mutex
+	listeners map[string]map[chan *scheduleEvent]bool
+	progress  map[string]*scheduler.Progress
+}{
+	listeners: map[string]map[chan *scheduleEvent]bool{},
+	progress:  map[string]*scheduler.Progress{},
+}
+
+// Send the progress of the generator to the listeners of the schedule
+func publishProgress(scheduleID string, p *scheduler.Progress) {
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	events.progress[scheduleID] = p
+	send(scheduleID, &scheduleEvent{name: "progress", data: p})
+}
+
+// Send the new state of the job to the listeners of the schedule, ending their streams
+// once the job is finished
+func publishJobState(scheduleID string, state string, errorMessage string) {
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	final := isFinished(state)
+	if final {
+		delete(events.progress, scheduleID)
+	}
+	send(scheduleID, &scheduleEvent{name: "job", data: gin.H{"state": state, "error": errorMessage}, final: final})
+}
+
+// Listeners that fall behind miss events, except for final ones
+func send(scheduleID string, ev *scheduleEvent) {
+	for ch := range events.listeners[scheduleID] {
+		if ev.final {
+			// Make room for the final event
+			select {
+			case <-ch:
+			default:
+			}
+		}
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func isFinished(state string) bool {
+	return state == store.JobSucceeded || state == store.JobFailed || state == store.JobCancelled
+}
+
+// Stream the progress and job state of the schedule given by the id parameter as
+// server-sent events until its job is finished
+func handleGetEvents(ctx *gin.Context) {
+	id := ctx.Param("id")
+	ch := make(chan *scheduleEvent, 16)
+	events.mu.Lock()
+	if events.listeners[id] == nil {
+		events.listeners[id] = map[chan *scheduleEvent]bool{}
+	}
+	events.listeners[id][ch] = true
+	latest := events.progress[id]
+	events.mu.Unlock()
+	defer func() {
+		events.mu.Lock()
+		delete(events.listeners[id], ch)
+		if len(events.listeners[id]) == 0 {
+			delete(events.listeners, id)
+		}
+		events.mu.Unlock()
+	}()
+
+	// The job is looked up after listening so a state change in between isn't missed
+	job, ok := loadJob(ctx)
+	if !ok {
+		return
+	}
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.SSEvent("job", gin.H{"state": job.State, "error": job.Error})
+	if isFinished(job.State) {
+		return
+	}
+	if latest != nil {
+		ctx.SSEvent("progress", latest)
+	}
+	ctx.Writer.Flush()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case ev := <-ch:
+			ctx.SSEvent(ev.name, ev.data)
+			return !ev.final
+		case <-ctx.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -1,35 +1,47 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rhyrak/go-schedule/internal/csvio"
+	"github.com/rhyrak/go-schedule/internal/report"
 	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/internal/store"
+	"github.com/rhyrak/go-schedule/internal/timetable"
+	"github.com/rhyrak/go-schedule/pkg/model"
 )
 
 func handleGetSchedule(ctx *gin.Context) {
 	type ScheduleMeta struct {
-		Id     string `json:"id"`
-		Status string `json:"status"`
-		Report string `json:"report"`
+		Id      string          `json:"id"`
+		Status  string          `json:"status"`
+		Report  json.RawMessage `json:"report"`  // Report object, a string for schedules created before typed reports
+		Project int64           `json:"project"` // 0 for schedules created before projects
+		Version int64           `json:"version"` // Input version the schedule was generated from
 	}
 
-	rows, err := scheduleRepository.Query("SELECT id, status, report FROM schedule")
+	schedules, err := scheduleStore.ListSchedules(0)
 	if err != nil {
 		ctx.Status(http.StatusInternalServerError)
 		return
 	}
 	var allScheduless []ScheduleMeta = []ScheduleMeta{}
-	for rows.Next() {
-		var meta ScheduleMeta
-		rows.Scan(&meta.Id, &meta.Status, &meta.Report)
+	for _, s := range schedules {
 		allScheduless = append(allScheduless, ScheduleMeta{
-			Id:     meta.Id,
-			Status: meta.Status,
-			Report: meta.Report,
+			Id:      s.ID,
+			Status:  s.Status,
+			Report:  reportJSON(s.Report),
+			Project: s.ProjectID,
+			Version: s.VersionID,
 		})
 	}
 
@@ -39,111 +51,486 @@ func handleGetSchedule(ctx *gin.Context) {
 }
 
 func handleGetScheduleWithId(ctx *gin.Context) {
-	id := ctx.Param("id")
+	data, ok := loadData(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	// Schedules are stored as JSON exports, older ones as CSV
+	if !strings.HasPrefix(data, "{") {
+		ctx.JSON(http.StatusOK, gin.H{
+			"format": "csv",
+			"data":   data,
+		})
+		return
+	}
+	if ctx.Query("format") == "csv" {
+		scheduleRows, err := csvio.ParseScheduleData(data)
+		if err == nil {
+			data, err = csvio.ExportRowsString(scheduleRows)
+		}
+		if err != nil {
+			ctx.String(http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"format": "csv",
+			"data":   data,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"format": "json",
+		"data":   json.RawMessage(data),
+	})
+}
+
+func handleGetTimetable(ctx *gin.Context) {
+	tt, ok := loadTimetable(ctx)
+	if !ok {
+		return
+	}
+	if ctx.Query("lecturer") == "" && ctx.Query("classroom") == "" && ctx.Query("department") == "" {
+		// List available views
+		ctx.JSON(http.StatusOK, gin.H{
+			"lecturers":  tt.Lecturers(),
+			"classrooms": tt.Classrooms(),
+			"cohorts":    tt.Cohorts(),
+		})
+		return
+	}
+	grid, ok := queryGrid(ctx, tt)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, grid)
+}
 
-	rows, err := scheduleRepository.Query("SELECT data FROM schedule where id = ?", id)
+func handleGetICS(ctx *gin.Context) {
+	tt, ok := loadTimetable(ctx)
+	if !ok {
+		return
+	}
+	grid, ok := queryGrid(ctx, tt)
+	if !ok {
+		return
+	}
+	cfg, ok := loadRunConfig(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+	start := ctx.DefaultQuery("start", cfg.SemesterStart)
+	end := ctx.DefaultQuery("end", cfg.SemesterEnd)
+	calendar, err := csvio.ExportICalendar(grid, start, end)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filename := strings.ReplaceAll(grid.Kind+"-"+grid.Name, " ", "-") + ".ics"
+	ctx.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(calendar))
+}
+
+func handleGetXLSX(ctx *gin.Context) {
+	scheduleRows, ok := loadRows(ctx)
+	if !ok {
+		return
+	}
+	cfg, ok := loadRunConfig(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	err := csvio.WriteWorkbook(&buf, scheduleRows, cfg.NumberOfDays, cfg.TimeSlotDuration, cfg.TimeSlotCount)
 	if err != nil {
 		ctx.Status(http.StatusInternalServerError)
 		return
 	}
-	var data string
-	if rows.Next() {
-		rows.Scan(&data)
+
+	ctx.Header("Content-Disposition", "attachment; filename=\"schedule-"+ctx.Param("id")+".xlsx\"")
+	ctx.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
+}
+
+func handleGetHTML(ctx *gin.Context) {
+	scheduleRows, ok := loadRows(ctx)
+	if !ok {
+		return
+	}
+
+	// Generation details are read back from the stored report
+	stored, ok := loadSchedule(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+	cfg, ok := storedConfig(ctx, stored)
+	if !ok {
+		return
+	}
+	meta := csvio.RenderMeta{}
+	if timestamp, err := strconv.ParseInt(ctx.Param("id"), 10, 64); err == nil {
+		meta.Generated = time.Unix(timestamp, 0)
+	}
+	if runReport, err := report.ParseReport(stored.Report); err == nil && runReport.Run != nil {
+		meta.Seed, meta.Cost, meta.Iteration = runReport.Run.Seed, runReport.Run.Cost, runReport.Run.Iteration
 	} else {
-		ctx.Status(http.StatusNotFound)
+		for _, line := range strings.Split(stored.Report, "\n") {
+			fmt.Sscanf(line, "Seed: %d", &meta.Seed)
+			fmt.Sscanf(line, "Cost: %d", &meta.Cost)
+			fmt.Sscanf(line, "Iteration: %d", &meta.Iteration)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := csvio.RenderHTML(&buf, scheduleRows, cfg.NumberOfDays, cfg.TimeSlotDuration, cfg.TimeSlotCount, meta)
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
 		return
 	}
 
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// Report of the run that created the schedule as JSON, text or Markdown given by the
+// format query. Reports stored before typed reports are served as text only.
+func handleGetReport(ctx *gin.Context) {
+	stored, ok := loadSchedule(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "text" && format != "markdown" {
+		ctx.String(http.StatusBadRequest, "unknown format "+format+", use json, text or markdown")
+		return
+	}
+	runReport, err := report.ParseReport(stored.Report)
+	if err != nil {
+		if format != "text" {
+			ctx.String(http.StatusNotFound, "the schedule has a text report only")
+			return
+		}
+		ctx.String(http.StatusOK, stored.Report)
+		return
+	}
+	switch format {
+	case "text":
+		ctx.String(http.StatusOK, runReport.String())
+	case "markdown":
+		ctx.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(runReport.Markdown()))
+	default:
+		ctx.JSON(http.StatusOK, runReport)
+	}
+}
+
+// Locked sessions stored with the schedule, used when it is repaired
+func handleGetLocks(ctx *gin.Context) {
+	locks, ok := loadLocks(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": data,
+		"locks": locks,
 	})
 }
 
-func handleDeleteScheduleWithId(ctx *gin.Context) {
+// Replace the locked sessions of the schedule. Locks without a day and starting time take
+// the day, time and classroom the session has in the schedule.
+func handlePutLocks(ctx *gin.Context) {
 	id := ctx.Param("id")
+	locks := []*model.Lock{}
+	if err := ctx.ShouldBindJSON(&locks); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, ok := loadData(ctx, id)
+	if !ok {
+		return
+	}
+	cfg, ok := loadRunConfig(ctx, id)
+	if !ok {
+		return
+	}
+	export, err := csvio.ParseScheduleExport(data, cfg.TimeSlotDuration, cfg.TimeSlotCount)
+	if err != nil {
+		ctx.String(http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	for i, l := range locks {
+		if l.DaySTR != "" || l.StartingTimeSTR != "" {
+			continue
+		}
+		if !lockPlacement(export, l) {
+			ctx.String(http.StatusUnprocessableEntity, fmt.Sprintf("lock %d: %s %s is not in the schedule", i+1, l.Department, l.CourseCodeSTR))
+			return
+		}
+	}
 
-	rows, err := scheduleRepository.Query("DELETE FROM schedule where id = ?", id)
+	stored, err := json.Marshal(locks)
 	if err != nil {
 		ctx.Status(http.StatusInternalServerError)
 		return
 	}
-	var data string
-	if rows.Next() {
-		rows.Scan(&data)
-	} else {
+	if err = scheduleStore.SetScheduleLocks(id, string(stored)); err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"locks": locks,
+	})
+}
+
+// Copy the day, time and classroom of the locked session from the schedule
+func lockPlacement(export *model.ScheduleJSON, l *model.Lock) bool {
+	lab := slices.Contains([]string{"yes", "true", "1"}, strings.ToLower(strings.TrimSpace(l.LabSTR)))
+	for _, day := range export.Days {
+		for _, slot := range day.Slots {
+			for _, p := range slot.Placements {
+				c := p.Course
+				if c.CourseCode != l.CourseCodeSTR || c.Department != l.Department || c.Laboratory != lab ||
+					(l.Section != 0 && c.Section != l.Section) || (!lab && c.PartIndex != max(l.Part-1, 0)) {
+					continue
+				}
+				l.DaySTR = day.Name
+				l.StartingTimeSTR = p.Start
+				if l.ClassroomSTR == "" && c.Environment == "classroom" {
+					l.ClassroomSTR = p.Classroom
+				}
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Load the locked sessions stored with the schedule with given id
+func loadLocks(ctx *gin.Context, id string) ([]*model.Lock, bool) {
+	stored, ok := loadSchedule(ctx, id)
+	if !ok {
+		return nil, false
+	}
+
+	locks := []*model.Lock{}
+	if stored.Locks != "" {
+		if err := json.Unmarshal([]byte(stored.Locks), &locks); err != nil {
+			ctx.String(http.StatusUnprocessableEntity, err.Error())
+			return nil, false
+		}
+	}
+	return locks, true
+}
+
+// Changes from the schedule given by id to the one given by other, as JSON, text or HTML
+func handleGetDiff(ctx *gin.Context) {
+	exports := []*model.ScheduleJSON{}
+	for _, id := range []string{ctx.Param("id"), ctx.Param("other")} {
+		data, ok := loadData(ctx, id)
+		if !ok {
+			return
+		}
+		// Each schedule is read on the time grid it was generated with
+		cfg, ok := loadRunConfig(ctx, id)
+		if !ok {
+			return
+		}
+		export, err := csvio.ParseScheduleExport(data, cfg.TimeSlotDuration, cfg.TimeSlotCount)
+		if err != nil {
+			ctx.String(http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		exports = append(exports, export)
+	}
+	diff := report.NewScheduleDiff(exports[0], exports[1])
+
+	switch ctx.DefaultQuery("format", "json") {
+	case "json":
+		ctx.JSON(http.StatusOK, diff)
+	case "text":
+		ctx.String(http.StatusOK, diff.String())
+	case "html":
+		var buf bytes.Buffer
+		if err := diff.RenderHTML(&buf); err != nil {
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+	default:
+		ctx.String(http.StatusBadRequest, "format must be json, text or html")
+	}
+}
+
+// Load the timetable of the schedule given by the id parameter
+func loadTimetable(ctx *gin.Context) (*timetable.Timetable, bool) {
+	scheduleRows, ok := loadRows(ctx)
+	if !ok {
+		return nil, false
+	}
+	cfg, ok := loadRunConfig(ctx, ctx.Param("id"))
+	if !ok {
+		return nil, false
+	}
+	return timetable.FromRows(scheduleRows, cfg.NumberOfDays, cfg.TimeSlotDuration, cfg.TimeSlotCount), true
+}
+
+// Load the exported rows of the schedule given by the id parameter
+func loadRows(ctx *gin.Context) ([]*model.ScheduleCSVRow, bool) {
+	data, ok := loadData(ctx, ctx.Param("id"))
+	if !ok {
+		return nil, false
+	}
+
+	scheduleRows, err := csvio.ParseScheduleData(data)
+	if err != nil {
+		ctx.String(http.StatusUnprocessableEntity, err.Error())
+		return nil, false
+	}
+	return scheduleRows, true
+}
+
+// Load the stored data of the schedule with given id
+func loadData(ctx *gin.Context, id string) (string, bool) {
+	stored, ok := loadSchedule(ctx, id)
+	if !ok {
+		return "", false
+	}
+	return stored.Data, true
+}
+
+// Load the schedule with given id from the repository
+func loadSchedule(ctx *gin.Context, id string) (*store.Schedule, bool) {
+	stored, err := scheduleStore.GetSchedule(id)
+	if err == store.ErrNotFound {
 		ctx.Status(http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return nil, false
+	}
+	return stored, true
+}
+
+// Stored report as JSON, reports stored before typed reports become a JSON string
+func reportJSON(text string) json.RawMessage {
+	if strings.HasPrefix(text, "{") && json.Valid([]byte(text)) {
+		return json.RawMessage(text)
+	}
+	data, _ := json.Marshal(text)
+	return data
+}
+
+// Select the lecturer, classroom or department and grade grid given in the query
+func queryGrid(ctx *gin.Context, tt *timetable.Timetable) (*timetable.Grid, bool) {
+	if lecturer := ctx.Query("lecturer"); lecturer != "" {
+		return tt.Lecturer(lecturer), true
+	} else if classroom := ctx.Query("classroom"); classroom != "" {
+		return tt.Classroom(classroom), true
+	} else if department := ctx.Query("department"); department != "" {
+		grade, err := strconv.Atoi(ctx.Query("grade"))
+		if err != nil {
+			ctx.String(http.StatusBadRequest, "invalid grade")
+			return nil, false
+		}
+		return tt.Cohort(department, grade), true
+	}
+	ctx.String(http.StatusBadRequest, "one of lecturer, classroom or department and grade is required")
+	return nil, false
+}
+
+func handleDeleteScheduleWithId(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	stored, ok := loadSchedule(ctx, id)
+	if !ok {
+		return
+	}
+	if err := scheduleStore.DeleteSchedule(id); err != nil {
+		ctx.Status(http.StatusInternalServerError)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": data,
+		"data": stored.Data,
 	})
 }
 
+// Generate a schedule from the uploaded input files with the configuration of the optional
+// config form value or file
 func handlePostSchedule(ctx *gin.Context) {
-	timestamp := fmt.Sprintf("%d", time.Now().Unix())
 	cfg := scheduler.NewDefaultConfiguration()
-
-	form, err := ctx.MultipartForm()
-	if err != nil {
-		log.Printf("error reading form: %v\n", err.Error())
-		ctx.String(http.StatusBadRequest, err.Error())
+	if !formConfig(ctx, cfg) {
+		return
+	}
+	projectID, ok := formProject(ctx)
+	if !ok {
+		return
+	}
+	versionID, ok := saveInputs(ctx, projectID, 0, nil)
+	if !ok {
 		return
 	}
 
-	if form.File["courses"] == nil || form.File["classrooms"] == nil {
-		log.Println("missing file(s): courses? classrooms?")
-		ctx.Status(http.StatusBadRequest)
+	timestamp, ok := startRun(ctx, cfg, projectID, versionID, "")
+	if !ok {
 		return
 	}
-	coursesFile := form.File["courses"][0]
-	classroomsFile := form.File["classrooms"][0]
-	CoursesPath := "db/" + timestamp + coursesFile.Filename
-	ClassroomsPath := "db/" + timestamp + classroomsFile.Filename
-	ctx.SaveUploadedFile(coursesFile, CoursesPath)
-	ctx.SaveUploadedFile(classroomsFile, ClassroomsPath)
-	cfg.CoursesFile = CoursesPath
-	cfg.ClassroomsFile = ClassroomsPath
+	ctx.JSON(http.StatusOK, gin.H{
+		"id":      timestamp,
+		"project": projectID,
+		"version": versionID,
+	})
+}
 
-	if form.File["reserved"] != nil {
-		priorityFile := form.File["reserved"][0]
-		PriorityPath := "db/" + timestamp + priorityFile.Filename
-		ctx.SaveUploadedFile(priorityFile, PriorityPath)
-		cfg.PriorityFile = PriorityPath
+// Repair the schedule given by the id parameter for newly uploaded inputs, the repaired
+// schedule is stored under a new id. Inputs that aren't uploaded are taken from the
+// schedule if it has stored inputs, the configuration as well with a new seed.
+func handleRepairSchedule(ctx *gin.Context) {
+	if _, ok := loadRows(ctx); !ok {
+		return
 	}
-	if form.File["busy"] != nil {
-		busyFile := form.File["busy"][0]
-		BusyPath := "db/" + timestamp + busyFile.Filename
-		ctx.SaveUploadedFile(busyFile, BusyPath)
-		cfg.BlacklistFile = BusyPath
+	locks, ok := loadLocks(ctx, ctx.Param("id"))
+	if !ok {
+		return
 	}
-	if form.File["conflicts"] != nil {
-		conflictsFile := form.File["conflicts"][0]
-		ConflictsPath := "db/" + timestamp + conflictsFile.Filename
-		ctx.SaveUploadedFile(conflictsFile, ConflictsPath)
-		cfg.ConflictsFile = ConflictsPath
+	stored, ok := loadSchedule(ctx, ctx.Param("id"))
+	if !ok {
+		return
 	}
-	if form.File["splits"] != nil {
-		splitsFile := form.File["splits"][0]
-		SplitsPath := "db/" + timestamp + splitsFile.Filename
-		ctx.SaveUploadedFile(splitsFile, SplitsPath)
-		cfg.SplitFile = SplitsPath
+	cfg := scheduler.NewDefaultConfiguration()
+	if stored.Config != "" {
+		if err := json.Unmarshal([]byte(stored.Config), cfg); err != nil {
+			ctx.String(http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		cfg.Seed = 0
 	}
-	if form.File["externals"] != nil {
-		externalsFile := form.File["externals"][0]
-		ExternalsPath := "db/" + timestamp + externalsFile.Filename
-		ctx.SaveUploadedFile(externalsFile, ExternalsPath)
-		cfg.ExternalFile = ExternalsPath
+	if !formConfig(ctx, cfg) {
+		return
+	}
+	projectID := stored.ProjectID
+	if projectID == 0 {
+		if projectID, ok = formProject(ctx); !ok {
+			return
+		}
 	}
 
-	cfg.ExportFile = "db/generated/" + timestamp + "-schedule.csv"
-	log.Printf("Generating schedule with the configuration:\n%v\n", cfg)
-
-	stmt, _ := scheduleRepository.Prepare("INSERT INTO schedule (id, data, status, report) VALUES (?, ?, ?, ?)")
-	stmt.Exec(timestamp, "", "in progress", "")
-	go createAndExportSchedule(cfg, timestamp)
+	// Locks of the schedule are kept along with uploaded ones
+	version, ok := saveInputs(ctx, projectID, stored.VersionID, locks)
+	if !ok {
+		return
+	}
 
+	log.Printf("Repairing schedule %s\n", ctx.Param("id"))
+	timestamp, ok := startRun(ctx, cfg, projectID, version, stored.Data)
+	if !ok {
+		return
+	}
 	ctx.JSON(http.StatusOK, gin.H{
-		"id": timestamp,
+		"id":      timestamp,
+		"project": projectID,
+		"version": version,
 	})
 }
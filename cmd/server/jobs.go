@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rhyrak/go-schedule/internal/csvio"
+	"github.com/rhyrak/go-schedule/internal/report"
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/internal/store"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Number of jobs run at once and attempts before a job fails
+var (
+	workerCount int
+	maxAttempts int
+)
+
+// Queued jobs are also looked for at this interval, in case a notification was missed
+const pollInterval = 5 * time.Second
+
+// A running job looks up whether it was cancelled at most once per interval
+const cancelCheckInterval = time.Second
+
+// Status of the schedule of a job in each state
+var jobStatuses = map[string]string{
+	store.JobQueued:    "queued",
+	store.JobRunning:   "in progress",
+	store.JobSucceeded: "success",
+	store.JobFailed:    "failed",
+	store.JobCancelled: "cancelled",
+}
+
+var wakeWorkers chan struct{}
+
+// Queue the jobs that were running when the server stopped again and start the workers
+func startWorkers() error {
+	running, err := scheduleStore.ListJobs(store.JobRunning)
+	if err != nil {
+		return err
+	}
+	for _, job := range running {
+		log.Printf("Resuming the job of schedule %s\n", job.ScheduleID)
+		retryJob(job, "interrupted by a server restart")
+	}
+
+	wakeWorkers = make(chan struct{}, workerCount)
+	for i := 0; i < workerCount; i++ {
+		go worker()
+	}
+	notifyWorkers()
+	return nil
+}
+
+// Queue a run of the schedule, repairing the previous schedule data if given
+func enqueueJob(scheduleID string, previous string) error {
+	err := scheduleStore.CreateJob(&store.Job{ScheduleID: scheduleID, State: store.JobQueued, Previous: previous})
+	if err != nil {
+		return err
+	}
+	notifyWorkers()
+	return nil
+}
+
+func notifyWorkers() {
+	select {
+	case wakeWorkers <- struct{}{}:
+	default:
+	}
+}
+
+// Run queued jobs one at a time, oldest first
+func worker() {
+	for {
+		job, err := scheduleStore.ClaimJob()
+		if err != nil {
+			if err != store.ErrNotFound {
+				log.Printf("error claiming a job: %v\n", err)
+			}
+			select {
+			case <-wakeWorkers:
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+		runJob(job)
+	}
+}
+
+// Run the job and store its result. Store and IO errors are retried, invalid inputs and
+// panics fail the job at once.
+func runJob(job *store.Job) {
+	id := job.ScheduleID
+	log.Printf("Running the job of schedule %s, attempt %d\n", id, job.Attempts)
+	scheduleStore.SetScheduleStatus(id, jobStatuses[store.JobRunning])
+	publishJobState(id, store.JobRunning, "")
+
+	data, reportString, ok, retry, err := attemptJob(job)
+	if err != nil {
+		log.Printf("Job of schedule %s failed: %v\n", id, err)
+		if retry {
+			retryJob(job, err.Error())
+		} else {
+			failJob(id, err.Error())
+		}
+		return
+	}
+	if !ok {
+		finishJob(id, store.JobFailed, "invalid inputs", data, reportString)
+		return
+	}
+	finishJob(id, store.JobSucceeded, "", data, reportString)
+}
+
+// Generate the schedule of the job from its stored configuration and input version. retry
+// tells if err is a store or IO error another attempt may not run into.
+func attemptJob(job *store.Job) (data string, reportString string, ok bool, retry bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			retry = false
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	stored, err := scheduleStore.GetSchedule(job.ScheduleID)
+	if err != nil {
+		return "", "", false, err != store.ErrNotFound, err
+	}
+	cfg := scheduler.NewDefaultConfiguration()
+	if err = json.Unmarshal([]byte(stored.Config), cfg); err != nil {
+		return "", "", false, false, err
+	}
+	cfg.Progress = func(p *scheduler.Progress) {
+		publishProgress(job.ScheduleID, p)
+	}
+	cfg.Cancelled = jobCancelled(job.ScheduleID)
+	if stored.VersionID != 0 {
+		if err = materializeInputs(stored.VersionID, cfg); err != nil {
+			return "", "", false, true, err
+		}
+	}
+	var previous []*model.ScheduleCSVRow
+	if job.Previous != "" {
+		if previous, err = csvio.ParseScheduleData(job.Previous); err != nil {
+			return "", "", false, false, err
+		}
+	}
+
+	data, reportString, ok = createAndExportSchedule(cfg, job.ScheduleID, previous)
+	return data, reportString, ok, false, nil
+}
+
+// Tells if the job of the schedule was cancelled or deleted while it runs
+func jobCancelled(scheduleID string) func() bool {
+	var checked time.Time
+	cancelled := false
+	return func() bool {
+		if !cancelled && time.Since(checked) >= cancelCheckInterval {
+			checked = time.Now()
+			job, err := scheduleStore.GetJob(scheduleID)
+			cancelled = err == store.ErrNotFound || (err == nil && job.State == store.JobCancelled)
+		}
+		return cancelled
+	}
+}
+
+// Queue the job again, or fail it once it is out of attempts
+func retryJob(job *store.Job, errorMessage string) {
+	if job.Attempts >= maxAttempts {
+		failJob(job.ScheduleID, errorMessage)
+		return
+	}
+	err := scheduleStore.UpdateJob(job.ScheduleID, store.JobQueued, errorMessage, store.JobRunning)
+	if err == store.ErrNotFound {
+		dropJob(job.ScheduleID)
+		return
+	}
+	if err != nil {
+		log.Printf("error queueing the job of schedule %s: %v\n", job.ScheduleID, err)
+		return
+	}
+	scheduleStore.SetScheduleStatus(job.ScheduleID, jobStatuses[store.JobQueued])
+	publishJobState(job.ScheduleID, store.JobQueued, errorMessage)
+	notifyWorkers()
+}
+
+// Fail the job with a report of the error
+func failJob(scheduleID string, errorMessage string) {
+	finishJob(scheduleID, store.JobFailed, errorMessage, "invalid", report.NewFatalReport(errorMessage).JSON())
+}
+
+// Store the result of the job, a schedule deleted meanwhile is left alone. The job is
+// only finished if it is still running, so a cancel isn't overwritten.
+func finishJob(scheduleID string, state string, errorMessage string, data string, reportString string) {
+	err := scheduleStore.UpdateJob(scheduleID, state, errorMessage, store.JobRunning)
+	if err == store.ErrNotFound {
+		dropJob(scheduleID)
+		return
+	}
+	if err != nil {
+		log.Printf("error storing the job of schedule %s: %v\n", scheduleID, err)
+	}
+	// Listeners are told once the result can be read
+	defer publishJobState(scheduleID, state, errorMessage)
+	if err := scheduleStore.FinishSchedule(scheduleID, data, jobStatuses[state], reportString); err != nil {
+		log.Printf("error storing schedule %s: %v\n", scheduleID, err)
+	}
+}
+
+// Drop the result of a job that was cancelled or deleted while it ran
+func dropJob(scheduleID string) {
+	log.Printf("Dropping the result of the job of schedule %s, it was cancelled or deleted\n", scheduleID)
+	scheduleStore.SetScheduleStatus(scheduleID, jobStatuses[store.JobCancelled])
+}
+
+// Jobs of the server, optionally in the state given by the state query
+func handleGetJobs(ctx *gin.Context) {
+	state := ctx.Query("state")
+	if _, found := jobStatuses[state]; state != "" && !found {
+		ctx.String(http.StatusBadRequest, "unknown job state "+state)
+		return
+	}
+	jobs, err := scheduleStore.ListJobs(state)
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"jobs": jobs,
+	})
+}
+
+// Job of the schedule given by the id parameter
+func handleGetJob(ctx *gin.Context) {
+	job, ok := loadJob(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, job)
+}
+
+// Cancel the job of the schedule given by the id parameter. A queued job won't run, a
+// running one stops at its next cancel check and its result is dropped.
+func handleCancelJob(ctx *gin.Context) {
+	job, ok := loadJob(ctx)
+	if !ok {
+		return
+	}
+	if job.State != store.JobQueued && job.State != store.JobRunning {
+		ctx.String(http.StatusConflict, "job is already "+job.State)
+		return
+	}
+	// The job may have finished since it was loaded
+	err := scheduleStore.UpdateJob(job.ScheduleID, store.JobCancelled, "", store.JobQueued, store.JobRunning)
+	if err == store.ErrNotFound {
+		ctx.String(http.StatusConflict, "job is no longer queued or running")
+		return
+	}
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if job.State == store.JobQueued {
+		scheduleStore.SetScheduleStatus(job.ScheduleID, jobStatuses[store.JobCancelled])
+	}
+	publishJobState(job.ScheduleID, store.JobCancelled, "")
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"previous": job.State,
+		"state":    store.JobCancelled,
+	})
+}
+
+func loadJob(ctx *gin.Context) (*store.Job, bool) {
+	job, err := scheduleStore.GetJob(ctx.Param("id"))
+	if err == store.ErrNotFound {
+		ctx.String(http.StatusNotFound, "schedule has no job")
+		return nil, false
+	}
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	return job, true
+}
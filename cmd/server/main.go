@@ -1,24 +1,47 @@
 package main
 
 import (
-	"database/sql"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/rhyrak/go-schedule/internal/store"
 )
 
 const (
 	port = ":3001"
 )
 
-var scheduleRepository *sql.DB
+var scheduleStore store.ScheduleStore
+
+// Directory of uploaded input files and generated schedules
+var dataDir string
 
 func main() {
+	storeConfig := store.Config{}
+	flag.StringVar(&storeConfig.Driver, "store", envOr("SCHEDULE_STORE", "sqlite"), "storage backend: sqlite, postgres, fs or memory")
+	flag.StringVar(&storeConfig.DSN, "store-dsn", os.Getenv("SCHEDULE_STORE_DSN"), "database file, postgres connection string or directory of the store")
+	flag.StringVar(&dataDir, "data-dir", envOr("SCHEDULE_DATA_DIR", "db"), "directory of uploaded input files and generated schedules")
+	flag.IntVar(&workerCount, "workers", envInt("SCHEDULE_WORKERS", 2), "number of schedules generated at once")
+	flag.IntVar(&maxAttempts, "max-attempts", envInt("SCHEDULE_MAX_ATTEMPTS", 3), "attempts of a job failing with a store or IO error before it fails")
+	flag.Parse()
+	if workerCount < 1 || maxAttempts < 1 {
+		panic("workers and max-attempts must be at least 1")
+	}
+
 	var err error
-	scheduleRepository, err = initDB()
+	scheduleStore, err = store.Open(storeConfig)
 	if err != nil {
 		panic(err)
 	}
+	if err = os.MkdirAll(filepath.Join(dataDir, "generated"), 0755); err != nil {
+		panic(err)
+	}
+	if err = startWorkers(); err != nil {
+		panic(err)
+	}
 	r := gin.Default()
 
 	r.Use(corsMiddleware)
@@ -26,29 +49,53 @@ func main() {
 	r.GET("/schedule", handleGetSchedule)
 	r.POST("/schedule", handlePostSchedule)
 	r.GET("/schedule/:id", handleGetScheduleWithId)
+	r.GET("/schedule/:id/timetable", handleGetTimetable)
+	r.GET("/schedule/:id/ics", handleGetICS)
+	r.GET("/schedule/:id/xlsx", handleGetXLSX)
+	r.GET("/schedule/:id/html", handleGetHTML)
+	r.GET("/schedule/:id/diff/:other", handleGetDiff)
+	r.GET("/schedule/:id/config", handleGetConfig)
+	r.GET("/schedule/:id/report", handleGetReport)
+	r.GET("/schedule/:id/locks", handleGetLocks)
+	r.PUT("/schedule/:id/locks", handlePutLocks)
+	r.POST("/schedule/:id/repair", handleRepairSchedule)
+	r.POST("/schedule/:id/move", handleMoveSession)
+	r.POST("/schedule/:id/swap", handleSwapSessions)
+	r.POST("/schedule/:id/unassign", handleUnassignSession)
+	r.POST("/schedule/:id/undo", handleUndoEdit)
+	r.GET("/schedule/:id/history", handleGetHistory)
+	r.POST("/schedule/:id/rerun", handleRerunSchedule)
+	r.GET("/schedule/:id/job", handleGetJob)
+	r.GET("/schedule/:id/events", handleGetEvents)
+	r.POST("/schedule/:id/cancel", handleCancelJob)
 	r.DELETE("/schedule/:id", handleDeleteScheduleWithId)
 
+	r.GET("/job", handleGetJobs)
+
+	r.GET("/project", handleGetProjects)
+	r.POST("/project", handlePostProject)
+	r.GET("/project/:id", handleGetProject)
+	r.POST("/project/:id/inputs", handlePostInputs)
+	r.GET("/project/:id/inputs/:version/:kind", handleGetInput)
+	r.POST("/project/:id/run", handlePostRun)
+
 	r.Run(port)
 }
 
-func initDB() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", "./scheduler.db")
-	if err != nil {
-		return nil, err
+// Value of the environment variable, or def if it is unset
+func envOr(key string, def string) string {
+	if value, found := os.LookupEnv(key); found {
+		return value
 	}
+	return def
+}
 
-	if err = db.Ping(); err != nil {
-		return nil, err
+// Integer value of the environment variable, or def if it is unset or invalid
+func envInt(key string, def int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
 	}
-	statement, err := db.Prepare("CREATE TABLE IF NOT EXISTS schedule (id INTEGER PRIMARY KEY, data TEXT, status TEXT, report TEXT)")
-	if err != nil {
-		return nil, err
-	}
-	if _, err = statement.Exec(); err != nil {
-		return nil, err
-	}
-
-	return db, nil
+	return def
 }
 
 func corsMiddleware(c *gin.Context) {
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rhyrak/go-schedule/internal/csvio"
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/internal/store"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Input files of a run by their form field names
+var inputKinds = []string{"courses", "classrooms", "reserved", "busy", "mandatory", "conflicts", "splits", "externals", "limits", "locks"}
+
+// Configuration field pointing to the input file of given kind
+func inputPath(cfg *scheduler.Configuration, kind string) *string {
+	switch kind {
+	case "courses":
+		return &cfg.CoursesFile
+	case "classrooms":
+		return &cfg.ClassroomsFile
+	case "reserved":
+		return &cfg.PriorityFile
+	case "busy":
+		return &cfg.BlacklistFile
+	case "mandatory":
+		return &cfg.MandatoryFile
+	case "conflicts":
+		return &cfg.ConflictsFile
+	case "splits":
+		return &cfg.SplitFile
+	case "externals":
+		return &cfg.ExternalFile
+	case "limits":
+		return &cfg.LecturerLimitsFile
+	case "locks":
+		return &cfg.LocksFile
+	}
+	return nil
+}
+
+func handleGetProjects(ctx *gin.Context) {
+	projects, err := scheduleStore.ListProjects()
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"projects": projects,
+	})
+}
+
+func handlePostProject(ctx *gin.Context) {
+	req := struct {
+		Name     string `json:"name"`
+		Semester string `json:"semester"`
+	}{}
+	if err := ctx.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		ctx.String(http.StatusBadRequest, "name is required")
+		return
+	}
+	if _, err := scheduleStore.FindProject(req.Name); err == nil {
+		ctx.String(http.StatusConflict, "project "+req.Name+" exists")
+		return
+	}
+	id, err := scheduleStore.CreateProject(req.Name, req.Semester)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"id": id,
+	})
+}
+
+// Project with its input versions and runs
+func handleGetProject(ctx *gin.Context) {
+	type Run struct {
+		Id      string `json:"id"`
+		Status  string `json:"status"`
+		Version int64  `json:"version"`
+	}
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	project, err := scheduleStore.GetProject(id)
+	if err != nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	versions, err := scheduleStore.ListInputVersions(id)
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	schedules, err := scheduleStore.ListSchedules(id)
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	runs := []Run{}
+	for _, s := range schedules {
+		runs = append(runs, Run{Id: s.ID, Status: s.Status, Version: s.VersionID})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"id":       project.ID,
+		"name":     project.Name,
+		"semester": project.Semester,
+		"created":  project.Created,
+		"versions": versions,
+		"runs":     runs,
+	})
+}
+
+// Store uploaded input files as a new version of the project, the others are taken from
+// the base version given by the base form value or the latest version
+func handlePostInputs(ctx *gin.Context) {
+	projectID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	base, ok := baseVersion(ctx, projectID, ctx.PostForm("base"))
+	if !ok {
+		return
+	}
+	versionID, ok := saveInputs(ctx, projectID, base, nil)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"version": versionID,
+	})
+}
+
+// Download an input file of a version
+func handleGetInput(ctx *gin.Context) {
+	projectID, err0 := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	versionID, err1 := strconv.ParseInt(ctx.Param("version"), 10, 64)
+	if err0 != nil || err1 != nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	if _, err := scheduleStore.GetInputVersion(projectID, versionID); err != nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	f, err := scheduleStore.GetInputFile(versionID, ctx.Param("kind"))
+	if err != nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+
+	ctx.Header("Content-Disposition", "attachment; filename=\""+f.Filename+"\"")
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", f.Data)
+}
+
+// Generate a schedule from an input version of the project, the latest one by default.
+// A configuration can be given like for POST /schedule, and a seed to repeat a run.
+func handlePostRun(ctx *gin.Context) {
+	projectID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	versionID, ok := baseVersion(ctx, projectID, ctx.Query("version"))
+	if !ok {
+		return
+	}
+	if versionID == 0 {
+		ctx.String(http.StatusBadRequest, "project has no inputs")
+		return
+	}
+	cfg := scheduler.NewDefaultConfiguration()
+	if seed := ctx.Query("seed"); seed != "" {
+		if cfg.Seed, err = strconv.ParseInt(seed, 10, 64); err != nil {
+			ctx.String(http.StatusBadRequest, "invalid seed")
+			return
+		}
+	}
+	if !formConfig(ctx, cfg) {
+		return
+	}
+
+	id, ok := startRun(ctx, cfg, projectID, versionID, "")
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"id": id,
+	})
+}
+
+// Run the schedule given by the id parameter again with the same configuration and seed.
+// Uploaded files replace the inputs of the run in a new input version.
+func handleRerunSchedule(ctx *gin.Context) {
+	id := ctx.Param("id")
+	stored, ok := loadSchedule(ctx, id)
+	if !ok {
+		return
+	}
+	if stored.VersionID == 0 {
+		ctx.String(http.StatusConflict, "inputs of the schedule are not stored, it can't be run again")
+		return
+	}
+	cfg, ok := loadConfig(ctx, id)
+	if !ok {
+		return
+	}
+
+	version := stored.VersionID
+	if files, ok := formInputs(ctx); !ok {
+		return
+	} else if len(files) != 0 {
+		if version, ok = saveInputs(ctx, stored.ProjectID, version, nil); !ok {
+			return
+		}
+	}
+
+	timestamp, ok := startRun(ctx, cfg, stored.ProjectID, version, "")
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"id":      timestamp,
+		"version": version,
+	})
+}
+
+// Store a run of the input version and queue it to generate a new schedule, or repair the
+// previous schedule data if given
+func startRun(ctx *gin.Context, cfg *scheduler.Configuration, projectID int64, versionID int64, previous string) (string, bool) {
+	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+	cfg.ExportFile = dataDir + "/generated/" + timestamp + "-schedule.csv"
+	log.Printf("Queueing input version %d with the configuration:\n%v\n", versionID, cfg)
+
+	err := scheduleStore.CreateSchedule(&store.Schedule{
+		ID:        timestamp,
+		Status:    jobStatuses[store.JobQueued],
+		Config:    configString(cfg),
+		ProjectID: projectID,
+		VersionID: versionID,
+	})
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return "", false
+	}
+	if err = enqueueJob(timestamp, previous); err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return "", false
+	}
+	return timestamp, true
+}
+
+// Store the uploaded input files as a new input version of the project. Files that aren't
+// uploaded are taken from the base version, without one from the default inputs of the
+// server, in which case courses and classrooms are required. Given locks are merged into
+// the uploaded ones.
+func saveInputs(ctx *gin.Context, projectID int64, baseID int64, locks []*model.Lock) (int64, bool) {
+	files, ok := formInputs(ctx)
+	if !ok {
+		return 0, false
+	}
+	if baseID == 0 && (files["courses"] == nil || files["classrooms"] == nil) {
+		log.Println("missing file(s): courses? classrooms?")
+		ctx.Status(http.StatusBadRequest)
+		return 0, false
+	}
+
+	if len(locks) != 0 {
+		if files["locks"] != nil {
+			uploaded, err := csvio.ParseLocks(string(files["locks"].Data), ';')
+			if err != nil {
+				ctx.String(http.StatusBadRequest, "Failed to parse data from "+files["locks"].Filename+" file. Please check the data integrity and format.\n")
+				return 0, false
+			}
+			locks = append(locks, uploaded...)
+		}
+		data, err := csvio.ExportLocks(locks, ';')
+		if err != nil {
+			ctx.Status(http.StatusInternalServerError)
+			return 0, false
+		}
+		files["locks"] = &store.InputFile{Kind: "locks", Filename: "locks.csv", Data: []byte(data)}
+	}
+
+	defaults := scheduler.NewDefaultConfiguration()
+	inputs := []*store.InputFile{}
+	for _, kind := range inputKinds {
+		if files[kind] == nil && baseID != 0 {
+			if f, err := scheduleStore.GetInputFile(baseID, kind); err == nil {
+				files[kind] = f
+			}
+		} else if files[kind] == nil {
+			if path := *inputPath(defaults, kind); path != "" {
+				if data, err := os.ReadFile(path); err == nil {
+					files[kind] = &store.InputFile{Kind: kind, Filename: filepath.Base(path), Data: data}
+				}
+			}
+		}
+		if files[kind] != nil {
+			inputs = append(inputs, files[kind])
+		}
+	}
+
+	versionID, err := scheduleStore.CreateInputVersion(projectID, baseID, ctx.PostForm("note"), inputs)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return 0, false
+	}
+	return versionID, true
+}
+
+// Read the uploaded input files, requests without a multipart form have none
+func formInputs(ctx *gin.Context) (map[string]*store.InputFile, bool) {
+	files := map[string]*store.InputFile{}
+	if !strings.HasPrefix(ctx.ContentType(), "multipart/form-data") {
+		return files, true
+	}
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		log.Printf("error reading form: %v\n", err.Error())
+		ctx.String(http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	for _, kind := range inputKinds {
+		if form.File[kind] == nil {
+			continue
+		}
+		header := form.File[kind][0]
+		file, err := header.Open()
+		if err != nil {
+			ctx.String(http.StatusBadRequest, err.Error())
+			return nil, false
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			ctx.String(http.StatusBadRequest, err.Error())
+			return nil, false
+		}
+		files[kind] = &store.InputFile{Kind: kind, Filename: filepath.Base(header.Filename), Data: data}
+	}
+	return files, true
+}
+
+// Write the files of an input version to <data dir>/inputs/<version>/ and point the configuration
+// to them. Kinds the version lacks keep their configured paths. Files already written are
+// kept, others are written through a temporary file so that jobs of the same version never
+// read a partially written one.
+func materializeInputs(versionID int64, cfg *scheduler.Configuration) error {
+	files, err := scheduleStore.InputFiles(versionID)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(dataDir, "inputs", strconv.FormatInt(versionID, 10))
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, f := range files {
+		path := inputPath(cfg, f.Kind)
+		if path == nil {
+			continue
+		}
+		*path = filepath.Join(dir, f.Kind+"-"+f.Filename)
+		// Version ids restart with the memory store, so existing files are compared
+		if existing, err := os.ReadFile(*path); err == nil && bytes.Equal(existing, f.Data) {
+			continue
+		}
+		if err = writeInputFile(dir, *path, f.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write data to a temporary file in dir and rename it to path
+func writeInputFile(dir string, path string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Check that the version belongs to the project, an empty version picks the latest one
+// or 0 if the project has none
+func baseVersion(ctx *gin.Context, projectID int64, version string) (int64, bool) {
+	if version == "" {
+		latest, err := scheduleStore.LatestInputVersion(projectID)
+		if err != nil {
+			ctx.Status(http.StatusInternalServerError)
+			return 0, false
+		}
+		return latest, true
+	}
+	id, err := strconv.ParseInt(version, 10, 64)
+	if err == nil {
+		_, err = scheduleStore.GetInputVersion(projectID, id)
+	}
+	if err != nil {
+		ctx.String(http.StatusNotFound, "input version "+version+" is not in the project")
+		return 0, false
+	}
+	return id, true
+}
+
+// Project given by the project form value, created with the semester form value if it
+// doesn't exist
+func formProject(ctx *gin.Context) (int64, bool) {
+	name := strings.TrimSpace(ctx.DefaultPostForm("project", "default"))
+	project, err := scheduleStore.FindProject(name)
+	if err == nil {
+		return project.ID, true
+	}
+	var id int64
+	if err == store.ErrNotFound {
+		id, err = scheduleStore.CreateProject(name, ctx.PostForm("semester"))
+	}
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return 0, false
+	}
+	return id, true
+}
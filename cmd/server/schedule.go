@@ -1,21 +1,21 @@
 package main
 
 import (
-	"fmt"
 	"log"
-	"math/rand"
-	"strconv"
 	"time"
 
 	"github.com/rhyrak/go-schedule/internal/csvio"
+	"github.com/rhyrak/go-schedule/internal/report"
 	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/internal/timetable"
 	"github.com/rhyrak/go-schedule/pkg/model"
 )
 
-func createAndExportSchedule(cfg *scheduler.Configuration, timestamp string) {
+// Generate a schedule, or repair the previous one if given, and return its data with the
+// JSON report. Not ok if the inputs couldn't be loaded.
+func createAndExportSchedule(cfg *scheduler.Configuration, timestamp string, previous []*model.ScheduleCSVRow) (string, string, bool) {
 	var errorExists bool = false
 	var fileErrorString string = ""
-	var reportString string = ""
 
 	// Parse and instantiate classroom objects from CSV
 	classrooms, err, errorString := csvio.LoadClassrooms(cfg.ClassroomsFile, ';')
@@ -25,195 +25,69 @@ func createAndExportSchedule(cfg *scheduler.Configuration, timestamp string) {
 		fileErrorString = fileErrorString + errorString
 	}
 
-	// Don't load these
-	ignoredCourses := []string{"ENGR450", "IE101", "CENG404"}
-
 	// Parse and instantiate course objects from CSV (ignored courses are not loaded)
-	courses, labs, reserved, busy, conflicts, congestedDepartments, uniqueDepartments, err, errorString := csvio.LoadCourses(cfg, ';', ignoredCourses)
+	courses, labs, reserved, busy, conflicts, congestedDepartments, uniqueDepartments, err, errorString := csvio.LoadCourses(cfg, ';', cfg.IgnoredCourses)
 
 	if err {
 		errorExists = true
 		fileErrorString = fileErrorString + errorString
 	}
 
-	if errorExists {
-		reportString = "Fatal Error\n" + fileErrorString
-		stmt, _ := scheduleRepository.Prepare("UPDATE schedule SET data = ?, status = ?, report = ? WHERE ID = ?;")
-		stmt.Exec("invalid", "failed", reportString, timestamp)
-		log.Println(reportString)
-		return
-	}
-
-	log.Println("Loading...")
-
-	reportString = reportString + "Departments are as below:\n"
-	for _, d := range uniqueDepartments {
-		reportString = reportString + d + "\n"
-	}
-	reportString = reportString + "\n"
-
-	if len(ignoredCourses) != 0 {
-		reportString = reportString + "Ignored courses are as below:\n"
-		for _, g := range ignoredCourses {
-			reportString = reportString + g + " is ignored.\n"
+	// Parse locked sessions
+	locks := []*model.Lock{}
+	if cfg.LocksFile != "" {
+		locks, err, errorString = csvio.LoadLocks(cfg.LocksFile, ';')
+		if err {
+			errorExists = true
+			fileErrorString = fileErrorString + errorString
 		}
-		reportString = reportString + "\n"
 	}
 
-	if len(busy) != 0 {
-		reportString = reportString + "Professors with their busy schedules are as below:\n"
-		for _, b := range busy {
-			reportString = reportString + b.Lecturer + " "
-			reportString = reportString + "["
-			for _, d := range b.Day {
-				switch d {
-				case 0:
-					reportString = reportString + " Monday "
-				case 1:
-					reportString = reportString + " Tuesday "
-				case 2:
-					reportString = reportString + " Wednesday "
-				case 3:
-					reportString = reportString + " Thursday "
-				case 4:
-					reportString = reportString + " Friday "
-				}
-			}
-			reportString = reportString + "]\n"
-		}
-		reportString = reportString + "\n"
+	if errorExists {
+		log.Println("Fatal Error\n" + fileErrorString)
+		return "invalid", report.NewFatalReport(fileErrorString).JSON(), false
 	}
 
-	if len(reserved) != 0 {
-		reportString = reportString + "Courses reserved to certain days and hours are as below:\n"
-		for _, c := range reserved {
-			reportString = reportString + c.CourseRef.Department + " " + c.CourseCodeSTR + " " + c.DaySTR + " " + c.StartingTimeSTR + "\n"
-		}
-	}
+	log.Println("Loading...")
 
-	reportString = reportString + "\n"
+	runReport := report.NewReport()
+	runReport.Inputs = report.NewInputSummary(classrooms, courses, labs, reserved, busy, conflicts, uniqueDepartments, cfg.IgnoredCourses)
 
-	if len(conflicts) != 0 {
-		reportString = reportString + "Courses that explicitly won't conflict with each other are as below:\n"
-		for _, cc := range conflicts {
-			reportString = reportString + cc.Department1 + " " + cc.Course_Code1 + " <-> " + cc.Department2 + " " + cc.Course_Code2 + "\n"
+	if len(locks) != 0 {
+		var lockErrors bool
+		var lockReport string
+		reserved, lockErrors, lockReport = csvio.ApplyLocks(locks, reserved, cfg, courses, labs, classrooms)
+		if lockErrors {
+			runReport.AddWarnings(report.WarningLock, lockReport)
 		}
-		reportString = reportString + "\n"
 	}
 
+	// Seed the random generator so the run can be reported and repeated
+	scheduler.SeedRandom(cfg)
+	scheduleStore.SetScheduleConfig(timestamp, configString(cfg))
+
 	// Start timer
 	start := time.Now().UnixNano()
-	var schedule *model.Schedule
-	var optimalSchedule *model.Schedule
-	var optimalCourses []*model.Course
-	var optimalLabs []*model.Laboratory
-	var iter int
-	var stateCount int = 2
-	var iterUpperLimit int = cfg.IterSoftLimit + 4999 // Extend final state by 5000 iterations (Doomsday)
-	var iterActivityDayDelta int = cfg.IterSoftLimit / stateCount
-	var state int = 0
-	var placementProbability = 0.1
-	unassignedCount := 21474836547
-	// Try to create a valid schedule upto iterLimit+1 times
-	for iter = 1; iter <= iterUpperLimit; iter++ {
-		// Increment state every iterState iterations and reset FreeDay fill probability
-		if iter%cfg.IterSoftLimit == 0 {
-			state++
-			placementProbability = 0.1
-		}
-
-		// Increment fill probabilty of Activity Day from 10% to 60% over the course of state iterations
-		placementProbability = placementProbability + (1 / float64(iterActivityDayDelta*4))
-
-		// Keep going in 2nd state, Also fully unlock Activity Day
-		if state >= stateCount-1 {
-			state = stateCount - 1
-			placementProbability = 1.0
-		}
-
-		for _, c := range classrooms {
-			// Initialize an empty classroom-oriented schedule to keep track of classroom utilization throughout the week
-			c.CreateSchedule(cfg.NumberOfDays, cfg.TimeSlotCount)
-		}
-
-		// Init and assign new conflict probabilities according to state
-		courses, labs = scheduler.InitRuntimeProperties(courses, labs, state, conflicts, cfg.RelativeConflictProbability)
-
-		// Shuffle around the courses vector randomly to allow for different output opportunities
-		rand.Shuffle(len(courses), func(i, j int) {
-			courses[i], courses[j] = courses[j], courses[i]
-		})
-
-		// Initialize an empty schedule to hold course data
-		schedule = model.NewSchedule(cfg.NumberOfDays, cfg.TimeSlotDuration, cfg.TimeSlotCount)
-
-		// Fill the empty schedule with course data and assign classrooms to courses
-		scheduler.PlaceReservedCourses(reserved, schedule, classrooms)
-		scheduler.FillCourses(courses, labs, schedule, classrooms, placementProbability, cfg.ActivityDay, congestedDepartments, cfg.DepartmentCongestionLimit, state)
-
-		// If schedule is valid, break, if not, shove everything out the window and try again (5dk)
-		_, valid, _, _, cnt := scheduler.Validate(courses, labs, schedule, classrooms, congestedDepartments, cfg.DepartmentCongestionLimit)
-		if valid {
-			optimalSchedule = schedule.DeepCopy()
-			optimalCourses = model.DeepCopyCourses(courses)
-			optimalLabs = model.DeepCopyLaboratories(labs)
-			break
+	var result *scheduler.Result
+	if previous != nil {
+		// Keep the previous schedule and only move what no longer fits
+		previousSchedule, importErrors, importReport := csvio.ImportRows(previous, cfg, courses, labs, classrooms)
+		if importErrors {
+			runReport.AddWarnings(report.WarningImport, importReport)
 		}
-		// Update least-faulty schedule
-		if cnt <= unassignedCount {
-			unassignedCount = cnt
-			optimalSchedule = schedule.DeepCopy()
-			optimalCourses = model.DeepCopyCourses(courses)
-			optimalLabs = model.DeepCopyLaboratories(labs)
-		}
-	}
-
-	end := time.Now().UnixNano()
-
-	// Validate and print error messages
-	unassignedCourses, valid, sufficientRooms, msg, uc := scheduler.Validate(optimalCourses, optimalLabs, optimalSchedule, classrooms, congestedDepartments, cfg.DepartmentCongestionLimit)
-	if !valid {
-		errorExists = true
-		reportString = reportString + "Invalid schedule:\n"
+		repaired := scheduler.Repair(cfg, previousSchedule, courses, labs, reserved, classrooms, conflicts, congestedDepartments)
+		runReport.Repair = report.NewRepairSummary(repaired)
+		result = &repaired.Result
 	} else {
-		reportString = reportString + "Passed all tests\n"
-	}
-
-	reportString = reportString + "Unassigned: " + strconv.Itoa(uc) + "\n\n"
-
-	if !sufficientRooms {
-		errorExists = true
-	}
-
-	reportString = reportString + "Classrooms and their occuppied days are as below:\n"
-
-	for _, c := range classrooms {
-		reportString = reportString + c.ID + " "
-		reportString = reportString + "["
-		for _, d := range c.AvailabilityArray {
-			switch d {
-			case 0:
-				reportString = reportString + " Monday "
-			case 1:
-				reportString = reportString + " Tuesday "
-			case 2:
-				reportString = reportString + " Wednesday "
-			case 3:
-				reportString = reportString + " Thursday "
-			case 4:
-				reportString = reportString + " Friday "
-			}
-		}
-		reportString = reportString + "]\n"
+		result = scheduler.Generate(cfg, courses, labs, reserved, classrooms, conflicts, congestedDepartments)
 	}
+	optimalSchedule, optimalCourses, optimalLabs := result.Schedule, result.Courses, result.Labs
+	iter, state, placementProbability := result.Iteration, result.State, result.PlacementProbability
+	end := time.Now().UnixNano()
 
-	reportString = reportString + "\n"
-
-	//fmt.Println(msg)
-	reportString = reportString + msg
-	if errorExists {
-		reportString = "Scheduling Error\n" + reportString
-	}
+	// Validate the schedule
+	runReport.SetValidation(scheduler.ValidateSchedule(optimalCourses, optimalLabs, optimalSchedule))
+	runReport.Locked = report.NewLockedSessions(reserved)
 
 	c := cfg.RelativeConflictProbability / 2.0 * 100.0
 	if state == 1 {
@@ -222,29 +96,26 @@ func createAndExportSchedule(cfg *scheduler.Configuration, timestamp string) {
 
 	// Show how evil the schedule is
 	optimalSchedule.CalculateCost()
-	reportString = reportString + fmt.Sprintf("State: %d\n", state)
-	reportString = reportString + fmt.Sprintf("Cost: %d\n", optimalSchedule.Cost)
-	reportString = reportString + fmt.Sprintf("Iteration: %d\n", iter)
-	reportString = reportString + fmt.Sprintf("Sibling Compulsory Conflict Probability: %1.2f%%\n", c)
-	reportString = reportString + fmt.Sprintf("Activity Day Placement Probability: %1.2f%%\n", placementProbability*100.0)
-	reportString = reportString + fmt.Sprintf("Elapsed Time: %f ms\n", float64(end-start)/1000000.0)
-	// reportString = reportString + fmt.Sprint("Exported output to: "+cfg.ExportFile+"\n\n")
-	reportString = reportString + "\n\n"
-
-	if !sufficientRooms {
-		// do something useful
-		var capacityNeeded int = 0
-		for _, c := range unassignedCourses {
-			if c.Number_of_Students > capacityNeeded {
-				capacityNeeded = c.Number_of_Students
-			}
-		}
-		reportString = reportString + "New classroom of capacity " + strconv.Itoa(capacityNeeded) + " needed. Please add it and re-run the program.\n"
+	runReport.Run = &report.RunSummary{
+		State:                state,
+		Cost:                 optimalSchedule.Cost,
+		Iteration:            iter,
+		Seed:                 cfg.Seed,
+		ConflictProbability:  c,
+		PlacementProbability: placementProbability * 100.0,
+		ElapsedMs:            float64(end-start) / 1000000.0,
+		NumberOfDays:         len(optimalSchedule.Days),
+		TimeSlotDuration:     optimalSchedule.TimeSlotDuration,
+		TimeSlotCount:        optimalSchedule.TimeSlotCount,
 	}
 
-	log.Println(reportString)
-	scheduleData := csvio.ExportScheduleString(schedule)
+	// Show classroom utilisation and the classrooms unassigned courses would need
+	runReport.Rooms = report.NewRoomReport(optimalSchedule, classrooms, optimalCourses, optimalLabs, cfg.RoomOccupancyRatio)
+	runReport.Stats = report.NewScheduleStats(timetable.New(optimalSchedule))
+
+	log.Println(runReport.String())
+	scheduleData := csvio.ExportScheduleJSONString(optimalSchedule)
 
-	stmt, _ := scheduleRepository.Prepare("UPDATE schedule SET data = ?, status = ?, report = ? WHERE ID = ?;")
-	stmt.Exec(scheduleData, "success", reportString, timestamp)
+	// The report is stored as JSON so clients can show its parts
+	return scheduleData, runReport.JSON(), true
 }
@@ -0,0 +1,233 @@
+package csvio
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rhyrak/go-schedule/internal/timetable"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// RenderMeta holds generation details printed on rendered timetables.
+type RenderMeta struct {
+	Seed      int64
+	Cost      int
+	Iteration int
+	Generated time.Time
+}
+
+type htmlCell struct {
+	Lines   []string
+	Class   string
+	Rowspan int
+}
+
+type htmlRow struct {
+	Time  string
+	Cells []*htmlCell // nil cells are covered by a session starting in an earlier row
+}
+
+type htmlGrid struct {
+	Anchor string
+	Title  string
+	Days   []string
+	Spans  []int // Columns of each day
+	Rows   []*htmlRow
+}
+
+type htmlSection struct {
+	Title string
+	Grids []*htmlGrid
+}
+
+type htmlLegendItem struct {
+	Class string
+	Code  string
+	Name  string
+}
+
+type htmlPage struct {
+	Meta     RenderMeta
+	Sections []*htmlSection
+	Legend   []*htmlLegendItem
+	Colours  []template.CSS
+}
+
+var htmlPageTemplate = template.Must(template.New("timetable").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Timetables</title>
+<style>
+body { font-family: Arial, Helvetica, sans-serif; font-size: 11px; margin: 16px; color: #222; }
+h1 { font-size: 20px; margin: 0 0 4px 0; }
+h2 { font-size: 16px; margin: 24px 0 8px 0; border-bottom: 1px solid #999; }
+h3 { font-size: 13px; margin: 16px 0 4px 0; }
+.meta { color: #555; margin-bottom: 12px; }
+nav a { margin-right: 8px; }
+table { border-collapse: collapse; width: 100%; table-layout: fixed; }
+th, td { border: 1px solid #999; padding: 2px 4px; vertical-align: middle; text-align: center; }
+th { background: #d9d9d9; }
+th.time { width: 48px; }
+td span { display: block; }
+td span:first-child { font-weight: bold; }
+.legend { display: flex; flex-wrap: wrap; gap: 4px 16px; }
+.legend div { display: flex; align-items: center; gap: 4px; }
+.legend i { display: inline-block; width: 12px; height: 12px; border: 1px solid #999; }
+{{range $i, $c := .Colours}}.c{{$i}} { background: #{{$c}}; }
+{{end -}}
+@page { size: A4 landscape; margin: 10mm; }
+@media print {
+	body { margin: 0; }
+	nav { display: none; }
+	h2 { break-before: page; }
+	.grid { break-inside: avoid; }
+	th, td { -webkit-print-color-adjust: exact; print-color-adjust: exact; }
+}
+</style>
+</head>
+<body>
+<h1>Timetables</h1>
+<div class="meta">Generated {{.Meta.Generated.Format "2006-01-02 15:04"}} &middot; Seed {{.Meta.Seed}} &middot; Cost {{.Meta.Cost}} &middot; Iteration {{.Meta.Iteration}}</div>
+<nav>{{range .Sections}}{{range .Grids}}<a href="#{{.Anchor}}">{{.Title}}</a> {{end}}{{end}}</nav>
+<h2>Legend</h2>
+<div class="legend">{{range .Legend}}<div><i class="{{.Class}}"></i>{{.Code}} {{.Name}}</div>{{end}}</div>
+{{range .Sections}}<h2>{{.Title}}</h2>
+{{range .Grids}}{{$grid := .}}<div class="grid" id="{{.Anchor}}">
+<h3>{{.Title}}</h3>
+<table>
+<tr><th class="time">Time</th>{{range $i, $d := .Days}}<th colspan="{{index $grid.Spans $i}}">{{$d}}</th>{{end}}</tr>
+{{range .Rows}}<tr><th class="time">{{.Time}}</th>{{range .Cells}}{{if .}}<td{{if .Class}} class="{{.Class}}"{{end}}{{if gt .Rowspan 1}} rowspan="{{.Rowspan}}"{{end}}>{{range .Lines}}<span>{{.}}</span>{{end}}</td>{{end}}{{end}}</tr>
+{{end}}</table>
+</div>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// ExportHTML renders the schedule as a standalone HTML page and writes it to the file
+// specified by the given path.
+func ExportHTML(schedule *model.Schedule, meta RenderMeta, path string) string {
+	out, err := os.Create(path)
+	if err != nil {
+		fmt.Println("Err02")
+		panic(err)
+	}
+	defer out.Close()
+
+	err = RenderHTML(out, formatAndFilterSchedule(schedule), len(schedule.Days), schedule.TimeSlotDuration, schedule.TimeSlotCount, meta)
+	if err != nil {
+		fmt.Println("Err03")
+		panic(err)
+	}
+	return path
+}
+
+// RenderHTML writes schedule rows as a standalone, print friendly HTML page with week
+// grids of every cohort, lecturer and classroom, a course legend and generation details.
+func RenderHTML(w io.Writer, rows []*model.ScheduleCSVRow, days int, timeSlotDuration int, timeSlotCount int, meta RenderMeta) error {
+	tt := timetable.FromRows(rows, days, timeSlotDuration, timeSlotCount)
+	page := &htmlPage{Meta: meta}
+	if page.Meta.Generated.IsZero() {
+		page.Meta.Generated = time.Now()
+	}
+
+	// Every course code gets its own colour
+	courses := map[string]string{}
+	for _, e := range tt.Entries {
+		if _, seen := courses[e.CourseCode]; !seen {
+			courses[e.CourseCode] = e.CourseName
+		}
+	}
+	codes := make([]string, 0, len(courses))
+	for code := range courses {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	classes := map[string]string{}
+	for i, code := range codes {
+		classes[code] = fmt.Sprintf("c%d", i)
+		page.Colours = append(page.Colours, template.CSS(courseColour(i)))
+		page.Legend = append(page.Legend, &htmlLegendItem{Class: classes[code], Code: code, Name: courses[code]})
+	}
+
+	cohorts := &htmlSection{Title: "Cohorts"}
+	for _, cohort := range tt.Cohorts() {
+		i := strings.LastIndex(cohort, " ")
+		var grade int
+		fmt.Sscanf(cohort[i+1:], "%d", &grade)
+		cohorts.Grids = append(cohorts.Grids, newHTMLGrid(tt, tt.Cohort(cohort[:i], grade), classes))
+	}
+	lecturers := &htmlSection{Title: "Lecturers"}
+	for _, lecturer := range tt.Lecturers() {
+		lecturers.Grids = append(lecturers.Grids, newHTMLGrid(tt, tt.Lecturer(lecturer), classes))
+	}
+	classrooms := &htmlSection{Title: "Classrooms"}
+	for _, classroom := range tt.Classrooms() {
+		classrooms.Grids = append(classrooms.Grids, newHTMLGrid(tt, tt.Classroom(classroom), classes))
+	}
+	page.Sections = []*htmlSection{cohorts, lecturers, classrooms}
+
+	return htmlPageTemplate.Execute(w, page)
+}
+
+// Lay out a grid as table rows, parallel sessions of a day get their own columns
+func newHTMLGrid(tt *timetable.Timetable, grid *timetable.Grid, classes map[string]string) *htmlGrid {
+	g := &htmlGrid{
+		Anchor: strings.Map(func(r rune) rune {
+			if r == ' ' || r == '"' || r == '#' {
+				return '-'
+			}
+			return r
+		}, grid.Kind+"-"+grid.Name),
+		Title: strings.ToUpper(grid.Kind[:1]) + grid.Kind[1:] + " " + grid.Name,
+		Days:  grid.Days,
+	}
+
+	lanes := map[*timetable.Entry]int{}
+	dayCols := make([]int, len(grid.Days))
+	columns := 0
+	sessions := grid.Sessions()
+	for d := range grid.Days {
+		daySessions := []*timetable.Entry{}
+		for _, e := range sessions {
+			if e.Day == d {
+				daySessions = append(daySessions, e)
+			}
+		}
+		count := assignLanes(daySessions, tt, lanes)
+		g.Spans = append(g.Spans, count)
+		dayCols[d] = columns
+		columns += count
+	}
+
+	// Start with empty cells and overwrite the ones sessions start in or cover
+	for _, slotTime := range grid.Times {
+		row := &htmlRow{Time: slotTime, Cells: make([]*htmlCell, columns)}
+		for c := range row.Cells {
+			row.Cells[c] = &htmlCell{Rowspan: 1}
+		}
+		g.Rows = append(g.Rows, row)
+	}
+	for _, e := range sessions {
+		col := dayCols[e.Day] + lanes[e]
+		span := min(sessionSlots(e, tt), len(g.Rows)-e.Slot)
+		detail := e.Classroom
+		if grid.Kind == "classroom" {
+			detail = e.Lecturer
+		}
+		lines := []string{e.CourseCode, detail}
+		if grid.Kind != "cohort" {
+			lines = append(lines, fmt.Sprintf("%s %d", e.Department, e.Grade))
+		}
+		g.Rows[e.Slot].Cells[col] = &htmlCell{Lines: lines, Class: classes[e.CourseCode], Rowspan: span}
+		for s := e.Slot + 1; s < e.Slot+span; s++ {
+			g.Rows[s].Cells[col] = nil
+		}
+	}
+	return g
+}
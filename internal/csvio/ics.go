@@ -0,0 +1,90 @@
+package csvio
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/rhyrak/go-schedule/internal/timetable"
+)
+
+const dateLayout = "2006-01-02"
+
+// ExportICalendar formats the sessions of a timetable grid as an iCalendar feed.
+// Every session becomes a weekly recurring event between the semester start and
+// end dates (YYYY-MM-DD). Times are floating local times.
+func ExportICalendar(grid *timetable.Grid, semesterStart string, semesterEnd string) (string, error) {
+	start, err := time.Parse(dateLayout, semesterStart)
+	if err != nil {
+		return "", fmt.Errorf("invalid semester start date %q, expected YYYY-MM-DD", semesterStart)
+	}
+	end, err := time.Parse(dateLayout, semesterEnd)
+	if err != nil {
+		return "", fmt.Errorf("invalid semester end date %q, expected YYYY-MM-DD", semesterEnd)
+	}
+	if end.Before(start) {
+		return "", fmt.Errorf("semester end %s is before semester start %s", semesterEnd, semesterStart)
+	}
+
+	var sb strings.Builder
+	writeICSLine(&sb, "BEGIN:VCALENDAR")
+	writeICSLine(&sb, "VERSION:2.0")
+	writeICSLine(&sb, "PRODID:-//rhyrak//go-schedule//EN")
+	writeICSLine(&sb, "CALSCALE:GREGORIAN")
+	writeICSLine(&sb, "X-WR-CALNAME:"+escapeICS(grid.Name))
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	until := end.Format("20060102") + "T235959"
+	for _, e := range grid.Sessions() {
+		// First occurrence is the first matching weekday on or after semester start (Monday = 0)
+		offset := (e.Day - (int(start.Weekday())+6)%7 + 7) % 7
+		first := start.AddDate(0, 0, offset)
+		if first.After(end) {
+			continue
+		}
+		var hh, mm int
+		fmt.Sscanf(grid.Times[e.Slot], "%d:%d", &hh, &mm)
+		begin := first.Add(time.Duration(hh)*time.Hour + time.Duration(mm)*time.Minute)
+		finish := begin.Add(time.Duration(e.Duration) * time.Minute)
+
+		uid := fnv.New64a()
+		fmt.Fprintf(uid, "%s|%s|%s|%d|%d", e.Department, e.CourseCode, e.Classroom, e.Day, e.Slot)
+
+		writeICSLine(&sb, "BEGIN:VEVENT")
+		writeICSLine(&sb, fmt.Sprintf("UID:%x@go-schedule", uid.Sum64()))
+		writeICSLine(&sb, "DTSTAMP:"+stamp)
+		writeICSLine(&sb, "DTSTART:"+begin.Format("20060102T150405"))
+		writeICSLine(&sb, "DTEND:"+finish.Format("20060102T150405"))
+		writeICSLine(&sb, "RRULE:FREQ=WEEKLY;UNTIL="+until)
+		writeICSLine(&sb, "SUMMARY:"+escapeICS(e.CourseCode+" "+e.CourseName))
+		writeICSLine(&sb, "LOCATION:"+escapeICS(e.Classroom))
+		writeICSLine(&sb, "DESCRIPTION:"+escapeICS(fmt.Sprintf("Course: %s %s\nClassroom: %s\nLecturer: %s\nDepartment: %s\nGrade: %d",
+			e.CourseCode, e.CourseName, e.Classroom, e.Lecturer, e.Department, e.Grade)))
+		writeICSLine(&sb, "CATEGORIES:"+escapeICS(e.Department))
+		writeICSLine(&sb, "END:VEVENT")
+	}
+	writeICSLine(&sb, "END:VCALENDAR")
+	return sb.String(), nil
+}
+
+// Escape TEXT values as described in RFC 5545
+func escapeICS(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// Write a content line folded at 75 octets with CRLF line endings
+func writeICSLine(sb *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		// Don't split UTF-8 sequences
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		sb.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74 // Leading space of the continuation line counts
+	}
+	sb.WriteString(line + "\r\n")
+}
@@ -0,0 +1,102 @@
+package csvio
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/rhyrak/go-schedule/internal/timetable"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Weekly grid of CENG 1 with a Monday and a Wednesday session
+func icsGrid() *timetable.Grid {
+	rows := []*model.ScheduleCSVRow{
+		{CourseCode: "CENG101", CourseName: "Programming; Data, Algorithms\\Practice", Department: "CENG", Class: 1,
+			Lecturer: "Dr. Çağlar Öztürk", Classrooms: "B-101", Day: 0, Time: 60, Duration: 120},
+		{CourseCode: "CENG103", CourseName: strings.Repeat("Çalışma ", 12), Department: "CENG", Class: 1,
+			Lecturer: "Dr. Ayşe Yılmaz", Classrooms: "B-102", Day: 2, Time: 0, Duration: 60},
+	}
+	return timetable.FromRows(rows, 5, 60, 9).Cohort("CENG", 1)
+}
+
+// Unfolded content lines of a feed, failing on lines longer than 75 octets or split runes
+func unfoldICS(t *testing.T, feed string) []string {
+	t.Helper()
+	if !strings.HasSuffix(feed, "\r\n") {
+		t.Fatal("feed does not end with CRLF")
+	}
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSuffix(feed, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) || strings.Contains(line, "\n") {
+			t.Errorf("line %q splits a UTF-8 sequence or has a bare line feed", line)
+		}
+		if strings.HasPrefix(line, " ") {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestExportICalendar(t *testing.T) {
+	// 2026-09-30 is a Wednesday
+	feed, err := ExportICalendar(icsGrid(), "2026-09-30", "2027-01-15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := unfoldICS(t, feed)
+	text := strings.Join(lines, "\n")
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR",
+		"X-WR-CALNAME:CENG 1",
+		"DTSTART:20261005T093000",
+		"DTEND:20261005T113000",
+		"DTSTART:20260930T083000",
+		"DTEND:20260930T093000",
+		`SUMMARY:CENG101 Programming\; Data\, Algorithms\\Practice`,
+		"SUMMARY:CENG103 " + strings.Repeat("Çalışma ", 12),
+		`DESCRIPTION:Course: CENG101 Programming\; Data\, Algorithms\\Practice\nClassroom: B-101\nLecturer: Dr. Çağlar Öztürk\nDepartment: CENG\nGrade: 1`,
+		"END:VCALENDAR",
+	} {
+		found := false
+		for _, line := range lines {
+			found = found || line == want
+		}
+		if !found {
+			t.Errorf("feed has no line %q:\n%s", want, text)
+		}
+	}
+	if n := strings.Count(text, "RRULE:FREQ=WEEKLY;UNTIL=20270115T235959"); n != 2 {
+		t.Errorf("%d events repeat weekly until the semester end, want 2", n)
+	}
+}
+
+func TestExportICalendarShortSemester(t *testing.T) {
+	// The Monday session starts after a semester of Wednesday to Friday
+	feed, err := ExportICalendar(icsGrid(), "2026-09-30", "2026-10-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := strings.Join(unfoldICS(t, feed), "\n")
+	if n := strings.Count(text, "BEGIN:VEVENT"); n != 1 || !strings.Contains(text, "SUMMARY:CENG103") {
+		t.Errorf("got %d events, want only the Wednesday session:\n%s", n, text)
+	}
+}
+
+func TestExportICalendarDates(t *testing.T) {
+	for _, dates := range [][2]string{
+		{"30.09.2026", "2027-01-15"},
+		{"2026-09-30", "2027-02-30"},
+		{"2026-09-30", "2026-09-29"},
+	} {
+		if _, err := ExportICalendar(icsGrid(), dates[0], dates[1]); err == nil {
+			t.Errorf("semester from %s to %s was accepted", dates[0], dates[1])
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package csvio
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// ImportSchedule reads a CSV or JSON schedule export and places the loaded courses,
+// laboratories and classrooms accordingly. Classroom occupancy and Placed, PlacedDay and
+// Classroom of courses are rebuilt, constraints are not checked so the result can be
+// validated. Rows without a matching course are skipped and reported, rows placed into
+// an occupied classroom are kept and reported.
+func ImportSchedule(path string, cfg *scheduler.Configuration, courses []*model.Course, labs []*model.Laboratory, rooms []*model.Classroom) (*model.Schedule, bool, string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Println("Err00")
+		return nil, true, "Failed to open " + path + " file. Please make sure the file exists.\n"
+	}
+	rows, err := ParseScheduleData(string(data))
+	if err != nil {
+		fmt.Println("Err01")
+		return nil, true, "Failed to parse data from " + path + " file. Please check the data integrity and format.\n"
+	}
+	schedule, errorExists, reportString := ImportRows(rows, cfg, courses, labs, rooms)
+	return schedule, errorExists, reportString
+}
+
+// ImportRows places the loaded courses, laboratories and classrooms as in the given
+// schedule rows, see ImportSchedule.
+func ImportRows(rows []*model.ScheduleCSVRow, cfg *scheduler.Configuration, courses []*model.Course, labs []*model.Laboratory, rooms []*model.Classroom) (*model.Schedule, bool, string) {
+	var errorExists bool = false
+	var reportString string = ""
+
+	schedule := model.NewSchedule(cfg.NumberOfDays, cfg.TimeSlotDuration, cfg.TimeSlotCount)
+	for _, r := range rooms {
+		r.CreateSchedule(cfg.NumberOfDays, cfg.TimeSlotCount)
+	}
+	for _, c := range courses {
+		c.Placed = false
+		c.PlacedDay = -1
+		c.Classroom = nil
+		c.NeededSlots = int(math.Ceil(float64(c.Duration) / float64(cfg.TimeSlotDuration)))
+	}
+	for _, l := range labs {
+		l.Placed = false
+		l.Classroom = nil
+		l.NeededSlots = int(math.Ceil(float64(l.Duration) / float64(cfg.TimeSlotDuration)))
+	}
+
+	for i, row := range rows {
+		line := fmt.Sprintf("- Row %d (%s %s %d): ", i+1, row.CourseCode, row.Department, row.Class)
+		slot := row.Time / cfg.TimeSlotDuration
+		if row.Day < 0 || row.Day >= cfg.NumberOfDays || slot < 0 || slot >= cfg.TimeSlotCount || row.Time%cfg.TimeSlotDuration != 0 {
+			errorExists = true
+			reportString = reportString + line + fmt.Sprintf("invalid day %d or time %d\n", row.Day, row.Time)
+			continue
+		}
+
+		course, lab := MatchRow(row, courses, labs)
+		if course == nil {
+			errorExists = true
+			reportString = reportString + line + "no matching unplaced course in inputs\n"
+			continue
+		}
+		if row.Duration != course.Duration {
+			reportString = reportString + line + fmt.Sprintf("duration %d differs from input duration %d\n", row.Duration, course.Duration)
+		}
+
+		var classroom *model.Classroom
+		for _, r := range rooms {
+			if r.ID == row.Classrooms {
+				classroom = r
+				break
+			}
+		}
+		if classroom == nil && course.NeedsRoom {
+			// Left unplaced so that it is reported as unassigned
+			errorExists = true
+			reportString = reportString + line + "unknown classroom " + row.Classrooms + "\n"
+			continue
+		}
+
+		var day *model.Day
+		for _, d := range schedule.Days {
+			if d.DayOfWeek == row.Day {
+				day = d
+				break
+			}
+		}
+		if classroom != nil {
+			// Kept in the schedule, the classroom stays with the course placed first
+			for i := slot; i < slot+course.NeededSlots && i < cfg.TimeSlotCount; i++ {
+				if other := classroom.CourseAt(row.Day, i); other != 0 {
+					errorExists = true
+					reportString = reportString + line + fmt.Sprintf("classroom %s is already occupied by %s\n", classroom.ID, courseName(courses, labs, other))
+					break
+				}
+			}
+		}
+		scheduler.PlaceCourse(course, day, slot, classroom)
+		course.PlacedDay = row.Day
+		if lab != nil {
+			lab.Placed = true
+			lab.Classroom = classroom
+		}
+	}
+
+	return schedule, errorExists, reportString
+}
+
+// Display name of the course or laboratory with given ID
+func courseName(courses []*model.Course, labs []*model.Laboratory, id model.CourseID) string {
+	for _, c := range courses {
+		if c.CourseID == id {
+			return c.DisplayName
+		}
+	}
+	for _, l := range labs {
+		if l.CourseID == id {
+			return l.DisplayName
+		}
+	}
+	return fmt.Sprintf("course %d", id)
+}
+
+// MatchRow finds the unplaced course of a schedule row. Laboratories are returned with
+// the course they are placed as.
+func MatchRow(row *model.ScheduleCSVRow, courses []*model.Course, labs []*model.Laboratory) (*model.Course, *model.Laboratory) {
+	if course := matchCourse(courses, row); course != nil {
+		return course, nil
+	}
+	if lab := matchLab(labs, row); lab != nil {
+		return scheduler.LabCourse(lab), lab
+	}
+	return nil, nil
+}
+
+// Find the first unplaced course of the row, preferring ones with the same duration and lecturer
+func matchCourse(courses []*model.Course, row *model.ScheduleCSVRow) *model.Course {
+	var best *model.Course
+	bestScore := -1
+	for _, c := range courses {
+		if c.Placed || c.DisplayName != row.CourseCode || c.Department != row.Department || c.Class != row.Class {
+			continue
+		}
+		score := 0
+		if c.Duration == row.Duration {
+			score += 2
+		}
+		if c.Lecturer == row.Lecturer {
+			score++
+		}
+		if score > bestScore {
+			best = c
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// Find the first unplaced laboratory of the row, preferring ones with the same lecturer
+func matchLab(labs []*model.Laboratory, row *model.ScheduleCSVRow) *model.Laboratory {
+	var best *model.Laboratory
+	for _, l := range labs {
+		if l.Placed || l.DisplayName != row.CourseCode || l.Department != row.Department || l.Class != row.Class {
+			continue
+		}
+		if l.Lecturer == row.Lecturer {
+			return l
+		}
+		if best == nil {
+			best = l
+		}
+	}
+	return best
+}
@@ -0,0 +1,58 @@
+package csvio
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+func TestImportRows(t *testing.T) {
+	cfg := scheduler.NewDefaultConfiguration()
+	row := func(code string, class int, day int, time int, room string) *model.ScheduleCSVRow {
+		return &model.ScheduleCSVRow{CourseCode: code, Day: day, Time: time, Duration: 120, Classrooms: room, Class: class, Department: "CENG", Lecturer: "Lect1"}
+	}
+	tests := []struct {
+		name   string
+		rows   []*model.ScheduleCSVRow
+		placed []bool
+		report []string // Lines expected in the report, none if the import is clean
+	}{
+		{"clean", []*model.ScheduleCSVRow{row("CENG101", 1, 0, 0, "R1"), row("CENG201", 2, 0, 0, "R2")}, []bool{true, true}, nil},
+		{"same room on other days", []*model.ScheduleCSVRow{row("CENG101", 1, 0, 0, "R1"), row("CENG201", 2, 1, 0, "R1")}, []bool{true, true}, nil},
+		{"room clash", []*model.ScheduleCSVRow{row("CENG101", 1, 0, 0, "R1"), row("CENG201", 2, 0, 60, "R1")},
+			[]bool{true, true}, []string{"- Row 2 (CENG201 CENG 2): classroom R1 is already occupied by CENG101"}},
+		{"unknown classroom", []*model.ScheduleCSVRow{row("CENG101", 1, 0, 0, "R9")},
+			[]bool{false, false}, []string{"- Row 1 (CENG101 CENG 1): unknown classroom R9"}},
+		{"misaligned time", []*model.ScheduleCSVRow{row("CENG101", 1, 0, 30, "R1")},
+			[]bool{false, false}, []string{"- Row 1 (CENG101 CENG 1): invalid day 0 or time 30"}},
+		{"no matching course", []*model.ScheduleCSVRow{row("CENG101", 1, 0, 0, "R1"), row("CENG101", 1, 1, 0, "R1")},
+			[]bool{true, false}, []string{"- Row 2 (CENG101 CENG 1): no matching unplaced course in inputs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			courses := []*model.Course{
+				{CourseID: 1, Course_Code: "CENG101", DisplayName: "CENG101", Department: "CENG", Class: 1, Lecturer: "Lect1", Duration: 120, NeedsRoom: true},
+				{CourseID: 2, Course_Code: "CENG201", DisplayName: "CENG201", Department: "CENG", Class: 2, Lecturer: "Lect1", Duration: 120, NeedsRoom: true},
+			}
+			rooms := []*model.Classroom{{ID: "R1", Capacity: 40}, {ID: "R2", Capacity: 40}}
+
+			_, errorExists, reportString := ImportRows(tt.rows, cfg, courses, nil, rooms)
+
+			if errorExists != (tt.report != nil) {
+				t.Errorf("errorExists is %t with report:\n%s", errorExists, reportString)
+			}
+			for _, want := range tt.report {
+				if !strings.Contains(reportString, want+"\n") {
+					t.Errorf("report doesn't contain %q:\n%s", want, reportString)
+				}
+			}
+			for i, c := range courses {
+				if c.Placed != tt.placed[i] {
+					t.Errorf("%s placed is %t, want %t", c.Course_Code, c.Placed, tt.placed[i])
+				}
+			}
+		})
+	}
+}
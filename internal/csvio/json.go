@@ -0,0 +1,230 @@
+package csvio
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// ExportScheduleJSON formats the schedule with the versioned JSON export schema and
+// writes it to the file specified by the given path.
+func ExportScheduleJSON(schedule *model.Schedule, path string) string {
+	data, err := json.MarshalIndent(NewScheduleJSON(schedule), "", "  ")
+	if err != nil {
+		fmt.Println("Err03")
+		panic(err)
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		fmt.Println("Err02")
+		panic(err)
+	}
+	return path
+}
+
+// ExportScheduleJSONString formats the schedule with the versioned JSON export schema.
+func ExportScheduleJSONString(schedule *model.Schedule) string {
+	data, err := json.Marshal(NewScheduleJSON(schedule))
+	if err != nil {
+		fmt.Println("Err03")
+		panic(err)
+	}
+	return string(data)
+}
+
+// NewScheduleJSON converts the schedule into the JSON export schema.
+func NewScheduleJSON(schedule *model.Schedule) *model.ScheduleJSON {
+	export := &model.ScheduleJSON{
+		Version:          model.ScheduleJSONVersion,
+		Cost:             schedule.Cost,
+		TimeSlotDuration: schedule.TimeSlotDuration,
+		TimeSlotCount:    schedule.TimeSlotCount,
+		Days:             []*model.DayJSON{},
+	}
+	slotTime := func(slot int) string {
+		start := model.FirstSlotStart + slot*schedule.TimeSlotDuration
+		return fmt.Sprintf("%0.2d:%0.2d", start/60, start%60)
+	}
+
+	days := slices.Clone(schedule.Days)
+	slices.SortFunc(days, func(d1 *model.Day, d2 *model.Day) int {
+		return d1.DayOfWeek - d2.DayOfWeek
+	})
+	for _, day := range days {
+		dayJSON := &model.DayJSON{
+			DayOfWeek: day.DayOfWeek,
+			Name:      model.DayName(day.DayOfWeek),
+			Slots:     []*model.SlotJSON{},
+		}
+		for i, slot := range day.Slots {
+			slotJSON := &model.SlotJSON{
+				Slot:       i,
+				Start:      slotTime(i),
+				End:        slotTime(i + 1),
+				Placements: []*model.PlacementJSON{},
+				Occupied:   []model.CourseID{},
+			}
+			for _, c := range slot.CourseRefs {
+				slotJSON.Occupied = append(slotJSON.Occupied, c.CourseID)
+				// Courses are listed as placements in their first slot only
+				if i > 0 && slices.ContainsFunc(day.Slots[i-1].CourseRefs, func(prev *model.Course) bool { return prev.CourseID == c.CourseID }) {
+					continue
+				}
+				classroom := c.Course_Environment
+				if c.NeedsRoom && c.Classroom != nil {
+					classroom = c.Classroom.ID
+				}
+				neededSlots := (c.Duration + schedule.TimeSlotDuration - 1) / schedule.TimeSlotDuration
+				end := model.FirstSlotStart + i*schedule.TimeSlotDuration + c.Duration
+				slotJSON.Placements = append(slotJSON.Placements, &model.PlacementJSON{
+					Course:    newCourseJSON(c),
+					Classroom: classroom,
+					StartSlot: i,
+					EndSlot:   i + neededSlots,
+					Start:     slotTime(i),
+					End:       fmt.Sprintf("%0.2d:%0.2d", end/60, end%60),
+					Duration:  c.Duration,
+				})
+			}
+			dayJSON.Slots = append(dayJSON.Slots, slotJSON)
+		}
+		export.Days = append(export.Days, dayJSON)
+	}
+	return export
+}
+
+func newCourseJSON(c *model.Course) *model.CourseJSON {
+	return &model.CourseJSON{
+		CourseID:           c.CourseID,
+		CourseCode:         c.Course_Code,
+		DisplayName:        c.DisplayName,
+		Name:               c.Course_Name,
+		Section:            c.Section,
+		Department:         c.Department,
+		Grade:              c.Class,
+		Lecturer:           c.Lecturer,
+		Students:           c.Number_of_Students,
+		Environment:        c.Course_Environment,
+		TplusU:             c.TplusU,
+		AKTS:               c.AKTS,
+		Compulsory:         c.Compulsory,
+		Reserved:           c.Reserved,
+		External:           c.External,
+		ServiceCourse:      c.ServiceCourse,
+		ParentID:           c.ParentID,
+		PartIndex:          c.PartIndex,
+		PartCount:          c.PartCount,
+		SiblingIDs:         append([]model.CourseID{}, c.SiblingIDs...),
+		Laboratory:         len(c.TheoryIDs) != 0,
+		TheoryIDs:          append([]model.CourseID{}, c.TheoryIDs...),
+		ConflictingCourses: append([]model.CourseID{}, c.ConflictingCourses...),
+	}
+}
+
+// ParseScheduleJSON parses a schedule exported by ExportScheduleJSONString.
+func ParseScheduleJSON(data string) (*model.ScheduleJSON, error) {
+	export := &model.ScheduleJSON{}
+	if err := json.Unmarshal([]byte(data), export); err != nil {
+		return nil, err
+	}
+	if export.Version < 1 || export.Version > model.ScheduleJSONVersion {
+		return nil, fmt.Errorf("unsupported schedule export version %d", export.Version)
+	}
+	return export, nil
+}
+
+// ParseScheduleData parses schedule rows from either a JSON or a CSV export.
+func ParseScheduleData(data string) ([]*model.ScheduleCSVRow, error) {
+	if strings.HasPrefix(strings.TrimSpace(data), "{") {
+		export, err := ParseScheduleJSON(data)
+		if err != nil {
+			return nil, err
+		}
+		return export.Rows(), nil
+	}
+	return ParseScheduleString(data)
+}
+
+// ParseScheduleExport parses a JSON export, or converts a CSV export into the JSON export
+// schema. CSV rows don't carry sections and split parts, courses with the same code,
+// department and grade are numbered as parts by their day and time instead.
+func ParseScheduleExport(data string, timeSlotDuration int, timeSlotCount int) (*model.ScheduleJSON, error) {
+	if strings.HasPrefix(strings.TrimSpace(data), "{") {
+		return ParseScheduleJSON(data)
+	}
+	rows, err := ParseScheduleString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &model.ScheduleJSON{
+		Version:          model.ScheduleJSONVersion,
+		TimeSlotDuration: timeSlotDuration,
+		TimeSlotCount:    timeSlotCount,
+		Days:             []*model.DayJSON{},
+	}
+	slotTime := func(minutes int) string {
+		start := model.FirstSlotStart + minutes
+		return fmt.Sprintf("%0.2d:%0.2d", start/60, start%60)
+	}
+	rows = slices.Clone(rows)
+	slices.SortStableFunc(rows, func(r1 *model.ScheduleCSVRow, r2 *model.ScheduleCSVRow) int {
+		if r1.Day != r2.Day {
+			return r1.Day - r2.Day
+		}
+		return r1.Time - r2.Time
+	})
+	parts := map[string]int{}
+	for _, row := range rows {
+		if row.Day < 0 || row.Time < 0 {
+			return nil, fmt.Errorf("invalid day %d or time %d of %s", row.Day, row.Time, row.CourseCode)
+		}
+		for len(export.Days) <= row.Day {
+			dayOfWeek := len(export.Days)
+			day := &model.DayJSON{DayOfWeek: dayOfWeek, Name: model.DayName(dayOfWeek), Slots: []*model.SlotJSON{}}
+			for i := 0; i < timeSlotCount; i++ {
+				day.Slots = append(day.Slots, &model.SlotJSON{
+					Slot:       i,
+					Start:      slotTime(i * timeSlotDuration),
+					End:        slotTime((i + 1) * timeSlotDuration),
+					Placements: []*model.PlacementJSON{},
+					Occupied:   []model.CourseID{},
+				})
+			}
+			export.Days = append(export.Days, day)
+		}
+		slot := row.Time / timeSlotDuration
+		if slot >= timeSlotCount {
+			return nil, fmt.Errorf("invalid time %d of %s", row.Time, row.CourseCode)
+		}
+
+		key := fmt.Sprintf("%s\x00%s\x00%d", row.CourseCode, row.Department, row.Class)
+		lab := strings.HasSuffix(row.CourseCode, " - LAB")
+		course := &model.CourseJSON{
+			CourseCode:  strings.TrimSuffix(row.CourseCode, " - LAB"),
+			DisplayName: row.CourseCode,
+			Name:        row.CourseName,
+			Department:  row.Department,
+			Grade:       row.Class,
+			Lecturer:    row.Lecturer,
+			PartIndex:   parts[key],
+			Laboratory:  lab,
+		}
+		parts[key]++
+		neededSlots := (row.Duration + timeSlotDuration - 1) / timeSlotDuration
+		export.Days[row.Day].Slots[slot].Placements = append(export.Days[row.Day].Slots[slot].Placements, &model.PlacementJSON{
+			Course:    course,
+			Classroom: row.Classrooms,
+			StartSlot: slot,
+			EndSlot:   slot + neededSlots,
+			Start:     slotTime(slot * timeSlotDuration),
+			End:       slotTime(slot*timeSlotDuration + row.Duration),
+			Duration:  row.Duration,
+		})
+	}
+	return export, nil
+}
@@ -156,6 +156,24 @@ func LoadCourses(cfg *scheduler.Configuration, delim rune, ignored []string) ([]
 		reportString = reportString + "Failed to parse data from " + cfg.ExternalFile + " file. Please check the data integrity and format.\n"
 	}
 
+	// Lecturer limits are optional, configured defaults apply to everyone else
+	_limits := []*model.LecturerLimit{}
+	if cfg.LecturerLimitsFile != "" {
+		limitsFile, err := os.OpenFile(cfg.LecturerLimitsFile, os.O_RDWR, os.ModePerm)
+		if err != nil {
+			fmt.Println("Err00")
+			errorExists = true
+			reportString = reportString + "Failed to open " + cfg.LecturerLimitsFile + " file. Please make sure the file exists.\n"
+		}
+		defer limitsFile.Close()
+
+		if err := gocsv.UnmarshalFile(limitsFile, &_limits); err != nil {
+			fmt.Println("Err01")
+			errorExists = true
+			reportString = reportString + "Failed to parse data from " + cfg.LecturerLimitsFile + " file. Please check the data integrity and format.\n"
+		}
+	}
+
 	if errorExists {
 		return nil, nil, nil, nil, nil, nil, nil, true, reportString
 	}
@@ -192,12 +210,14 @@ func LoadCourses(cfg *scheduler.Configuration, delim rune, ignored []string) ([]
 			DisplayName:              e.Course_Name,
 			ServiceCourse:            false,
 			HasBeenSplit:             false,
-			IsFirstHalf:              false,
 			HasLab:                   false,
 			PlacedDay:                -1,
 			AreEqual:                 false,
-			IsBiggerHalf:             false,
-			OtherHalfID:              0,
+			ParentID:                 0,
+			PartIndex:                0,
+			PartCount:                1,
+			SiblingIDs:               []model.CourseID{},
+			External:                 true,
 		}
 		_courses = append(_courses, &externalCourse)
 
@@ -258,6 +278,7 @@ func LoadCourses(cfg *scheduler.Configuration, delim rune, ignored []string) ([]
 
 	// Assign miscellaneous properties
 	courses, labs := assignCourseProperties(courses, busy, _splits)
+	assignLecturerLimits(courses, labs, _limits, cfg)
 
 	// Count up 4th class courses
 	congestedDepartments, uniqueDepartments := FindFourthClassCount(courses)
@@ -293,6 +314,11 @@ func LoadClassrooms(path string, delim rune) ([]*model.Classroom, bool, string)
 		return nil, true, reportString
 	}
 
+	// Sort once by capacity, room search relies on smaller rooms coming first
+	slices.SortStableFunc(classrooms, func(c1 *model.Classroom, c2 *model.Classroom) int {
+		return c1.Capacity - c2.Capacity
+	})
+
 	for _, c := range classrooms {
 		if !c.AssignAvailableDays() {
 			errorExists = true
@@ -329,120 +355,79 @@ func assignCourseProperties(courses []*model.Course, busy []*model.Busy, splits
 			fmt.Println(course)
 			panic(err)
 		}
-		var shouldSplit bool = false
-		var firstHalf int = 0
-		var secondHalf int = 0
-		var newCourse1 model.Course
-		var newCourse2 model.Course
+		var durations []int
+		parts := []*model.Course{}
 		hasLab := U != 0
 
 		for _, _s := range splits {
-			if _s.Course_Code == course.Course_Code && _s.Course_Department == course.Department && (_s.Half_Duration > T || _s.Half_Duration <= 0) {
-				fmt.Print(_s)
-				panic("Invalid Half duration!")
-			}
-			if _s.Course_Code == course.Course_Code && _s.Course_Department == course.Department && _s.Half_Duration < T {
-				shouldSplit = true
-				firstHalf = _s.Half_Duration
+			if _s.Course_Code == course.Course_Code && _s.Course_Department == course.Department {
+				durations = splitDurations(_s, T)
 				break
 			}
 		}
+		shouldSplit := len(durations) > 1
 
-		ratio := float32(firstHalf) / float32(T)
-
-		// Split into first half
+		// Split into parts linked to the parent course
 		if shouldSplit {
-			secondHalf = T - firstHalf
-			newCourse1 = model.Course{
-				Section:                  course.Section,
-				Course_Code:              course.Course_Code,
-				Course_Name:              course.Course_Name,
-				Number_of_Students:       course.Number_of_Students,
-				Course_Environment:       course.Course_Environment,
-				TplusU:                   course.TplusU,
-				AKTS:                     course.AKTS * ratio,
-				Class:                    course.Class,
-				Department:               course.Department,
-				Lecturer:                 course.Lecturer,
-				Duration:                 firstHalf * 60,
-				CourseID:                 id,
-				ConflictingCourses:       []model.CourseID{},
-				Placed:                   false,
-				Classroom:                nil,
-				NeedsRoom:                course.Course_Environment == "classroom",
-				NeededSlots:              0,
-				Reserved:                 false,
-				ReservedStartingTimeSlot: 0,
-				ReservedDay:              0,
-				BusyDays:                 []int{},
-				Compulsory:               course.Compulsory,
-				ConflictProbability:      0.0,
-				DisplayName:              course.Course_Code,
-				ServiceCourse:            false,
-				HasBeenSplit:             true,
-				IsFirstHalf:              true,
-				HasLab:                   hasLab,
-				PlacedDay:                -1,
-				AreEqual:                 firstHalf == secondHalf,
-				IsBiggerHalf:             firstHalf >= secondHalf,
-				OtherHalfID:              id + 1,
-			}
-			// Don't forget to assign busy days
-			for _, busyDay := range busy {
-				if busyDay.Lecturer == newCourse1.Lecturer {
-					newCourse1.BusyDays = busyDay.Day
-					break
+			parentID := id
+			siblingIDs := make([]model.CourseID, len(durations))
+			areEqual := true
+			for i, d := range durations {
+				siblingIDs[i] = parentID + model.CourseID(i+1)
+				if d != durations[0] {
+					areEqual = false
 				}
 			}
-			additionalCourses = append(additionalCourses, &newCourse1)
-			id++
-
-			ratio = float32(secondHalf) / float32(T)
-			// Split into second half
-			newCourse2 = model.Course{
-				Section:                  course.Section,
-				Course_Code:              course.Course_Code,
-				Course_Name:              course.Course_Name,
-				Number_of_Students:       course.Number_of_Students,
-				Course_Environment:       course.Course_Environment,
-				TplusU:                   course.TplusU,
-				AKTS:                     course.AKTS * ratio,
-				Class:                    course.Class,
-				Department:               course.Department,
-				Lecturer:                 course.Lecturer,
-				Duration:                 secondHalf * 60,
-				CourseID:                 id,
-				ConflictingCourses:       []model.CourseID{},
-				Placed:                   false,
-				Classroom:                nil,
-				NeedsRoom:                course.Course_Environment == "classroom",
-				NeededSlots:              0,
-				Reserved:                 false,
-				ReservedStartingTimeSlot: 0,
-				ReservedDay:              0,
-				BusyDays:                 []int{},
-				Compulsory:               course.Compulsory,
-				ConflictProbability:      0.0,
-				DisplayName:              course.Course_Code,
-				ServiceCourse:            false,
-				HasBeenSplit:             true,
-				IsFirstHalf:              false,
-				HasLab:                   hasLab,
-				PlacedDay:                -1,
-				AreEqual:                 firstHalf == secondHalf,
-				IsBiggerHalf:             firstHalf < secondHalf,
-				OtherHalfID:              newCourse1.CourseID,
-			}
-			// Don't forget to assign busy days
-			for _, busyDay := range busy {
-				if busyDay.Lecturer == newCourse2.Lecturer {
-					newCourse2.BusyDays = busyDay.Day
-					break
+			for i, d := range durations {
+				id++
+				ratio := float32(d) / float32(T)
+				newCourse := model.Course{
+					Section:                  course.Section,
+					Course_Code:              course.Course_Code,
+					Course_Name:              course.Course_Name,
+					Number_of_Students:       course.Number_of_Students,
+					Course_Environment:       course.Course_Environment,
+					TplusU:                   course.TplusU,
+					AKTS:                     course.AKTS * ratio,
+					Class:                    course.Class,
+					Department:               course.Department,
+					Lecturer:                 course.Lecturer,
+					RoomStability:            course.RoomStability,
+					Duration:                 d * 60,
+					CourseID:                 id,
+					ConflictingCourses:       []model.CourseID{},
+					Placed:                   false,
+					Classroom:                nil,
+					NeedsRoom:                course.Course_Environment == "classroom",
+					NeededSlots:              0,
+					Reserved:                 false,
+					ReservedStartingTimeSlot: 0,
+					ReservedDay:              0,
+					BusyDays:                 []int{},
+					Compulsory:               course.Compulsory,
+					ConflictProbability:      0.0,
+					DisplayName:              course.Course_Code,
+					ServiceCourse:            false,
+					HasBeenSplit:             true,
+					HasLab:                   hasLab,
+					PlacedDay:                -1,
+					AreEqual:                 areEqual,
+					ParentID:                 parentID,
+					PartIndex:                i,
+					PartCount:                len(durations),
+					SiblingIDs:               siblingIDs,
 				}
+				// Don't forget to assign busy days
+				for _, busyDay := range busy {
+					if busyDay.Lecturer == newCourse.Lecturer {
+						newCourse.BusyDays = busyDay.Day
+						break
+					}
+				}
+				parts = append(parts, &newCourse)
+				additionalCourses = append(additionalCourses, &newCourse)
 			}
-			additionalCourses = append(additionalCourses, &newCourse2)
 			id++
-
 		}
 
 		course.Duration = 60 * T
@@ -467,6 +452,7 @@ func assignCourseProperties(courses []*model.Course, busy []*model.Busy, splits
 				Class:                    course.Class,
 				Department:               course.Department,
 				Lecturer:                 course.Lecturer,
+				RoomStability:            course.RoomStability,
 				Duration:                 60 * U,
 				CourseID:                 id,
 				ConflictingCourses:       []model.CourseID{},
@@ -493,8 +479,7 @@ func assignCourseProperties(courses []*model.Course, busy []*model.Busy, splits
 			}
 
 			if shouldSplit {
-				newLab.TheoreticalCourseRef = append(newLab.TheoreticalCourseRef, &newCourse1)
-				newLab.TheoreticalCourseRef = append(newLab.TheoreticalCourseRef, &newCourse2)
+				newLab.TheoreticalCourseRef = append(newLab.TheoreticalCourseRef, parts...)
 			} else {
 				newLab.TheoreticalCourseRef = append(newLab.TheoreticalCourseRef, course)
 			}
@@ -509,6 +494,8 @@ func assignCourseProperties(courses []*model.Course, busy []*model.Busy, splits
 			course.PlacedDay = -1
 			course.HasLab = hasLab
 			course.AreEqual = true
+			course.ParentID = course.CourseID
+			course.PartCount = 1
 			additionalCourses = append(additionalCourses, course)
 			course.NeedsRoom = course.Course_Environment == "classroom"
 			for _, busyDay := range busy {
@@ -523,6 +510,38 @@ func assignCourseProperties(courses []*model.Course, busy []*model.Busy, splits
 	return additionalCourses, additionalLabs
 }
 
+// Parse session durations of a split entry, either a list such as "2,1,1" or
+// Half_Duration and the remainder for two-way splits
+func splitDurations(s *model.Split, T int) []int {
+	if s.Durations == "" {
+		if s.Half_Duration > T || s.Half_Duration <= 0 {
+			fmt.Print(s)
+			panic("Invalid Half duration!")
+		}
+		if s.Half_Duration == T {
+			return []int{T}
+		}
+		return []int{s.Half_Duration, T - s.Half_Duration}
+	}
+
+	durations := []int{}
+	total := 0
+	for _, d := range strings.Split(s.Durations, ",") {
+		duration, err := strconv.Atoi(strings.TrimSpace(d))
+		if err != nil || duration <= 0 {
+			fmt.Print(s)
+			panic("Invalid split duration!")
+		}
+		durations = append(durations, duration)
+		total += duration
+	}
+	if total != T {
+		fmt.Print(s)
+		panic("Split durations don't add up to T!")
+	}
+	return durations
+}
+
 // Parse relevant data
 func assignReservedCourseProperties(course *model.Course, reserved *model.Reserved) {
 	startHH, err0 := strconv.Atoi(substr(reserved.StartingTimeSTR, 0, 2))
@@ -578,6 +597,64 @@ func assignReservedCourseProperties(course *model.Course, reserved *model.Reserv
 	//reserved.CourseRef = course
 }
 
+// Resolve per-lecturer limits against configured defaults and attach them to courses and labs
+func assignLecturerLimits(courses []*model.Course, labs []*model.Laboratory, limits []*model.LecturerLimit, cfg *scheduler.Configuration) {
+	resolved := map[string]*model.LecturerLimit{}
+	for _, l := range limits {
+		limit := &model.LecturerLimit{
+			Lecturer:            l.Lecturer,
+			MaxDailyHours:       l.MaxDailyHours,
+			MaxConsecutiveHours: l.MaxConsecutiveHours,
+			MaxDays:             l.MaxDays,
+			MinDays:             l.MinDays,
+			NoEarlyAfterLateSTR: l.NoEarlyAfterLateSTR,
+			NoEarlyAfterLate:    cfg.NoEarlyAfterLate,
+		}
+		// Zero values fall back to defaults
+		if limit.MaxDailyHours <= 0 {
+			limit.MaxDailyHours = cfg.MaxLecturerDailyHours
+		}
+		if limit.MaxConsecutiveHours <= 0 {
+			limit.MaxConsecutiveHours = cfg.MaxLecturerConsecutiveHours
+		}
+		if limit.MaxDays <= 0 {
+			limit.MaxDays = cfg.MaxLecturerDays
+		}
+		if limit.MinDays <= 0 {
+			limit.MinDays = cfg.MinLecturerDays
+		}
+		switch strings.ToLower(strings.TrimSpace(l.NoEarlyAfterLateSTR)) {
+		case "yes", "true", "1":
+			limit.NoEarlyAfterLate = true
+		case "no", "false", "0":
+			limit.NoEarlyAfterLate = false
+		}
+		resolved[l.Lecturer] = limit
+	}
+
+	defaultLimit := &model.LecturerLimit{
+		MaxDailyHours:       cfg.MaxLecturerDailyHours,
+		MaxConsecutiveHours: cfg.MaxLecturerConsecutiveHours,
+		MaxDays:             cfg.MaxLecturerDays,
+		MinDays:             cfg.MinLecturerDays,
+		NoEarlyAfterLate:    cfg.NoEarlyAfterLate,
+	}
+	for _, c := range courses {
+		if limit, ok := resolved[c.Lecturer]; ok {
+			c.Limits = limit
+		} else {
+			c.Limits = defaultLimit
+		}
+	}
+	for _, l := range labs {
+		if limit, ok := resolved[l.Lecturer]; ok {
+			l.Limits = limit
+		} else {
+			l.Limits = defaultLimit
+		}
+	}
+}
+
 // Combine multi-line entries into one
 func mergeBusyDays(busy []*model.Busy, multibusy []*model.BusyCSV) []*model.Busy {
 	for _, b1 := range multibusy {
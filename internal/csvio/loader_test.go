@@ -0,0 +1,45 @@
+package csvio
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+func TestSplitDurations(t *testing.T) {
+	tests := []struct {
+		name  string
+		split model.Split
+		T     int
+		want  []int // nil if the entry is invalid
+	}{
+		{"half duration", model.Split{Half_Duration: 2}, 3, []int{2, 1}},
+		{"half duration is the whole course", model.Split{Half_Duration: 3}, 3, []int{3}},
+		{"durations", model.Split{Durations: "2,1,1"}, 4, []int{2, 1, 1}},
+		{"durations with spaces", model.Split{Durations: " 1, 1 ,2"}, 4, []int{1, 1, 2}},
+		{"durations win over half duration", model.Split{Half_Duration: 1, Durations: "2,2"}, 4, []int{2, 2}},
+		{"half duration too long", model.Split{Half_Duration: 4}, 3, nil},
+		{"no half duration", model.Split{}, 3, nil},
+		{"durations don't add up", model.Split{Durations: "2,1"}, 4, nil},
+		{"zero duration", model.Split{Durations: "4,0"}, 4, nil},
+		{"not a number", model.Split{Durations: "2,x"}, 4, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if r != nil && tt.want != nil {
+					t.Errorf("panicked: %v", r)
+				}
+				if r == nil && tt.want == nil {
+					t.Error("accepted an invalid split")
+				}
+			}()
+			got := splitDurations(&tt.split, tt.T)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package csvio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// LoadLocks reads and parses given csv file for locked sessions.
+func LoadLocks(path string, delim rune) ([]*model.Lock, bool, string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Println("Err00")
+		return nil, true, "Failed to open " + path + " file. Please make sure the file exists.\n"
+	}
+	locks, err := ParseLocks(string(data), delim)
+	if err != nil {
+		fmt.Println("Err01")
+		return nil, true, "Failed to parse data from " + path + " file. Please check the data integrity and format.\n"
+	}
+	return locks, false, ""
+}
+
+// ParseLocks parses locked sessions from csv data with a header line.
+func ParseLocks(data string, delim rune) ([]*model.Lock, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.Comma = delim
+	locks := []*model.Lock{}
+	if err := gocsv.UnmarshalCSV(r, &locks); err != nil {
+		return nil, err
+	}
+	return locks, nil
+}
+
+// ExportLocks formats locked sessions as csv data with a header line.
+func ExportLocks(locks []*model.Lock, delim rune) (string, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	w.Comma = delim
+	if err := gocsv.MarshalCSV(locks, gocsv.NewSafeCSVWriter(w)); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// ApplyLocks matches locks to the loaded courses, laboratories and classrooms and adds
+// them to the reserved courses, replacing reservations of the same course. Locks that
+// don't match anything or have an invalid day or time are skipped and reported.
+func ApplyLocks(locks []*model.Lock, reserved []*model.Reserved, cfg *scheduler.Configuration, courses []*model.Course, labs []*model.Laboratory, rooms []*model.Classroom) ([]*model.Reserved, bool, string) {
+	var errorExists bool = false
+	var reportString string = ""
+
+	for i, l := range locks {
+		line := fmt.Sprintf("- Lock %d (%s %s): ", i+1, l.Department, l.CourseCodeSTR)
+		code := strings.ReplaceAll(strings.TrimSpace(l.CourseCodeSTR), ",", "_")
+
+		day, slot, ok := ParseDayTime(l.DaySTR, l.StartingTimeSTR, cfg)
+		if !ok {
+			errorExists = true
+			reportString = reportString + line + "invalid day " + l.DaySTR + " or starting time " + l.StartingTimeSTR + "\n"
+			continue
+		}
+
+		var room *model.Classroom
+		if id := strings.TrimSpace(l.ClassroomSTR); id != "" {
+			for _, r := range rooms {
+				if r.ID == id {
+					room = r
+					break
+				}
+			}
+			if room == nil {
+				errorExists = true
+				reportString = reportString + line + "unknown classroom " + id + "\n"
+				continue
+			}
+		}
+
+		entry := &model.Reserved{
+			Department:      l.Department,
+			CourseCodeSTR:   code,
+			StartingTimeSTR: l.StartingTimeSTR,
+			DaySTR:          l.DaySTR,
+			Room:            room,
+			Locked:          true,
+		}
+		switch strings.ToLower(strings.TrimSpace(l.LabSTR)) {
+		case "yes", "true", "1":
+			for _, lab := range labs {
+				if lab.Course_Code == code && lab.Department == l.Department && (l.Section == 0 || lab.Section == l.Section) && !lab.Reserved {
+					entry.LabRef = lab
+					break
+				}
+			}
+			if entry.LabRef == nil {
+				errorExists = true
+				reportString = reportString + line + "no matching unlocked laboratory\n"
+				continue
+			}
+			entry.LabRef.Reserved = true
+			entry.LabRef.ReservedDay = day
+			entry.LabRef.ReservedStartingTimeSlot = slot
+		default:
+			part := max(l.Part-1, 0)
+			for _, c := range courses {
+				if c.Course_Code == code && c.Department == l.Department && (l.Section == 0 || c.Section == l.Section) && c.PartIndex == part && !isLocked(reserved, c) {
+					entry.CourseRef = c
+					break
+				}
+			}
+			if entry.CourseRef == nil {
+				errorExists = true
+				reportString = reportString + line + "no matching unlocked course or split part\n"
+				continue
+			}
+			// A lock replaces the reservation of the course
+			reserved = slices.DeleteFunc(reserved, func(r *model.Reserved) bool { return r.CourseRef == entry.CourseRef })
+			entry.CourseRef.Reserved = true
+			entry.CourseRef.ReservedDay = day
+			entry.CourseRef.ReservedStartingTimeSlot = slot
+		}
+		reserved = append(reserved, entry)
+	}
+
+	return reserved, errorExists, reportString
+}
+
+func isLocked(reserved []*model.Reserved, course *model.Course) bool {
+	return slices.ContainsFunc(reserved, func(r *model.Reserved) bool { return r.Locked && r.CourseRef == course })
+}
+
+// ParseDayTime converts a day name and HH:MM starting time into day of week and time slot.
+func ParseDayTime(day string, startingTime string, cfg *scheduler.Configuration) (int, int, bool) {
+	dayOfWeek := -1
+	for d := 0; d < min(cfg.NumberOfDays, model.WeekLength); d++ {
+		if model.DayName(d) == strings.TrimSpace(day) {
+			dayOfWeek = d
+		}
+	}
+	slot, ok := parseSlot(startingTime, cfg.TimeSlotDuration, cfg.TimeSlotCount)
+	return dayOfWeek, slot, ok && dayOfWeek >= 0
+}
+
+func parseSlot(startingTime string, timeSlotDuration int, timeSlotCount int) (int, bool) {
+	hh, mm, found := strings.Cut(strings.TrimSpace(startingTime), ":")
+	if !found {
+		return 0, false
+	}
+	hours, err0 := strconv.Atoi(hh)
+	minutes, err1 := strconv.Atoi(mm)
+	if err0 != nil || err1 != nil || minutes < 0 || minutes > 59 {
+		return 0, false
+	}
+	offset := hours*60 + minutes - model.FirstSlotStart
+	if offset < 0 || offset%timeSlotDuration != 0 || offset/timeSlotDuration >= timeSlotCount {
+		return 0, false
+	}
+	return offset / timeSlotDuration, true
+}
+
+// LocksString lists locked sessions and the hard constraints of the ones that couldn't be placed.
+func LocksString(reserved []*model.Reserved) string {
+	reportString := ""
+	for _, r := range reserved {
+		if !r.Locked {
+			continue
+		}
+		name := r.CourseCodeSTR
+		if r.LabRef != nil {
+			name = r.LabRef.DisplayName
+		} else if r.CourseRef.PartCount > 1 {
+			name = fmt.Sprintf("%s part %d/%d", name, r.CourseRef.PartIndex+1, r.CourseRef.PartCount)
+		}
+		reportString = reportString + r.Department + " " + name + " " + r.DaySTR + " " + r.StartingTimeSTR
+		if r.Room != nil {
+			reportString = reportString + " " + r.Room.ID
+		}
+		if r.Violation != "" {
+			reportString = reportString + " is not placed: " + r.Violation
+		}
+		reportString = reportString + "\n"
+	}
+	return reportString
+}
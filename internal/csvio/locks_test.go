@@ -0,0 +1,123 @@
+package csvio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+func TestLocksRoundTrip(t *testing.T) {
+	locks := []*model.Lock{
+		{Department: "CENG", CourseCodeSTR: "CENG101", Section: 1, DaySTR: "Monday", StartingTimeSTR: "08:30", ClassroomSTR: "R1"},
+		{Department: "EE", CourseCodeSTR: "EE201", Part: 2, LabSTR: "Yes", DaySTR: "Friday", StartingTimeSTR: "13:30"},
+	}
+	data, err := ExportLocks(locks, ';')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := strings.SplitN(data, "\n", 2)[0]; header != "Department;Course_Code;Section;Part;Lab;Day;Starting_Time;Classroom" {
+		t.Errorf("got header %q", header)
+	}
+	parsed, err := ParseLocks(data, ';')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, locks) {
+		t.Errorf("parsed %v, want %v", parsed, locks)
+	}
+}
+
+func TestLocksKeepScheduleDelimiter(t *testing.T) {
+	// Lock delimiters must not leak into the schedule CSV of later exports
+	if _, err := ExportLocks([]*model.Lock{{Department: "CENG", CourseCodeSTR: "CENG101"}}, ';'); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseLocks("Department;Course_Code\nCENG;CENG101\n", ';'); err != nil {
+		t.Fatal(err)
+	}
+	rows := []*model.ScheduleCSVRow{{CourseCode: "CENG101", Day: 1, Time: 60, Duration: 120, Classrooms: "R1", Class: 1, Department: "CENG", Lecturer: "Lect1"}}
+	data, err := ExportRowsString(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(data, "course_code,day,time") {
+		t.Fatalf("schedule exported as %q", data)
+	}
+	parsed, err := ParseScheduleString(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, rows) {
+		t.Errorf("parsed %v, want %v", parsed, rows)
+	}
+}
+
+func TestParseDayTime(t *testing.T) {
+	cfg := scheduler.NewDefaultConfiguration()
+	tests := []struct {
+		day, time string
+		days      int
+		wantDay   int
+		wantSlot  int
+		ok        bool
+	}{
+		{"Monday", "08:30", 5, 0, 0, true},
+		{" Tuesday ", "10:30", 5, 1, 2, true},
+		{"Friday", "16:30", 5, 4, 8, true},
+		{"Friday", "17:30", 5, 0, 0, false}, // After the last time slot
+		{"Monday", "09:00", 5, 0, 0, false}, // Between time slots
+		{"Monday", "07:30", 5, 0, 0, false},
+		{"Monday", "8", 5, 0, 0, false},
+		{"Saturday", "08:30", 5, 0, 0, false},
+		{"Thursday", "08:30", 3, 0, 0, false}, // Outside a three day week
+	}
+	for _, tt := range tests {
+		cfg.NumberOfDays = tt.days
+		day, slot, ok := ParseDayTime(tt.day, tt.time, cfg)
+		if ok != tt.ok || (ok && (day != tt.wantDay || slot != tt.wantSlot)) {
+			t.Errorf("ParseDayTime(%q, %q) = %d, %d, %t, want %d, %d, %t", tt.day, tt.time, day, slot, ok, tt.wantDay, tt.wantSlot, tt.ok)
+		}
+	}
+}
+
+func TestApplyLocks(t *testing.T) {
+	cfg := scheduler.NewDefaultConfiguration()
+	room := &model.Classroom{ID: "R1", Capacity: 40}
+	theory := &model.Course{Course_Code: "CENG101", Department: "CENG", Section: 1}
+	second := &model.Course{Course_Code: "CENG101", Department: "CENG", Section: 2}
+	lab := &model.Laboratory{Course_Code: "CENG101", Department: "CENG", Section: 1}
+	previous := &model.Reserved{Department: "CENG", CourseCodeSTR: "CENG101", CourseRef: theory}
+
+	locks := []*model.Lock{
+		{Department: "CENG", CourseCodeSTR: "CENG101", Section: 1, DaySTR: "Tuesday", StartingTimeSTR: "10:30", ClassroomSTR: "R1"},
+		{Department: "CENG", CourseCodeSTR: "CENG101", LabSTR: "yes", DaySTR: "Friday", StartingTimeSTR: "13:30"},
+		{Department: "CENG", CourseCodeSTR: "CENG101", Section: 3, DaySTR: "Monday", StartingTimeSTR: "08:30"},
+		{Department: "CENG", CourseCodeSTR: "CENG101", DaySTR: "Monday", StartingTimeSTR: "08:30", ClassroomSTR: "R9"},
+		{Department: "CENG", CourseCodeSTR: "CENG101", DaySTR: "Sunday", StartingTimeSTR: "08:30"},
+	}
+	reserved, errorExists, reportString := ApplyLocks(locks, []*model.Reserved{previous}, cfg, []*model.Course{theory, second}, []*model.Laboratory{lab}, []*model.Classroom{room})
+
+	if !errorExists {
+		t.Error("locks that don't match were not reported")
+	}
+	for _, want := range []string{"Lock 3 (CENG CENG101): no matching unlocked course", "Lock 4 (CENG CENG101): unknown classroom R9", "Lock 5 (CENG CENG101): invalid day Sunday"} {
+		if !strings.Contains(reportString, want) {
+			t.Errorf("report doesn't contain %q:\n%s", want, reportString)
+		}
+	}
+	if len(reserved) != 2 {
+		t.Fatalf("got %d reservations, want the lock of the course replacing its reservation and the lab lock", len(reserved))
+	}
+	if r := reserved[0]; r.CourseRef != theory || r.Room != room || !r.Locked || theory.ReservedDay != 1 || theory.ReservedStartingTimeSlot != 2 {
+		t.Errorf("course lock %+v placed the course on day %d slot %d", *r, theory.ReservedDay, theory.ReservedStartingTimeSlot)
+	}
+	if r := reserved[1]; r.LabRef != lab || !lab.Reserved || lab.ReservedDay != 4 || lab.ReservedStartingTimeSlot != 5 {
+		t.Errorf("lab lock %+v placed the lab on day %d slot %d", *r, lab.ReservedDay, lab.ReservedStartingTimeSlot)
+	}
+	if second.Reserved {
+		t.Error("lock of section 1 reserved section 2")
+	}
+}
@@ -1,6 +1,7 @@
 package csvio
 
 import (
+	"encoding/csv"
 	"fmt"
 	"os"
 	"slices"
@@ -62,6 +63,21 @@ func ExportScheduleString(schedule *model.Schedule) string {
 	return str
 }
 
+// ExportRowsString formats schedule rows as CSV.
+func ExportRowsString(rows []*model.ScheduleCSVRow) (string, error) {
+	return gocsv.MarshalString(&rows)
+}
+
+// ParseScheduleString parses schedule rows exported by ExportScheduleString.
+func ParseScheduleString(data string) ([]*model.ScheduleCSVRow, error) {
+	rows := []*model.ScheduleCSVRow{}
+	r := csv.NewReader(strings.NewReader(data))
+	if err := gocsv.UnmarshalCSV(r, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
 // PrintSchedule prints weekly schedule grouped by department name.
 func PrintSchedule(schedule *model.Schedule) {
 	days := []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
@@ -104,7 +120,7 @@ func formatAndFilterSchedule(schedule *model.Schedule) []*model.ScheduleCSVRow {
 					continue
 				}
 				classroom := c.Course_Environment
-				if c.NeedsRoom {
+				if c.NeedsRoom && c.Classroom != nil {
 					classroom = c.Classroom.ID
 				}
 				//conflictp := fmt.Sprintf("%v", c.ConflictProbability) // put this in Lecturer to see conflict probability of each course
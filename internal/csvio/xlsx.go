@@ -0,0 +1,417 @@
+package csvio
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rhyrak/go-schedule/internal/timetable"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+const (
+	styleDefault = iota
+	styleHeader
+	styleTime
+	styleFirstCourse // Course styles start here, one per course code
+)
+
+type xlsxCell struct {
+	value string
+	style int
+}
+
+type xlsxSheet struct {
+	name      string
+	cells     map[int]map[int]xlsxCell // cells[row][column], both starting at 1
+	merges    []string
+	colWidths map[int]float64
+	rowCount  int
+}
+
+type xlsxWorkbook struct {
+	sheets  []*xlsxSheet
+	courses []string // Course codes in style order
+}
+
+// ExportWorkbook formats the schedule as an XLSX workbook and writes it to the file
+// specified by the given path.
+func ExportWorkbook(schedule *model.Schedule, path string) string {
+	out, err := os.Create(path)
+	if err != nil {
+		fmt.Println("Err02")
+		panic(err)
+	}
+	defer out.Close()
+
+	err = WriteWorkbook(out, formatAndFilterSchedule(schedule), len(schedule.Days), schedule.TimeSlotDuration, schedule.TimeSlotCount)
+	if err != nil {
+		fmt.Println("Err03")
+		panic(err)
+	}
+	return path
+}
+
+// WriteWorkbook writes schedule rows as an XLSX workbook with one grade by day grid
+// sheet per department followed by a classroom and a lecturer sheet.
+func WriteWorkbook(w io.Writer, rows []*model.ScheduleCSVRow, days int, timeSlotDuration int, timeSlotCount int) error {
+	tt := timetable.FromRows(rows, days, timeSlotDuration, timeSlotCount)
+	wb := &xlsxWorkbook{}
+
+	departments := []string{}
+	for _, e := range tt.Entries {
+		if !slices.Contains(departments, e.Department) {
+			departments = append(departments, e.Department)
+		}
+	}
+	slices.Sort(departments)
+	for _, department := range departments {
+		wb.addDepartmentSheet(tt, department)
+	}
+	classroom := func(e *timetable.Entry) string { return e.Classroom }
+	lecturer := func(e *timetable.Entry) string { return e.Lecturer }
+	wb.addTimelineSheet(tt, "Classrooms", "Classroom", tt.Classrooms(), classroom, lecturer)
+	wb.addTimelineSheet(tt, "Lecturers", "Lecturer", tt.Lecturers(), lecturer, classroom)
+
+	return wb.write(w)
+}
+
+// Department sheet has a block per grade with time slots as rows and days as columns.
+// Parallel sessions of a day get their own columns.
+func (wb *xlsxWorkbook) addDepartmentSheet(tt *timetable.Timetable, department string) {
+	sh := wb.addSheet(department)
+	sh.set(1, 1, department, styleHeader)
+	sh.colWidths[1] = 8
+	row := 3
+
+	grades := []int{}
+	for _, e := range tt.Entries {
+		if e.Department == department && !slices.Contains(grades, e.Grade) {
+			grades = append(grades, e.Grade)
+		}
+	}
+	slices.Sort(grades)
+	for _, grade := range grades {
+		grid := tt.Cohort(department, grade)
+		sessions := grid.Sessions()
+
+		// Place each day's sessions into lanes so parallel sessions don't overlap
+		col := 2
+		dayCols := make([]int, len(grid.Days))
+		lanes := map[*timetable.Entry]int{}
+		for d := range grid.Days {
+			daySessions := []*timetable.Entry{}
+			for _, e := range sessions {
+				if e.Day == d {
+					daySessions = append(daySessions, e)
+				}
+			}
+			count := assignLanes(daySessions, tt, lanes)
+			dayCols[d] = col
+			sh.set(row+1, col, grid.Days[d], styleHeader)
+			if count > 1 {
+				sh.merge(row+1, col, row+1, col+count-1)
+			}
+			for c := col; c < col+count; c++ {
+				sh.colWidths[c] = 18
+			}
+			col += count
+		}
+
+		sh.set(row, 1, fmt.Sprintf("Grade %d", grade), styleHeader)
+		sh.merge(row, 1, row, col-1)
+		sh.set(row+1, 1, "Time", styleHeader)
+		for s, time := range grid.Times {
+			sh.set(row+2+s, 1, time, styleTime)
+		}
+		for _, e := range sessions {
+			r := row + 2 + e.Slot
+			c := dayCols[e.Day] + lanes[e]
+			sh.set(r, c, fmt.Sprintf("%s\n%s\n%s", e.CourseCode, e.Classroom, e.Lecturer), wb.courseStyle(e.CourseCode))
+			if n := min(sessionSlots(e, tt), tt.TimeSlotCount-e.Slot); n > 1 {
+				sh.merge(r, c, r+n-1, c)
+			}
+		}
+		row += 2 + len(grid.Times) + 1
+	}
+}
+
+// Timeline sheet has a row per classroom or lecturer and a column per time slot of each day.
+// Parallel sessions of the same classroom or lecturer get their own rows. Cells show the
+// course, its cohort and the detail of the other kind.
+func (wb *xlsxWorkbook) addTimelineSheet(tt *timetable.Timetable, name string, title string, keys []string, key func(e *timetable.Entry) string, detail func(e *timetable.Entry) string) {
+	sh := wb.addSheet(name)
+	sh.set(1, 1, title, styleHeader)
+	sh.merge(1, 1, 2, 1)
+	sh.colWidths[1] = 16
+	for d := 0; d < tt.Days; d++ {
+		col := 2 + d*tt.TimeSlotCount
+		sh.set(1, col, model.DayName(d), styleHeader)
+		sh.merge(1, col, 1, col+tt.TimeSlotCount-1)
+		for s := 0; s < tt.TimeSlotCount; s++ {
+			sh.set(2, col+s, tt.SlotTime(s), styleTime)
+			sh.colWidths[col+s] = 14
+		}
+	}
+
+	row := 3
+	for _, k := range keys {
+		sessions := []*timetable.Entry{}
+		for _, e := range tt.Entries {
+			if key(e) == k && e.Day >= 0 && e.Day < tt.Days && e.Slot >= 0 && e.Slot < tt.TimeSlotCount {
+				sessions = append(sessions, e)
+			}
+		}
+		// Lanes are shared by all days since days lie on the same row
+		lanes := map[*timetable.Entry]int{}
+		count := 1
+		for d := 0; d < tt.Days; d++ {
+			daySessions := []*timetable.Entry{}
+			for _, e := range sessions {
+				if e.Day == d {
+					daySessions = append(daySessions, e)
+				}
+			}
+			count = max(count, assignLanes(daySessions, tt, lanes))
+		}
+
+		sh.set(row, 1, k, styleHeader)
+		if count > 1 {
+			sh.merge(row, 1, row+count-1, 1)
+		}
+		for _, e := range sessions {
+			r := row + lanes[e]
+			c := 2 + e.Day*tt.TimeSlotCount + e.Slot
+			sh.set(r, c, fmt.Sprintf("%s (%s %d)\n%s", e.CourseCode, e.Department, e.Grade, detail(e)), wb.courseStyle(e.CourseCode))
+			if n := min(sessionSlots(e, tt), tt.TimeSlotCount-e.Slot); n > 1 {
+				sh.merge(r, c, r, c+n-1)
+			}
+		}
+		row += count
+	}
+}
+
+// Assign sessions of a single day to the first lane free at their starting slot.
+// Returns the number of lanes used, at least one.
+func assignLanes(sessions []*timetable.Entry, tt *timetable.Timetable, lanes map[*timetable.Entry]int) int {
+	slices.SortStableFunc(sessions, func(e1 *timetable.Entry, e2 *timetable.Entry) int {
+		if slot := e1.Slot - e2.Slot; slot != 0 {
+			return slot
+		}
+		return strings.Compare(e1.CourseCode, e2.CourseCode)
+	})
+	laneEnds := []int{}
+	for _, e := range sessions {
+		lane := slices.IndexFunc(laneEnds, func(end int) bool { return end <= e.Slot })
+		if lane < 0 {
+			lane = len(laneEnds)
+			laneEnds = append(laneEnds, 0)
+		}
+		laneEnds[lane] = e.Slot + sessionSlots(e, tt)
+		lanes[e] = lane
+	}
+	return max(1, len(laneEnds))
+}
+
+func sessionSlots(e *timetable.Entry, tt *timetable.Timetable) int {
+	return max(1, (e.Duration+tt.TimeSlotDuration-1)/tt.TimeSlotDuration)
+}
+
+// Add a sheet with a unique name Excel accepts
+func (wb *xlsxWorkbook) addSheet(name string) *xlsxSheet {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "Sheet"
+	}
+	if len([]rune(name)) > 28 {
+		name = string([]rune(name)[:28])
+	}
+	unique := name
+	for i := 2; slices.ContainsFunc(wb.sheets, func(s *xlsxSheet) bool { return strings.EqualFold(s.name, unique) }); i++ {
+		unique = fmt.Sprintf("%s %d", name, i)
+	}
+	sh := &xlsxSheet{name: unique, cells: map[int]map[int]xlsxCell{}, colWidths: map[int]float64{}}
+	wb.sheets = append(wb.sheets, sh)
+	return sh
+}
+
+// Get the style of a course, every course code gets its own fill colour
+func (wb *xlsxWorkbook) courseStyle(courseCode string) int {
+	i := slices.Index(wb.courses, courseCode)
+	if i < 0 {
+		i = len(wb.courses)
+		wb.courses = append(wb.courses, courseCode)
+	}
+	return styleFirstCourse + i
+}
+
+func (sh *xlsxSheet) set(row int, col int, value string, style int) {
+	if sh.cells[row] == nil {
+		sh.cells[row] = map[int]xlsxCell{}
+	}
+	sh.cells[row][col] = xlsxCell{value, style}
+	sh.rowCount = max(sh.rowCount, row)
+}
+
+func (sh *xlsxSheet) merge(row1 int, col1 int, row2 int, col2 int) {
+	sh.merges = append(sh.merges, cellRef(row1, col1)+":"+cellRef(row2, col2))
+	sh.rowCount = max(sh.rowCount, row2)
+}
+
+// Format a cell reference such as B12
+func cellRef(row int, col int) string {
+	name := ""
+	for ; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return fmt.Sprintf("%s%d", name, row)
+}
+
+func (wb *xlsxWorkbook) write(w io.Writer) error {
+	z := zip.NewWriter(w)
+	parts := map[string]string{}
+	order := []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"}
+
+	var types, sheets, rels strings.Builder
+	for i, sh := range wb.sheets {
+		part := fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		order = append(order, part)
+		parts[part] = sh.xml()
+		types.WriteString(fmt.Sprintf(`<Override PartName="/%s" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, part))
+		sheets.WriteString(fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sh.name), i+1, i+1))
+		rels.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1))
+	}
+	rels.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(wb.sheets)+1))
+
+	parts["[Content_Types].xml"] = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		types.String() + `</Types>`
+	parts["_rels/.rels"] = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	parts["xl/workbook.xml"] = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets>` + sheets.String() + `</sheets></workbook>`
+	parts["xl/_rels/workbook.xml.rels"] = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		rels.String() + `</Relationships>`
+	parts["xl/styles.xml"] = wb.stylesXML()
+
+	for _, name := range order {
+		f, err := z.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, parts[name]); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// Styles are default, header, time and one filled cell style per course
+func (wb *xlsxWorkbook) stylesXML() string {
+	var fills, xfs strings.Builder
+	fills.WriteString(`<fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>`)
+	fills.WriteString(`<fill><patternFill patternType="solid"><fgColor rgb="FFD9D9D9"/></patternFill></fill>`)
+	xfs.WriteString(`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>`)
+	xfs.WriteString(`<xf numFmtId="0" fontId="1" fillId="2" borderId="1" xfId="0" applyFont="1" applyFill="1" applyBorder="1" applyAlignment="1"><alignment horizontal="center" vertical="center"/></xf>`)
+	xfs.WriteString(`<xf numFmtId="0" fontId="0" fillId="0" borderId="1" xfId="0" applyBorder="1" applyAlignment="1"><alignment horizontal="center" vertical="top"/></xf>`)
+	for i := range wb.courses {
+		fills.WriteString(fmt.Sprintf(`<fill><patternFill patternType="solid"><fgColor rgb="FF%s"/></patternFill></fill>`, courseColour(i)))
+		xfs.WriteString(fmt.Sprintf(`<xf numFmtId="0" fontId="0" fillId="%d" borderId="1" xfId="0" applyFill="1" applyBorder="1" applyAlignment="1"><alignment horizontal="center" vertical="center" wrapText="1"/></xf>`, 3+i))
+	}
+	return xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="10"/><name val="Calibri"/></font><font><b/><sz val="10"/><name val="Calibri"/></font></fonts>` +
+		fmt.Sprintf(`<fills count="%d">%s</fills>`, 3+len(wb.courses), fills.String()) +
+		`<borders count="2"><border><left/><right/><top/><bottom/><diagonal/></border>` +
+		`<border><left style="thin"/><right style="thin"/><top style="thin"/><bottom style="thin"/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		fmt.Sprintf(`<cellXfs count="%d">%s</cellXfs>`, styleFirstCourse+len(wb.courses), xfs.String()) +
+		`</styleSheet>`
+}
+
+// Pick well separated pastel colours by stepping the hue with the golden angle
+func courseColour(i int) string {
+	hue := math.Mod(float64(i)*137.508, 360) / 60
+	x := 1 - math.Abs(math.Mod(hue, 2)-1)
+	var r, g, b float64
+	switch int(hue) {
+	case 0:
+		r, g, b = 1, x, 0
+	case 1:
+		r, g, b = x, 1, 0
+	case 2:
+		r, g, b = 0, 1, x
+	case 3:
+		r, g, b = 0, x, 1
+	case 4:
+		r, g, b = x, 0, 1
+	default:
+		r, g, b = 1, 0, x
+	}
+	pastel := func(v float64) int { return int(175 + 80*v) }
+	return fmt.Sprintf("%02X%02X%02X", pastel(r), pastel(g), pastel(b))
+}
+
+func (sh *xlsxSheet) xml() string {
+	var sb strings.Builder
+	sb.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(sh.colWidths) != 0 {
+		cols := []int{}
+		for c := range sh.colWidths {
+			cols = append(cols, c)
+		}
+		slices.Sort(cols)
+		sb.WriteString("<cols>")
+		for _, c := range cols {
+			sb.WriteString(fmt.Sprintf(`<col min="%d" max="%d" width="%g" customWidth="1"/>`, c, c, sh.colWidths[c]))
+		}
+		sb.WriteString("</cols>")
+	}
+	sb.WriteString("<sheetData>")
+	for r := 1; r <= sh.rowCount; r++ {
+		row := sh.cells[r]
+		if len(row) == 0 {
+			continue
+		}
+		cols := []int{}
+		for c := range row {
+			cols = append(cols, c)
+		}
+		slices.Sort(cols)
+		sb.WriteString(fmt.Sprintf(`<row r="%d">`, r))
+		for _, c := range cols {
+			cell := row[c]
+			sb.WriteString(fmt.Sprintf(`<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, cellRef(r, c), cell.style, xmlEscape(cell.value)))
+		}
+		sb.WriteString("</row>")
+	}
+	sb.WriteString("</sheetData>")
+	if len(sh.merges) != 0 {
+		sb.WriteString(fmt.Sprintf(`<mergeCells count="%d">`, len(sh.merges)))
+		for _, m := range sh.merges {
+			sb.WriteString(fmt.Sprintf(`<mergeCell ref="%s"/>`, m))
+		}
+		sb.WriteString("</mergeCells>")
+	}
+	sb.WriteString("</worksheet>")
+	return sb.String()
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,310 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// DiffPlacement is the day, time and classroom of a course in one of the compared schedules.
+type DiffPlacement struct {
+	Day       int    `json:"day"`
+	DayName   string `json:"day_name"`
+	Start     string `json:"start"` // HH:MM
+	End       string `json:"end"`   // HH:MM
+	Classroom string `json:"classroom"`
+}
+
+// CourseDiff is a course that changed between two schedules. Old is nil for added and
+// New is nil for removed courses.
+type CourseDiff struct {
+	Course     string         `json:"course"` // Display name
+	Section    int            `json:"section"`
+	Part       int            `json:"part"` // Split part, 0 for the first
+	Parts      int            `json:"parts"`
+	Department string         `json:"department"`
+	Grade      int            `json:"grade"`
+	Lecturer   string         `json:"lecturer"`
+	Old        *DiffPlacement `json:"old"`
+	New        *DiffPlacement `json:"new"`
+}
+
+// ScheduleDiff lists the changes from an old to a new schedule.
+type ScheduleDiff struct {
+	Moved       []*CourseDiff `json:"moved"`        // Different day or time, the classroom may differ too
+	RoomChanged []*CourseDiff `json:"room_changed"` // Same day and time in a different classroom
+	Added       []*CourseDiff `json:"added"`
+	Removed     []*CourseDiff `json:"removed"`
+	Unchanged   int           `json:"unchanged"`
+}
+
+type diffEntry struct {
+	course    *CourseDiff
+	placement *DiffPlacement
+}
+
+// NewScheduleDiff lists the changes from the previous to the current schedule export.
+// Courses are matched by course code, section and split part within their department and
+// grade, laboratories separately from their theory courses. Parts of a course that kept
+// their time are matched first as equal parts are interchangeable. Exports converted from
+// CSV have no sections, if either schedule lacks them courses are matched by display
+// name instead.
+func NewScheduleDiff(previous *model.ScheduleJSON, current *model.ScheduleJSON) *ScheduleDiff {
+	sectioned := hasSections(previous) && hasSections(current)
+	oldEntries := diffEntries(previous, sectioned)
+	newEntries := diffEntries(current, sectioned)
+	keys := []string{}
+	for key := range oldEntries {
+		keys = append(keys, key)
+	}
+	for key := range newEntries {
+		if _, ok := oldEntries[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	diff := &ScheduleDiff{Moved: []*CourseDiff{}, RoomChanged: []*CourseDiff{}, Added: []*CourseDiff{}, Removed: []*CourseDiff{}}
+	for _, key := range keys {
+		olds, news := oldEntries[key], newEntries[key]
+		parts := max(len(olds), len(news))
+		for _, e := range append(slices.Clone(olds), news...) {
+			e.course.Parts = parts
+		}
+
+		// Pair parts that kept their time, then the rest in order of parts
+		pairs := map[*diffEntry]*diffEntry{}
+		paired := map[*diffEntry]bool{}
+		for _, o := range olds {
+			for _, n := range news {
+				if !paired[n] && samePlacementTime(o.placement, n.placement) {
+					pairs[o] = n
+					paired[n] = true
+					break
+				}
+			}
+		}
+		for _, o := range olds {
+			if pairs[o] != nil {
+				continue
+			}
+			for _, n := range news {
+				if !paired[n] {
+					pairs[o] = n
+					paired[n] = true
+					break
+				}
+			}
+		}
+
+		for _, o := range olds {
+			n := pairs[o]
+			if n == nil {
+				o.course.Old = o.placement
+				diff.Removed = append(diff.Removed, o.course)
+				continue
+			}
+			c := n.course
+			c.Old, c.New = o.placement, n.placement
+			switch {
+			case !samePlacementTime(o.placement, n.placement):
+				diff.Moved = append(diff.Moved, c)
+			case o.placement.Classroom != n.placement.Classroom:
+				diff.RoomChanged = append(diff.RoomChanged, c)
+			default:
+				diff.Unchanged++
+			}
+		}
+		for _, n := range news {
+			if !paired[n] {
+				n.course.New = n.placement
+				diff.Added = append(diff.Added, n.course)
+			}
+		}
+	}
+
+	for _, list := range [][]*CourseDiff{diff.Moved, diff.RoomChanged, diff.Added, diff.Removed} {
+		slices.SortFunc(list, compareCourseDiffs)
+	}
+	return diff
+}
+
+func samePlacementTime(p1 *DiffPlacement, p2 *DiffPlacement) bool {
+	return p1.Day == p2.Day && p1.Start == p2.Start && p1.End == p2.End
+}
+
+// Check if courses of the export have sections, exports converted from CSV don't
+func hasSections(s *model.ScheduleJSON) bool {
+	for _, day := range s.Days {
+		for _, slot := range day.Slots {
+			for _, p := range slot.Placements {
+				if p.Course.Section != 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Group the placements of the export by course, ordered by part. Without sections parts
+// are numbered by day and time.
+func diffEntries(s *model.ScheduleJSON, sectioned bool) map[string][]*diffEntry {
+	entries := map[string][]*diffEntry{}
+	days := slices.Clone(s.Days)
+	slices.SortFunc(days, func(d1 *model.DayJSON, d2 *model.DayJSON) int {
+		return d1.DayOfWeek - d2.DayOfWeek
+	})
+	for _, day := range days {
+		for _, slot := range day.Slots {
+			for _, p := range slot.Placements {
+				c := p.Course
+				var key string
+				part := c.PartIndex
+				if sectioned {
+					key = fmt.Sprintf("%s\x00%d\x00%t\x00%s\x00%d", c.CourseCode, c.Section, c.Laboratory, c.Department, c.Grade)
+				} else {
+					key = fmt.Sprintf("%s\x00%s\x00%d", c.DisplayName, c.Department, c.Grade)
+					part = len(entries[key])
+				}
+				entries[key] = append(entries[key], &diffEntry{
+					course: &CourseDiff{
+						Course:     c.DisplayName,
+						Section:    c.Section,
+						Part:       part,
+						Department: c.Department,
+						Grade:      c.Grade,
+						Lecturer:   c.Lecturer,
+					},
+					placement: &DiffPlacement{
+						Day:       day.DayOfWeek,
+						DayName:   day.Name,
+						Start:     p.Start,
+						End:       p.End,
+						Classroom: p.Classroom,
+					},
+				})
+			}
+		}
+	}
+	for _, list := range entries {
+		slices.SortStableFunc(list, func(e1 *diffEntry, e2 *diffEntry) int {
+			return e1.course.Part - e2.course.Part
+		})
+	}
+	return entries
+}
+
+func compareCourseDiffs(c1 *CourseDiff, c2 *CourseDiff) int {
+	if c := strings.Compare(c1.Department, c2.Department); c != 0 {
+		return c
+	}
+	if c1.Grade != c2.Grade {
+		return c1.Grade - c2.Grade
+	}
+	if c := strings.Compare(c1.Course, c2.Course); c != 0 {
+		return c
+	}
+	if c1.Section != c2.Section {
+		return c1.Section - c2.Section
+	}
+	return c1.Part - c2.Part
+}
+
+// Name formats the course with its section and part if there is more than one.
+func (c *CourseDiff) Name() string {
+	name := c.Course
+	if c.Section > 1 {
+		name = fmt.Sprintf("%s section %d", name, c.Section)
+	}
+	if c.Parts > 1 {
+		name = fmt.Sprintf("%s part %d/%d", name, c.Part+1, c.Parts)
+	}
+	return name
+}
+
+// String formats the placement as day, time interval and classroom.
+func (p *DiffPlacement) String() string {
+	if p == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s %s-%s %s", p.DayName, p.Start, p.End, p.Classroom)
+}
+
+// String formats the changes as a plain text list.
+func (d *ScheduleDiff) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Schedule changes: %d moved, %d classroom changes, %d added, %d removed, %d unchanged\n",
+		len(d.Moved), len(d.RoomChanged), len(d.Added), len(d.Removed), d.Unchanged))
+	sections := []struct {
+		title   string
+		courses []*CourseDiff
+	}{
+		{"Moved courses", d.Moved},
+		{"Classroom changes", d.RoomChanged},
+		{"Added courses", d.Added},
+		{"Removed courses", d.Removed},
+	}
+	for _, s := range sections {
+		if len(s.courses) == 0 {
+			continue
+		}
+		sb.WriteString("\n" + s.title + ":\n")
+		for _, c := range s.courses {
+			sb.WriteString(fmt.Sprintf("- %s %s %d: %s -> %s\n", c.Name(), c.Department, c.Grade, c.Old.String(), c.New.String()))
+		}
+	}
+	return sb.String()
+}
+
+var diffPageTemplate = template.Must(template.New("diff").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Schedule changes</title>
+<style>
+body { font-family: Arial, Helvetica, sans-serif; font-size: 12px; margin: 16px; color: #222; }
+h1 { font-size: 20px; margin: 0 0 4px 0; }
+h2 { font-size: 16px; margin: 24px 0 8px 0; border-bottom: 1px solid #999; }
+.meta { color: #555; margin-bottom: 12px; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #999; padding: 2px 6px; text-align: left; }
+th { background: #d9d9d9; }
+td.old { background: #fde2e2; }
+td.new { background: #e2f5e2; }
+</style>
+</head>
+<body>
+<h1>Schedule changes</h1>
+<div class="meta">{{len .Diff.Moved}} moved &middot; {{len .Diff.RoomChanged}} classroom changes &middot; {{len .Diff.Added}} added &middot; {{len .Diff.Removed}} removed &middot; {{.Diff.Unchanged}} unchanged</div>
+{{range .Sections}}{{if .Courses}}<h2>{{.Title}}</h2>
+<table>
+<tr><th>Course</th><th>Department</th><th>Grade</th><th>Lecturer</th><th>Before</th><th>After</th></tr>
+{{range .Courses}}<tr><td>{{.Name}}</td><td>{{.Department}}</td><td>{{.Grade}}</td><td>{{.Lecturer}}</td><td class="old">{{.Old.String}}</td><td class="new">{{.New.String}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// RenderHTML writes the changes as a standalone HTML page.
+func (d *ScheduleDiff) RenderHTML(w io.Writer) error {
+	type section struct {
+		Title   string
+		Courses []*CourseDiff
+	}
+	return diffPageTemplate.Execute(w, struct {
+		Diff     *ScheduleDiff
+		Sections []section
+	}{
+		Diff: d,
+		Sections: []section{
+			{"Moved courses", d.Moved},
+			{"Classroom changes", d.RoomChanged},
+			{"Added courses", d.Added},
+			{"Removed courses", d.Removed},
+		},
+	})
+}
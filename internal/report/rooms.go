@@ -0,0 +1,317 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// RoomUsage holds utilisation statistics of a single classroom.
+type RoomUsage struct {
+	ID             string  `json:"id"`
+	FloorNumber    int     `json:"floor_number"`
+	Capacity       int     `json:"capacity"`
+	OccupiedSlots  []int   `json:"occupied_slots"` // OccupiedSlots[Day] = occupied time slot count
+	AvailableSlots int     `json:"available_slots"`
+	Utilisation    float64 `json:"utilisation"`  // Occupied share of available time slots in percent
+	AverageFill    float64 `json:"average_fill"` // Students per capacity over occupied time slots in percent
+	NeverUsed      bool    `json:"never_used"`
+}
+
+// SlotPressure holds how many classrooms are occupied at a time slot.
+type SlotPressure struct {
+	Day       int     `json:"day"`
+	Slot      int     `json:"slot"`
+	Occupied  int     `json:"occupied"`
+	Available int     `json:"available"`
+	Pressure  float64 `json:"pressure"` // Occupied share of available classrooms in percent
+}
+
+// RoomAddition is a group of classrooms that would have to be added.
+type RoomAddition struct {
+	Capacity int `json:"capacity"`
+	Count    int `json:"count"`
+}
+
+// RoomReport holds classroom utilisation and capacity planning results.
+type RoomReport struct {
+	Rooms       []*RoomUsage    `json:"rooms"`
+	Utilisation float64         `json:"utilisation"`
+	Pressure    []*SlotPressure `json:"pressure"`
+	PeakHour    *SlotPressure   `json:"peak_hour"`
+	NeverUsed   []string        `json:"never_used"`
+	Additions   []*RoomAddition `json:"additions"`
+	days        int
+	slotMinutes int
+}
+
+// NewRoomReport computes classroom utilisation of a schedule and simulates the classrooms
+// needed for unassigned courses. Occupancy is rebuilt from the schedule so it doesn't
+// depend on the state rooms were left in by the last iteration.
+func NewRoomReport(schedule *model.Schedule, rooms []*model.Classroom, courses []*model.Course, labs []*model.Laboratory, occupancyRatio float64) *RoomReport {
+	days := len(schedule.Days)
+	slots := schedule.TimeSlotCount
+	report := &RoomReport{
+		Rooms:       []*RoomUsage{},
+		Pressure:    []*SlotPressure{},
+		NeverUsed:   []string{},
+		days:        days,
+		slotMinutes: schedule.TimeSlotDuration,
+	}
+
+	// Rebuild classroom occupancy and seated students from the schedule
+	occupancy := map[string]*model.Classroom{}
+	students := map[string][][]int{}
+	for _, r := range rooms {
+		room := model.DeepCopyClassroom(r)
+		room.CreateSchedule(days, slots)
+		occupancy[r.ID] = room
+		students[r.ID] = make([][]int, days)
+		for d := range students[r.ID] {
+			students[r.ID][d] = make([]int, slots)
+		}
+	}
+	for _, day := range schedule.Days {
+		for i, slot := range day.Slots {
+			for _, c := range slot.CourseRefs {
+				if c.Classroom == nil || occupancy[c.Classroom.ID] == nil {
+					continue
+				}
+				occupancy[c.Classroom.ID].PlaceCourse(day.DayOfWeek, i, c.CourseID)
+				students[c.Classroom.ID][day.DayOfWeek][i] = c.Number_of_Students
+			}
+		}
+	}
+
+	totalOccupied, totalAvailable := 0, 0
+	for _, r := range rooms {
+		room := occupancy[r.ID]
+		usage := &RoomUsage{
+			ID:            r.ID,
+			FloorNumber:   r.FloorNumber,
+			Capacity:      r.Capacity,
+			OccupiedSlots: make([]int, days),
+		}
+		occupied := 0
+		fill := 0.0
+		for d := 0; d < days; d++ {
+			if slices.Contains(r.AvailabilityArray, d) {
+				usage.AvailableSlots += slots
+			}
+			for s := 0; s < slots; s++ {
+				if room.CourseAt(d, s) != 0 {
+					usage.OccupiedSlots[d]++
+					occupied++
+					fill += float64(students[r.ID][d][s]) / float64(r.Capacity)
+				}
+			}
+		}
+		if usage.AvailableSlots > 0 {
+			usage.Utilisation = 100.0 * float64(occupied) / float64(usage.AvailableSlots)
+		}
+		if occupied > 0 {
+			usage.AverageFill = 100.0 * fill / float64(occupied)
+		} else {
+			usage.NeverUsed = true
+			report.NeverUsed = append(report.NeverUsed, r.ID)
+		}
+		totalOccupied += occupied
+		totalAvailable += usage.AvailableSlots
+		report.Rooms = append(report.Rooms, usage)
+	}
+	if totalAvailable > 0 {
+		report.Utilisation = 100.0 * float64(totalOccupied) / float64(totalAvailable)
+	}
+
+	// Find how crowded each time slot is
+	for d := 0; d < days; d++ {
+		for s := 0; s < slots; s++ {
+			pressure := &SlotPressure{Day: d, Slot: s}
+			for _, r := range rooms {
+				if !slices.Contains(r.AvailabilityArray, d) {
+					continue
+				}
+				pressure.Available++
+				if occupancy[r.ID].CourseAt(d, s) != 0 {
+					pressure.Occupied++
+				}
+			}
+			if pressure.Available > 0 {
+				pressure.Pressure = 100.0 * float64(pressure.Occupied) / float64(pressure.Available)
+			}
+			if report.PeakHour == nil || pressure.Pressure > report.PeakHour.Pressure {
+				report.PeakHour = pressure
+			}
+			report.Pressure = append(report.Pressure, pressure)
+		}
+	}
+
+	occupied := []*model.Classroom{}
+	for _, r := range rooms {
+		occupied = append(occupied, occupancy[r.ID])
+	}
+	report.Additions = simulateAdditions(schedule, occupied, courses, labs, occupancyRatio)
+	return report
+}
+
+// Estimate classrooms to add so that every unassigned course gets a room. Unassigned
+// courses, the biggest first, are placed at the first time free of their conflicting
+// courses, cohort and lecturer at which a classroom is free, either one of the existing
+// classrooms or one added before. Only if there is none a classroom is added at the first
+// such time, its capacity rounded up to the nearest ten. Courses without such a time can't
+// be placed with more classrooms and are left out.
+func simulateAdditions(schedule *model.Schedule, occupied []*model.Classroom, courses []*model.Course, labs []*model.Laboratory, occupancyRatio float64) []*RoomAddition {
+	days := len(schedule.Days)
+	unassigned := []*model.Course{}
+	for _, c := range courses {
+		if !c.Placed && c.NeedsRoom {
+			unassigned = append(unassigned, c)
+		}
+	}
+	for _, l := range labs {
+		if !l.Placed && l.NeedsRoom {
+			unassigned = append(unassigned, scheduler.LabCourse(l))
+		}
+	}
+	// Biggest courses first so smaller ones share their classrooms
+	slices.SortStableFunc(unassigned, func(c1 *model.Course, c2 *model.Course) int {
+		return c2.Number_of_Students - c1.Number_of_Students
+	})
+
+	// Courses at each time slot, placed ones and the simulated ones
+	sessions := make([][][]*model.Course, days)
+	for d := range sessions {
+		sessions[d] = make([][]*model.Course, schedule.TimeSlotCount)
+	}
+	for _, day := range schedule.Days {
+		for i, slot := range day.Slots {
+			sessions[day.DayOfWeek][i] = slices.Clone(slot.CourseRefs)
+		}
+	}
+	rooms := []*model.Classroom{}
+	for _, r := range occupied {
+		rooms = append(rooms, model.DeepCopyClassroom(r))
+	}
+	existing := len(rooms)
+
+	for _, c := range unassigned {
+		capacity := scheduler.RequiredCapacity(c, occupancyRatio)
+		needed := (c.Duration + schedule.TimeSlotDuration - 1) / schedule.TimeSlotDuration
+		var room *model.Classroom
+		day, start := -1, -1
+	search:
+		for d := 0; d < days; d++ {
+			if slices.Contains(c.BusyDays, d) {
+				continue
+			}
+			for s := 0; s+needed <= schedule.TimeSlotCount; s++ {
+				if !timeFree(sessions[d][s:s+needed], c) {
+					continue
+				}
+				if day < 0 {
+					day, start = d, s
+				}
+				if room = smallestFreeRoom(rooms, capacity, d, s, needed); room != nil {
+					day, start = d, s
+					break search
+				}
+			}
+		}
+		if day < 0 {
+			continue
+		}
+		if room == nil {
+			room = &model.Classroom{ID: fmt.Sprintf("added %d", len(rooms)-existing+1), Capacity: (capacity + 9) / 10 * 10}
+			for d := 0; d < days; d++ {
+				room.AvailabilityArray = append(room.AvailabilityArray, d)
+			}
+			room.CreateSchedule(days, schedule.TimeSlotCount)
+			rooms = append(rooms, room)
+		}
+		for s := start; s < start+needed; s++ {
+			room.PlaceCourse(day, s, c.CourseID)
+			sessions[day][s] = append(sessions[day][s], c)
+		}
+	}
+
+	additions := []*RoomAddition{}
+	for _, r := range rooms[existing:] {
+		i := slices.IndexFunc(additions, func(a *RoomAddition) bool { return a.Capacity == r.Capacity })
+		if i < 0 {
+			additions = append(additions, &RoomAddition{Capacity: r.Capacity, Count: 1})
+		} else {
+			additions[i].Count++
+		}
+	}
+	return additions
+}
+
+// Check that none of the courses in the time slots conflicts with course, shares its
+// cohort or its lecturer
+func timeFree(slots [][]*model.Course, course *model.Course) bool {
+	for _, courses := range slots {
+		for _, c := range courses {
+			if slices.Contains(course.ConflictingCourses, c.CourseID) || slices.Contains(c.ConflictingCourses, course.CourseID) ||
+				(c.Department == course.Department && c.Class == course.Class) || (course.Lecturer != "" && c.Lecturer == course.Lecturer) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Smallest classroom of at least capacity seats that is available for the time interval
+func smallestFreeRoom(rooms []*model.Classroom, capacity int, day int, start int, needed int) *model.Classroom {
+	var smallest *model.Classroom
+	for _, r := range rooms {
+		if r.Capacity < capacity || !slices.Contains(r.AvailabilityArray, day) || (smallest != nil && r.Capacity >= smallest.Capacity) {
+			continue
+		}
+		free := true
+		for s := start; s < start+needed; s++ {
+			free = free && r.IsAvailable(day, s)
+		}
+		if free {
+			smallest = r
+		}
+	}
+	return smallest
+}
+
+// String formats the report as a human readable table.
+func (r *RoomReport) String() string {
+	var sb strings.Builder
+	sb.WriteString("Classroom utilisation:\n")
+	sb.WriteString(fmt.Sprintf("%-12s %5s %5s", "Classroom", "Floor", "Cap."))
+	for d := 0; d < r.days; d++ {
+		sb.WriteString(fmt.Sprintf(" %4.3s", model.DayName(d)))
+	}
+	sb.WriteString(fmt.Sprintf(" %7s %7s\n", "Util.", "Fill"))
+	for _, u := range r.Rooms {
+		sb.WriteString(fmt.Sprintf("%-12s %5d %5d", u.ID, u.FloorNumber, u.Capacity))
+		for _, o := range u.OccupiedSlots {
+			sb.WriteString(fmt.Sprintf(" %4d", o))
+		}
+		sb.WriteString(fmt.Sprintf(" %6.2f%% %6.2f%%\n", u.Utilisation, u.AverageFill))
+	}
+	sb.WriteString(fmt.Sprintf("Overall utilisation: %1.2f%%\n", r.Utilisation))
+
+	if r.PeakHour != nil {
+		start := model.FirstSlotStart + r.PeakHour.Slot*r.slotMinutes
+		sb.WriteString(fmt.Sprintf("Peak hour: %s %0.2d:%0.2d with %d/%d classrooms occupied (%1.2f%%)\n",
+			model.DayName(r.PeakHour.Day), start/60, start%60, r.PeakHour.Occupied, r.PeakHour.Available, r.PeakHour.Pressure))
+	}
+	if len(r.NeverUsed) != 0 {
+		sb.WriteString("Never used classrooms: " + strings.Join(r.NeverUsed, ", ") + "\n")
+	}
+	if len(r.Additions) != 0 {
+		sb.WriteString("Classrooms to add for unassigned courses:\n")
+		for _, a := range r.Additions {
+			sb.WriteString(fmt.Sprintf("%d x capacity %d\n", a.Count, a.Capacity))
+		}
+	}
+	return sb.String()
+}
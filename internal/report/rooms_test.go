@@ -0,0 +1,69 @@
+package report
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+func TestSimulateAdditions(t *testing.T) {
+	// A placed course of CENG 1 taught by Lect1 fills the one day week of three time slots
+	// in R1, R2 is free but small
+	course := func(id model.CourseID, grade int, lecturer string, students int, slots int) *model.Course {
+		return &model.Course{CourseID: id, Course_Code: fmt.Sprintf("C%d", id), Department: "CENG", Class: grade,
+			Lecturer: lecturer, Number_of_Students: students, Duration: slots * 60, NeededSlots: slots, NeedsRoom: true}
+	}
+	conflicting := course(2, 2, "Lect2", 55, 1)
+	conflicting.ConflictingCourses = []model.CourseID{1}
+	tests := []struct {
+		name       string
+		unassigned []*model.Course
+		want       []*RoomAddition
+	}{
+		{"existing classroom is free", []*model.Course{course(2, 2, "Lect2", 25, 1)}, []*RoomAddition{}},
+		{"existing classrooms are too small", []*model.Course{course(2, 2, "Lect2", 55, 1)}, []*RoomAddition{{Capacity: 50, Count: 1}}},
+		{"capacity is rounded down", []*model.Course{course(2, 2, "Lect2", 26, 1)}, []*RoomAddition{}},
+		{"cohort is never free", []*model.Course{course(2, 1, "Lect2", 55, 1)}, []*RoomAddition{}},
+		{"lecturer is never free", []*model.Course{course(2, 2, "Lect1", 55, 1)}, []*RoomAddition{}},
+		{"conflicting course is never free", []*model.Course{conflicting}, []*RoomAddition{}},
+		{"new classroom is shared over the day", []*model.Course{course(2, 2, "Lect2", 55, 1), course(3, 3, "Lect3", 55, 2)},
+			[]*RoomAddition{{Capacity: 50, Count: 1}}},
+		{"courses at the same time need their own classrooms", []*model.Course{course(2, 2, "Lect2", 55, 3), course(3, 3, "Lect3", 75, 3)},
+			[]*RoomAddition{{Capacity: 60, Count: 1}, {Capacity: 50, Count: 1}}},
+		{"simulated courses keep their cohort free", []*model.Course{course(2, 2, "Lect2", 55, 3), course(3, 2, "Lect3", 55, 3)},
+			[]*RoomAddition{{Capacity: 50, Count: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := model.NewSchedule(1, 60, 3)
+			rooms := []*model.Classroom{{ID: "R1", Capacity: 40, AvailabilityArray: []int{0}}, {ID: "R2", Capacity: 20, AvailabilityArray: []int{0}}}
+			for _, r := range rooms {
+				r.CreateSchedule(1, 3)
+			}
+			placed := course(1, 1, "Lect1", 30, 3)
+			placed.Placed = true
+			scheduler.PlaceCourse(placed, schedule.Days[0], 0, rooms[0])
+
+			additions := simulateAdditions(schedule, rooms, append([]*model.Course{placed}, tt.unassigned...), nil, 0.8)
+
+			if !reflect.DeepEqual(additions, tt.want) {
+				t.Errorf("got additions %s, want %s", additionsString(additions), additionsString(tt.want))
+			}
+			if rooms[1].CourseAt(0, 0) != 0 {
+				t.Error("simulation placed a course into a classroom of the report")
+			}
+		})
+	}
+}
+
+// Count x capacity of each group of additions, e.g. [2x50 1x60]
+func additionsString(additions []*RoomAddition) string {
+	groups := []string{}
+	for _, a := range additions {
+		groups = append(groups, fmt.Sprintf("%dx%d", a.Count, a.Capacity))
+	}
+	return fmt.Sprint(groups)
+}
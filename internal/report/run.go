@@ -0,0 +1,559 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rhyrak/go-schedule/internal/scheduler"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Statuses of a run report
+const (
+	StatusSuccess         = "success"          // The schedule passed all checks
+	StatusSchedulingError = "scheduling_error" // The schedule failed a check or has unassigned courses
+	StatusFatalError      = "fatal_error"      // The inputs couldn't be loaded, there is no schedule
+)
+
+// Kinds of warnings, problems with inputs that didn't stop the run
+const (
+	WarningLock   = "lock"   // Lock that couldn't be applied
+	WarningImport = "import" // Row of the previous schedule that couldn't be kept by a repair
+)
+
+// Report is the result of a generator or repair run: the loaded inputs, warnings, the
+// validation of the schedule and statistics of the run.
+type Report struct {
+	Status     string                `json:"status"`
+	Errors     []string              `json:"errors"` // Input errors of a fatal report
+	Inputs     *InputSummary         `json:"inputs"`
+	Warnings   []*Warning            `json:"warnings"`
+	Repair     *RepairSummary        `json:"repair"`
+	Validation *scheduler.Validation `json:"validation"`
+	Locked     []*LockedSession      `json:"locked"`
+	Run        *RunSummary           `json:"run"`
+	Rooms      *RoomReport           `json:"rooms"`
+	Stats      *ScheduleStats        `json:"stats"`
+	Exports    []*Export             `json:"exports"`
+}
+
+// InputSummary lists what was loaded from the input files.
+type InputSummary struct {
+	Courses        int                 `json:"courses"`
+	Laboratories   int                 `json:"laboratories"`
+	Departments    []string            `json:"departments"`
+	IgnoredCourses []string            `json:"ignored_courses"`
+	Busy           []*BusyLecturer     `json:"busy"`
+	Reserved       []*ReservedCourse   `json:"reserved"`
+	Conflicts      []*ConflictPair     `json:"conflicts"` // Courses that explicitly won't conflict
+	Classrooms     []*ClassroomSummary `json:"classrooms"`
+}
+
+type BusyLecturer struct {
+	Lecturer string   `json:"lecturer"`
+	Days     []string `json:"days"`
+}
+
+type ReservedCourse struct {
+	Department   string `json:"department"`
+	CourseCode   string `json:"course_code"`
+	Day          string `json:"day"`
+	StartingTime string `json:"starting_time"`
+}
+
+type ConflictPair struct {
+	Department1 string `json:"department1"`
+	CourseCode1 string `json:"course_code1"`
+	Department2 string `json:"department2"`
+	CourseCode2 string `json:"course_code2"`
+}
+
+type ClassroomSummary struct {
+	ID       string   `json:"id"`
+	Capacity int      `json:"capacity"`
+	Days     []string `json:"days"` // Days of the classroom's availability list
+}
+
+// Warning is a problem with the inputs that didn't stop the run.
+type Warning struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// RepairSummary holds what a repair kept and moved.
+type RepairSummary struct {
+	Pinned     int               `json:"pinned"`
+	Unassigned int               `json:"unassigned"`
+	Moves      []*scheduler.Move `json:"moves"`
+}
+
+// LockedSession is a session locked to a day, time and optionally a classroom.
+type LockedSession struct {
+	Department   string `json:"department"`
+	Course       string `json:"course"` // Display name, with the part of split courses
+	Day          string `json:"day"`
+	StartingTime string `json:"starting_time"`
+	Classroom    string `json:"classroom"`
+	Violation    string `json:"violation"` // Hard constraint that kept the lock from being placed
+}
+
+// RunSummary holds the generator's statistics and the time grid of the schedule.
+type RunSummary struct {
+	State                int     `json:"state"`
+	Cost                 int     `json:"cost"`
+	Iteration            int     `json:"iteration"`
+	Seed                 int64   `json:"seed"`
+	ConflictProbability  float64 `json:"conflict_probability"`  // Sibling compulsory conflict probability in percent
+	PlacementProbability float64 `json:"placement_probability"` // Activity day placement probability in percent
+	ElapsedMs            float64 `json:"elapsed_ms"`
+	NumberOfDays         int     `json:"number_of_days"`
+	TimeSlotDuration     int     `json:"time_slot_duration"`
+	TimeSlotCount        int     `json:"time_slot_count"`
+}
+
+// Export is a file the schedule was written to.
+type Export struct {
+	Kind string `json:"kind"` // output, workbook or timetables
+	Path string `json:"path"`
+}
+
+// NewReport returns an empty report of a successful run.
+func NewReport() *Report {
+	return &Report{Status: StatusSuccess, Errors: []string{}, Warnings: []*Warning{}, Locked: []*LockedSession{}, Exports: []*Export{}}
+}
+
+// NewFatalReport returns the report of a run whose inputs couldn't be loaded, errors is
+// the error string of the loaders.
+func NewFatalReport(errors string) *Report {
+	r := NewReport()
+	r.Status = StatusFatalError
+	r.Errors = lines(errors)
+	return r
+}
+
+// ParseReport parses a report formatted with JSON.
+func ParseReport(data string) (*Report, error) {
+	r := &Report{}
+	if err := json.Unmarshal([]byte(data), r); err != nil {
+		return nil, err
+	}
+	// The time grid isn't part of the sub-reports
+	if r.Run != nil && r.Rooms != nil {
+		r.Rooms.days, r.Rooms.slotMinutes = r.Run.NumberOfDays, r.Run.TimeSlotDuration
+	}
+	if r.Run != nil && r.Stats != nil {
+		r.Stats.slotMinutes = r.Run.TimeSlotDuration
+	}
+	return r, nil
+}
+
+// NewInputSummary summarises the loaded inputs.
+func NewInputSummary(classrooms []*model.Classroom, courses []*model.Course, labs []*model.Laboratory, reserved []*model.Reserved, busy []*model.Busy, conflicts []*model.Conflict, departments []string, ignored []string) *InputSummary {
+	s := &InputSummary{
+		Courses:        len(courses),
+		Laboratories:   len(labs),
+		Departments:    append([]string{}, departments...),
+		IgnoredCourses: append([]string{}, ignored...),
+		Busy:           []*BusyLecturer{},
+		Reserved:       []*ReservedCourse{},
+		Conflicts:      []*ConflictPair{},
+		Classrooms:     []*ClassroomSummary{},
+	}
+	for _, b := range busy {
+		s.Busy = append(s.Busy, &BusyLecturer{Lecturer: b.Lecturer, Days: dayNames(b.Day)})
+	}
+	for _, c := range reserved {
+		s.Reserved = append(s.Reserved, &ReservedCourse{Department: c.Department, CourseCode: c.CourseCodeSTR, Day: c.DaySTR, StartingTime: c.StartingTimeSTR})
+	}
+	for _, cc := range conflicts {
+		s.Conflicts = append(s.Conflicts, &ConflictPair{Department1: cc.Department1, CourseCode1: cc.Course_Code1, Department2: cc.Department2, CourseCode2: cc.Course_Code2})
+	}
+	for _, c := range classrooms {
+		s.Classrooms = append(s.Classrooms, &ClassroomSummary{ID: c.ID, Capacity: c.Capacity, Days: dayNames(c.AvailabilityArray)})
+	}
+	return s
+}
+
+// NewLockedSessions lists the locked sessions of the reserved courses.
+func NewLockedSessions(reserved []*model.Reserved) []*LockedSession {
+	locked := []*LockedSession{}
+	for _, r := range reserved {
+		if !r.Locked {
+			continue
+		}
+		name := r.CourseCodeSTR
+		if r.LabRef != nil {
+			name = r.LabRef.DisplayName
+		} else if r.CourseRef.PartCount > 1 {
+			name = fmt.Sprintf("%s part %d/%d", name, r.CourseRef.PartIndex+1, r.CourseRef.PartCount)
+		}
+		session := &LockedSession{Department: r.Department, Course: name, Day: r.DaySTR, StartingTime: r.StartingTimeSTR, Violation: r.Violation}
+		if r.Room != nil {
+			session.Classroom = r.Room.ID
+		}
+		locked = append(locked, session)
+	}
+	return locked
+}
+
+// NewRepairSummary summarises the moves of a repair.
+func NewRepairSummary(repaired *scheduler.RepairResult) *RepairSummary {
+	return &RepairSummary{Pinned: repaired.Pinned, Unassigned: repaired.Unassigned, Moves: repaired.Moves}
+}
+
+// AddWarnings adds a warning of given kind for every line of a loader report.
+func (r *Report) AddWarnings(kind string, report string) {
+	for _, line := range lines(report) {
+		r.Warnings = append(r.Warnings, &Warning{Kind: kind, Message: strings.TrimPrefix(line, "- ")})
+	}
+}
+
+// SetValidation sets the validation of the schedule and the status following it.
+func (r *Report) SetValidation(v *scheduler.Validation) {
+	r.Validation = v
+	if v.Valid && v.SufficientRooms {
+		r.Status = StatusSuccess
+	} else {
+		r.Status = StatusSchedulingError
+	}
+}
+
+// JSON formats the report with JSON, as stored by the server.
+func (r *Report) JSON() string {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Format formats the report as text, json or markdown, text for unknown formats.
+func (r *Report) Format(format string) string {
+	switch format {
+	case "json":
+		return r.JSON()
+	case "markdown":
+		return r.Markdown()
+	}
+	return r.String()
+}
+
+// String formats the report as the text report of the CLI.
+func (r *Report) String() string {
+	var sb strings.Builder
+	if r.Status == StatusFatalError {
+		sb.WriteString("Fatal Error\n")
+		for _, e := range r.Errors {
+			sb.WriteString(e + "\n")
+		}
+		return sb.String()
+	}
+	if r.Status == StatusSchedulingError {
+		sb.WriteString("Scheduling Error\n")
+	}
+
+	if in := r.Inputs; in != nil {
+		sb.WriteString("Departments are as below:\n")
+		for _, d := range in.Departments {
+			sb.WriteString(d + "\n")
+		}
+		sb.WriteString("\n")
+		if len(in.IgnoredCourses) != 0 {
+			sb.WriteString("Ignored courses are as below:\n")
+			for _, g := range in.IgnoredCourses {
+				sb.WriteString(g + " is ignored.\n")
+			}
+			sb.WriteString("\n")
+		}
+		if len(in.Busy) != 0 {
+			sb.WriteString("Professors with their busy schedules are as below:\n")
+			for _, b := range in.Busy {
+				sb.WriteString(b.Lecturer + " [")
+				for _, d := range b.Days {
+					sb.WriteString(" " + d + " ")
+				}
+				sb.WriteString("]\n")
+			}
+			sb.WriteString("\n")
+		}
+		if len(in.Reserved) != 0 {
+			sb.WriteString("Courses reserved to certain days and hours are as below:\n")
+			for _, c := range in.Reserved {
+				sb.WriteString(c.Department + " " + c.CourseCode + " " + c.Day + " " + c.StartingTime + "\n")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	r.writeWarnings(&sb, WarningLock, "Locks that couldn't be applied are as below:\n")
+	if r.Inputs != nil && len(r.Inputs.Conflicts) != 0 {
+		sb.WriteString("Courses that explicitly won't conflict with each other are as below:\n")
+		for _, cc := range r.Inputs.Conflicts {
+			sb.WriteString(cc.Department1 + " " + cc.CourseCode1 + " <-> " + cc.Department2 + " " + cc.CourseCode2 + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	r.writeWarnings(&sb, WarningImport, "Previous schedule rows that couldn't be kept are as below:\n")
+	if r.Repair != nil {
+		sb.WriteString(fmt.Sprintf("Repair kept %d placements and moved %d courses, %d courses are unassigned\n", r.Repair.Pinned, len(r.Repair.Moves), r.Repair.Unassigned))
+		for _, m := range r.Repair.Moves {
+			sb.WriteString(fmt.Sprintf("- %s %s %d: %s -> %s (%s)\n", m.CourseCode, m.Department, m.Grade,
+				r.placement(m.FromDay, m.FromSlot, m.FromRoom), r.placement(m.ToDay, m.ToSlot, m.ToRoom), m.Reason))
+		}
+		sb.WriteString("\n")
+	}
+
+	if v := r.Validation; v != nil {
+		if v.Valid {
+			sb.WriteString("Passed all tests\n")
+		} else {
+			sb.WriteString("Invalid schedule:\n")
+		}
+		sb.WriteString(fmt.Sprintf("Unassigned: %d\n\n", len(v.Unassigned)))
+	}
+	if len(r.Locked) != 0 {
+		sb.WriteString("Locked sessions are as below:\n")
+		for _, l := range r.Locked {
+			sb.WriteString(l.String() + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	if r.Inputs != nil {
+		sb.WriteString("Classrooms and their occuppied days are as below:\n")
+		for _, c := range r.Inputs.Classrooms {
+			sb.WriteString(c.ID + " [")
+			for _, d := range c.Days {
+				sb.WriteString(" " + d + " ")
+			}
+			sb.WriteString("]\n")
+		}
+		sb.WriteString("\n")
+	}
+	if r.Validation != nil {
+		sb.WriteString(r.Validation.Message())
+	}
+
+	if run := r.Run; run != nil {
+		sb.WriteString(fmt.Sprintf("State: %d\n", run.State))
+		sb.WriteString(fmt.Sprintf("Cost: %d\n", run.Cost))
+		sb.WriteString(fmt.Sprintf("Iteration: %d\n", run.Iteration))
+		sb.WriteString(fmt.Sprintf("Seed: %d\n", run.Seed))
+		sb.WriteString(fmt.Sprintf("Sibling Compulsory Conflict Probability: %1.2f%%\n", run.ConflictProbability))
+		sb.WriteString(fmt.Sprintf("Activity Day Placement Probability: %1.2f%%\n", run.PlacementProbability))
+		sb.WriteString(fmt.Sprintf("Elapsed Time: %f ms\n", run.ElapsedMs))
+	}
+	for _, e := range r.Exports {
+		sb.WriteString("Exported " + e.Kind + " to: " + e.Path + "\n")
+	}
+	sb.WriteString("\n")
+
+	if r.Rooms != nil {
+		sb.WriteString(r.Rooms.String())
+	}
+	if r.Validation != nil && !r.Validation.SufficientRooms {
+		sb.WriteString("Please add the classrooms above and re-run the program.\n")
+	}
+	return sb.String()
+}
+
+// Markdown formats the report as a Markdown document with tables.
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# Schedule report\n\n")
+	sb.WriteString("Status: **" + r.Status + "**\n")
+	if r.Status == StatusFatalError {
+		sb.WriteString("\n## Errors\n\n")
+		for _, e := range r.Errors {
+			sb.WriteString("- " + markdownEscape(e) + "\n")
+		}
+		return sb.String()
+	}
+
+	if run := r.Run; run != nil {
+		sb.WriteString("\n## Run\n\n| State | Cost | Iteration | Seed | Conflict probability | Placement probability | Elapsed |\n|---|---|---|---|---|---|---|\n")
+		sb.WriteString(fmt.Sprintf("| %d | %d | %d | %d | %1.2f%% | %1.2f%% | %.0f ms |\n", run.State, run.Cost, run.Iteration, run.Seed,
+			run.ConflictProbability, run.PlacementProbability, run.ElapsedMs))
+	}
+
+	if v := r.Validation; v != nil {
+		sb.WriteString("\n## Validation\n\n| Check | Result | Violations |\n|---|---|---|\n")
+		for _, c := range v.Checks {
+			result := "OK"
+			if !c.Passed {
+				result = "FAIL"
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %d |\n", c.Name, result, len(c.Violations)))
+		}
+		if len(v.Unassigned) != 0 {
+			sb.WriteString("\n### Unassigned courses\n\n| Course | Department | Students | Lecturer | Kind |\n|---|---|---|---|---|\n")
+			for _, un := range v.Unassigned {
+				kind := "In-class"
+				if un.Laboratory {
+					kind = "Laboratory"
+				}
+				if un.Compulsory {
+					kind = kind + ", compulsory"
+				} else {
+					kind = kind + ", elective"
+				}
+				sb.WriteString(fmt.Sprintf("| %s | %s | %d | %s | %s |\n", markdownEscape(un.CourseCode), markdownEscape(un.Department), un.Students, markdownEscape(un.Lecturer), kind))
+			}
+		}
+		for _, c := range v.Checks[1:] {
+			if len(c.Violations) == 0 {
+				continue
+			}
+			sb.WriteString("\n### " + c.Name + "\n\n")
+			for _, violation := range c.Violations {
+				sb.WriteString("- " + markdownEscape(violation.Message) + "\n")
+			}
+		}
+	}
+
+	if len(r.Warnings) != 0 {
+		sb.WriteString("\n## Warnings\n\n")
+		for _, w := range r.Warnings {
+			sb.WriteString("- " + w.Kind + ": " + markdownEscape(w.Message) + "\n")
+		}
+	}
+
+	if r.Repair != nil {
+		sb.WriteString(fmt.Sprintf("\n## Repair\n\nKept %d placements and moved %d courses, %d courses are unassigned.\n", r.Repair.Pinned, len(r.Repair.Moves), r.Repair.Unassigned))
+		if len(r.Repair.Moves) != 0 {
+			sb.WriteString("\n| Course | From | To | Reason |\n|---|---|---|---|\n")
+			for _, m := range r.Repair.Moves {
+				sb.WriteString(fmt.Sprintf("| %s %s %d | %s | %s | %s |\n", markdownEscape(m.CourseCode), markdownEscape(m.Department), m.Grade,
+					r.placement(m.FromDay, m.FromSlot, m.FromRoom), r.placement(m.ToDay, m.ToSlot, m.ToRoom), markdownEscape(m.Reason)))
+			}
+		}
+	}
+
+	if len(r.Locked) != 0 {
+		sb.WriteString("\n## Locked sessions\n\n")
+		for _, l := range r.Locked {
+			sb.WriteString("- " + markdownEscape(l.String()) + "\n")
+		}
+	}
+
+	if in := r.Inputs; in != nil {
+		sb.WriteString(fmt.Sprintf("\n## Inputs\n\n%d courses and %d laboratories of %s.\n", in.Courses, in.Laboratories, markdownEscape(strings.Join(in.Departments, ", "))))
+		if len(in.IgnoredCourses) != 0 {
+			sb.WriteString("Ignored courses: " + markdownEscape(strings.Join(in.IgnoredCourses, ", ")) + ".\n")
+		}
+		if len(in.Busy) != 0 {
+			sb.WriteString("\n| Busy lecturer | Days |\n|---|---|\n")
+			for _, b := range in.Busy {
+				sb.WriteString("| " + markdownEscape(b.Lecturer) + " | " + strings.Join(b.Days, ", ") + " |\n")
+			}
+		}
+		if len(in.Reserved) != 0 {
+			sb.WriteString("\n| Reserved course | Day | Starting time |\n|---|---|---|\n")
+			for _, c := range in.Reserved {
+				sb.WriteString("| " + markdownEscape(c.Department+" "+c.CourseCode) + " | " + markdownEscape(c.Day) + " | " + markdownEscape(c.StartingTime) + " |\n")
+			}
+		}
+		if len(in.Conflicts) != 0 {
+			sb.WriteString("\n| Course | Won't conflict with |\n|---|---|\n")
+			for _, cc := range in.Conflicts {
+				sb.WriteString("| " + markdownEscape(cc.Department1+" "+cc.CourseCode1) + " | " + markdownEscape(cc.Department2+" "+cc.CourseCode2) + " |\n")
+			}
+		}
+	}
+
+	if r.Rooms != nil {
+		sb.WriteString("\n## Classrooms\n\n| Classroom | Capacity | Utilisation | Average fill |\n|---|---|---|---|\n")
+		for _, u := range r.Rooms.Rooms {
+			sb.WriteString(fmt.Sprintf("| %s | %d | %1.2f%% | %1.2f%% |\n", markdownEscape(u.ID), u.Capacity, u.Utilisation, u.AverageFill))
+		}
+		sb.WriteString(fmt.Sprintf("\nOverall utilisation: %1.2f%%\n", r.Rooms.Utilisation))
+		if len(r.Rooms.Additions) != 0 {
+			sb.WriteString("\nClassrooms to add for unassigned courses:\n\n")
+			for _, a := range r.Rooms.Additions {
+				sb.WriteString(fmt.Sprintf("- %d x capacity %d\n", a.Count, a.Capacity))
+			}
+		}
+	}
+
+	if r.Stats != nil {
+		sb.WriteString(fmt.Sprintf("\n## Statistics\n\n%d sessions, %1.1f hours.\n\n| Department | Sessions | Hours |\n|---|---|---|\n", r.Stats.Sessions, r.Stats.Hours))
+		for _, g := range r.Stats.Departments {
+			sb.WriteString(fmt.Sprintf("| %s | %d | %1.1f |\n", markdownEscape(g.Name), g.Sessions, g.Hours))
+		}
+	}
+
+	if len(r.Exports) != 0 {
+		sb.WriteString("\n## Exports\n\n")
+		for _, e := range r.Exports {
+			sb.WriteString("- " + e.Kind + ": `" + e.Path + "`\n")
+		}
+	}
+	return sb.String()
+}
+
+func (l *LockedSession) String() string {
+	text := l.Department + " " + l.Course + " " + l.Day + " " + l.StartingTime
+	if l.Classroom != "" {
+		text = text + " " + l.Classroom
+	}
+	if l.Violation != "" {
+		text = text + " is not placed: " + l.Violation
+	}
+	return text
+}
+
+func (r *Report) writeWarnings(sb *strings.Builder, kind string, title string) {
+	written := false
+	for _, w := range r.Warnings {
+		if w.Kind != kind {
+			continue
+		}
+		if !written {
+			sb.WriteString(title)
+			written = true
+		}
+		sb.WriteString("- " + w.Message + "\n")
+	}
+	if written {
+		sb.WriteString("\n")
+	}
+}
+
+// Day, time and classroom of a repair move, day is -1 for unassigned courses
+func (r *Report) placement(day int, slot int, room string) string {
+	if day < 0 {
+		return "unassigned"
+	}
+	slotMinutes := 60
+	if r.Run != nil {
+		slotMinutes = r.Run.TimeSlotDuration
+	}
+	start := model.FirstSlotStart + slot*slotMinutes
+	text := fmt.Sprintf("%s %0.2d:%0.2d", model.DayName(day), start/60, start%60)
+	if room != "" {
+		text = text + " " + room
+	}
+	return text
+}
+
+func dayNames(days []int) []string {
+	names := []string{}
+	for _, d := range days {
+		names = append(names, model.DayName(d))
+	}
+	return names
+}
+
+// Non-empty lines of a loader report
+func lines(report string) []string {
+	result := []string{}
+	for _, line := range strings.Split(report, "\n") {
+		if strings.TrimSpace(line) != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+func markdownEscape(text string) string {
+	return strings.NewReplacer("|", "\\|", "*", "\\*", "_", "\\_", "`", "\\`").Replace(text)
+}
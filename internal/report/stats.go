@@ -0,0 +1,166 @@
+package report
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rhyrak/go-schedule/internal/timetable"
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// StatsGroup holds the sessions and teaching hours of a day, department, lecturer or
+// classroom.
+type StatsGroup struct {
+	Name          string  `json:"name"`
+	Sessions      int     `json:"sessions"`
+	Hours         float64 `json:"hours"`
+	Days          int     `json:"days,omitempty"`            // Days with sessions, not set for days
+	MaxDailyHours float64 `json:"max_daily_hours,omitempty"` // Hours of the busiest day, not set for days
+	Utilisation   float64 `json:"utilisation,omitempty"`     // Occupied share of the week's time slots in percent, classrooms only
+}
+
+// ScheduleStats holds session counts and teaching hours of a schedule export.
+type ScheduleStats struct {
+	Sessions    int           `json:"sessions"`
+	Hours       float64       `json:"hours"`
+	Days        []*StatsGroup `json:"days"`
+	Departments []*StatsGroup `json:"departments"`
+	Lecturers   []*StatsGroup `json:"lecturers"`
+	Classrooms  []*StatsGroup `json:"classrooms"`
+	BusiestSlot *SlotPressure `json:"busiest_slot"` // Occupied is the number of sessions running
+	slotMinutes int
+}
+
+// NewScheduleStats counts the sessions and hours of the timetable by day, department,
+// lecturer and classroom. Groups are ordered by name, days by day of week.
+func NewScheduleStats(tt *timetable.Timetable) *ScheduleStats {
+	stats := &ScheduleStats{Days: []*StatsGroup{}, slotMinutes: tt.TimeSlotDuration}
+	for d := 0; d < tt.Days; d++ {
+		stats.Days = append(stats.Days, &StatsGroup{Name: model.DayName(d)})
+	}
+	departments := map[string]*StatsGroup{}
+	lecturers := map[string]*StatsGroup{}
+	classrooms := map[string]*StatsGroup{}
+	dailyHours := map[*StatsGroup][]float64{}
+	running := make([][]int, tt.Days)
+	for d := range running {
+		running[d] = make([]int, tt.TimeSlotCount)
+	}
+	slotsUsed := map[*StatsGroup]int{}
+
+	add := func(groups map[string]*StatsGroup, name string, e *timetable.Entry, hours float64) *StatsGroup {
+		g, found := groups[name]
+		if !found {
+			g = &StatsGroup{Name: name}
+			groups[name] = g
+			dailyHours[g] = make([]float64, tt.Days)
+		}
+		g.Sessions++
+		g.Hours += hours
+		if e.Day >= 0 && e.Day < tt.Days {
+			dailyHours[g][e.Day] += hours
+		}
+		return g
+	}
+
+	for _, e := range tt.Entries {
+		hours := float64(e.Duration) / 60
+		slots := (e.Duration + tt.TimeSlotDuration - 1) / tt.TimeSlotDuration
+		stats.Sessions++
+		stats.Hours += hours
+		if e.Day >= 0 && e.Day < tt.Days {
+			stats.Days[e.Day].Sessions++
+			stats.Days[e.Day].Hours += hours
+			for s := e.Slot; s < e.Slot+slots && s < tt.TimeSlotCount; s++ {
+				running[e.Day][s]++
+			}
+		}
+		add(departments, e.Department, e, hours)
+		if e.Lecturer != "" {
+			add(lecturers, e.Lecturer, e, hours)
+		}
+		if e.Classroom != "" {
+			slotsUsed[add(classrooms, e.Classroom, e, hours)] += slots
+		}
+	}
+
+	for d, slots := range running {
+		for s, count := range slots {
+			if stats.BusiestSlot == nil || count > stats.BusiestSlot.Occupied {
+				stats.BusiestSlot = &SlotPressure{Day: d, Slot: s, Occupied: count}
+			}
+		}
+	}
+	for g, hours := range dailyHours {
+		for _, h := range hours {
+			if h > 0 {
+				g.Days++
+			}
+			g.MaxDailyHours = max(g.MaxDailyHours, h)
+		}
+	}
+	if week := tt.Days * tt.TimeSlotCount; week > 0 {
+		for g, used := range slotsUsed {
+			g.Utilisation = float64(used) / float64(week) * 100
+		}
+	}
+
+	stats.Departments = sortedGroups(departments)
+	stats.Lecturers = sortedGroups(lecturers)
+	stats.Classrooms = sortedGroups(classrooms)
+	return stats
+}
+
+func sortedGroups(groups map[string]*StatsGroup) []*StatsGroup {
+	sorted := []*StatsGroup{}
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	slices.SortFunc(sorted, func(g1 *StatsGroup, g2 *StatsGroup) int {
+		return cmp.Compare(g1.Name, g2.Name)
+	})
+	return sorted
+}
+
+// String formats the statistics as human readable tables.
+func (s *ScheduleStats) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Sessions: %d\nHours: %1.1f\n", s.Sessions, s.Hours))
+	if s.BusiestSlot != nil && s.BusiestSlot.Occupied > 0 {
+		start := model.FirstSlotStart + s.BusiestSlot.Slot*s.slotMinutes
+		sb.WriteString(fmt.Sprintf("Busiest slot: %s %0.2d:%0.2d with %d sessions\n",
+			model.DayName(s.BusiestSlot.Day), start/60, start%60, s.BusiestSlot.Occupied))
+	}
+	writeGroups(&sb, "Day", s.Days, false, false)
+	writeGroups(&sb, "Department", s.Departments, true, false)
+	writeGroups(&sb, "Lecturer", s.Lecturers, true, false)
+	writeGroups(&sb, "Classroom", s.Classrooms, true, true)
+	return sb.String()
+}
+
+func writeGroups(sb *strings.Builder, title string, groups []*StatsGroup, daily bool, utilisation bool) {
+	width := len(title)
+	for _, g := range groups {
+		width = max(width, len(g.Name))
+	}
+	sb.WriteString(fmt.Sprintf("\n%-*s %8s %7s", width, title, "Sessions", "Hours"))
+	if daily {
+		sb.WriteString(fmt.Sprintf(" %4s %9s", "Days", "Max daily"))
+	}
+	if utilisation {
+		sb.WriteString(fmt.Sprintf(" %7s", "Util."))
+	}
+	sb.WriteString("\n")
+	for _, g := range groups {
+		sb.WriteString(fmt.Sprintf("%-*s %8d %7.1f", width, g.Name, g.Sessions, g.Hours))
+		if daily {
+			sb.WriteString(fmt.Sprintf(" %4d %9.1f", g.Days, g.MaxDailyHours))
+		}
+		if utilisation {
+			sb.WriteString(fmt.Sprintf(" %6.2f%%", g.Utilisation))
+		}
+		sb.WriteString("\n")
+	}
+}
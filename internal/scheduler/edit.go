@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// PlacementViolations runs the checks of the generator for placing course at given day and
+// starting time slot and returns the classroom to place it into with every hard constraint
+// the placement breaks. A nil room picks a classroom like the generator does.
+func PlacementViolations(course *model.Course, schedule *model.Schedule, dayOfWeek int, start int, room *model.Classroom, rooms []*model.Classroom, occupancyRatio float64) (*model.Classroom, []string) {
+	violations := []string{}
+	if dayOfWeek < 0 || dayOfWeek >= len(schedule.Days) || start < 0 || start+course.NeededSlots > schedule.TimeSlotCount {
+		return nil, append(violations, "doesn't fit into the day")
+	}
+	day := findDay(schedule, dayOfWeek)
+	if slices.Contains(course.BusyDays, day.DayOfWeek) {
+		violations = append(violations, "lecturer is busy on "+model.DayName(day.DayOfWeek))
+	}
+
+	// Same checks as checkSlots, reported one by one
+	if !course.ServiceCourse {
+		if start > 0 {
+			for _, prev := range day.Slots[start-1].CourseRefs {
+				if prev.CourseID != course.CourseID && prev.Lecturer == course.Lecturer {
+					violations = append(violations, "lecturer has no break after "+courseName(prev))
+					break
+				}
+			}
+		}
+		reported := map[model.CourseID]bool{}
+		for r := start; r < start+course.NeededSlots; r++ {
+			for _, placed := range day.Slots[r].CourseRefs {
+				if !reported[placed.CourseID] && slices.Contains(course.ConflictingCourses, placed.CourseID) {
+					reported[placed.CourseID] = true
+					violations = append(violations, "conflicts with "+courseName(placed))
+				}
+			}
+		}
+		if !checkLecturerLimits(schedule, day, start, course.NeededSlots, course) {
+			violations = append(violations, "exceeds lecturer limits")
+		}
+	}
+
+	for _, slot := range day.Slots {
+		for _, placed := range slot.CourseRefs {
+			if placed.CourseID == course.CourseID {
+				continue
+			}
+			if course.HasBeenSplit && slices.Contains(course.SiblingIDs, placed.CourseID) {
+				violations = append(violations, "another part is on the same day")
+			} else if slices.Contains(course.TheoryIDs, placed.CourseID) {
+				violations = append(violations, "theory course is on the same day")
+			} else if slices.Contains(placed.TheoryIDs, course.CourseID) {
+				violations = append(violations, "laboratory is on the same day")
+			} else {
+				continue
+			}
+			// Report each of them once
+			break
+		}
+	}
+
+	if !course.NeedsRoom {
+		return nil, violations
+	}
+	capacity := RequiredCapacity(course, occupancyRatio)
+	if room != nil {
+		if !roomFits(room, capacity, day.DayOfWeek, start, course.NeededSlots) {
+			violations = append(violations, "classroom "+room.ID+" is too small or unavailable")
+		}
+		return room, violations
+	}
+	room = findRoom(rooms, capacity, day.DayOfWeek, start, course.NeededSlots, sessionRoom(schedule, course), isHardRoomStability(course), cohortFloor(day, start, course.NeededSlots, course))
+	if room == nil {
+		violations = append(violations, "no classroom available")
+	}
+	return room, violations
+}
+
+// PlaceEdited places a manually moved course regardless of the constraints it breaks and
+// returns them, see PlacementViolations. Courses that don't fit into the day are left
+// unplaced. Lab is the laboratory the course stands for, if any.
+func PlaceEdited(course *model.Course, lab *model.Laboratory, schedule *model.Schedule, dayOfWeek int, start int, room *model.Classroom, rooms []*model.Classroom, occupancyRatio float64) []string {
+	room, violations := PlacementViolations(course, schedule, dayOfWeek, start, room, rooms, occupancyRatio)
+	if dayOfWeek < 0 || dayOfWeek >= len(schedule.Days) || start < 0 || start+course.NeededSlots > schedule.TimeSlotCount {
+		return violations
+	}
+	PlaceCourse(course, findDay(schedule, dayOfWeek), start, room)
+	course.PlacedDay = dayOfWeek
+	if lab != nil {
+		lab.Placed = true
+		lab.Classroom = room
+	}
+	return violations
+}
+
+func courseName(c *model.Course) string {
+	return fmt.Sprintf("%s %s %d", c.DisplayName, c.Department, c.Class)
+}
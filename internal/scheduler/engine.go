@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Result holds the best schedule of a run and the state it was found in.
+type Result struct {
+	Schedule             *model.Schedule
+	Courses              []*model.Course
+	Labs                 []*model.Laboratory
+	Iteration            int
+	State                int
+	PlacementProbability float64
+	Elapsed              time.Duration
+}
+
+// Generate places courses over and over until a valid schedule is found or the iteration
+// limit is reached or the run is cancelled. Returns the valid schedule or the one with the
+// fewest unassigned courses.
+func Generate(cfg *Configuration, courses []*model.Course, labs []*model.Laboratory, reserved []*model.Reserved, classrooms []*model.Classroom, conflicts []*model.Conflict, congestedDepartments map[string]int) *Result {
+	start := time.Now()
+	var schedule *model.Schedule
+	var optimalSchedule *model.Schedule
+	var optimalCourses []*model.Course
+	var optimalLabs []*model.Laboratory
+	var iter int
+	var stateCount int = 2
+	var iterUpperLimit int = cfg.IterSoftLimit + 4999 // Extend final state by 5000 iterations (Doomsday)
+	var iterActivityDayDelta int = cfg.IterSoftLimit / stateCount
+	var state int = 0
+	var placementProbability = 0.1
+	unassignedCount := 21474836547
+	// Try to create a valid schedule upto iterLimit+1 times
+	for iter = 1; iter <= iterUpperLimit; iter++ {
+		// Increment state every iterState iterations and reset FreeDay fill probability
+		if iter%cfg.IterSoftLimit == 0 {
+			state++
+			placementProbability = 0.1
+		}
+
+		// Increment fill probabilty of Activity Day from 10% to 60% over the course of state iterations
+		placementProbability = placementProbability + (1 / float64(iterActivityDayDelta*4))
+
+		// Keep going in 2nd state, Also fully unlock Activity Day
+		if state >= stateCount-1 {
+			state = stateCount - 1
+			placementProbability = 1.0
+		}
+
+		for _, c := range classrooms {
+			// Initialize an empty classroom-oriented schedule to keep track of classroom utilization throughout the week
+			c.CreateSchedule(cfg.NumberOfDays, cfg.TimeSlotCount)
+		}
+
+		// Init and assign new conflict probabilities according to state
+		courses, labs = InitRuntimeProperties(cfg, courses, labs, state, conflicts)
+
+		// Shuffle around the courses vector randomly to allow for different output opportunities
+		cfg.random().Shuffle(len(courses), func(i, j int) {
+			courses[i], courses[j] = courses[j], courses[i]
+		})
+
+		// Initialize an empty schedule to hold course data
+		schedule = model.NewSchedule(cfg.NumberOfDays, cfg.TimeSlotDuration, cfg.TimeSlotCount)
+		schedule.ShuffleDays(cfg.random())
+
+		// Fill the empty schedule with course data and assign classrooms to courses
+		PlaceReservedCourses(reserved, schedule, classrooms, cfg.RoomOccupancyRatio)
+		FillCourses(courses, labs, schedule, classrooms, cfg.RoomOccupancyRatio, placementProbability, cfg.ActivityDay, congestedDepartments, cfg.DepartmentCongestionLimit, state)
+
+		// Match classrooms to courses now that days and time slots are fixed
+		AssignRooms(schedule, labs, classrooms, cfg.RoomOccupancyRatio)
+
+		// If schedule is valid, break, if not, shove everything out the window and try again (5dk)
+		validation := ValidateSchedule(courses, labs, schedule)
+		cnt := len(validation.Unassigned)
+		if validation.Valid {
+			unassignedCount = cnt
+			optimalSchedule = schedule.DeepCopy()
+			optimalCourses = model.DeepCopyCourses(courses)
+			optimalLabs = model.DeepCopyLaboratories(labs)
+			break
+		}
+		// Update least-faulty schedule
+		improved := cnt < unassignedCount
+		if cnt <= unassignedCount {
+			unassignedCount = cnt
+			optimalSchedule = schedule.DeepCopy()
+			optimalCourses = model.DeepCopyCourses(courses)
+			optimalLabs = model.DeepCopyLaboratories(labs)
+		}
+		if improved || iter%ProgressInterval == 0 {
+			reportProgress(cfg, iter, state, placementProbability, optimalSchedule, unassignedCount, start, false)
+		}
+		if cfg.cancelled() {
+			break
+		}
+	}
+	reportProgress(cfg, iter, state, placementProbability, optimalSchedule, unassignedCount, start, true)
+
+	return &Result{
+		Schedule:             optimalSchedule,
+		Courses:              optimalCourses,
+		Labs:                 optimalLabs,
+		Iteration:            iter,
+		State:                state,
+		PlacementProbability: placementProbability,
+		Elapsed:              time.Since(start),
+	}
+}
@@ -1,6 +1,13 @@
 package scheduler
 
-import "hash/maphash"
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
 
 type Configuration struct {
 	ClassroomsFile              string
@@ -11,6 +18,8 @@ type Configuration struct {
 	ConflictsFile               string
 	SplitFile                   string
 	ExternalFile                string
+	LecturerLimitsFile          string
+	LocksFile                   string // Optional, sessions pinned to a day, time and classroom
 	ExportFile                  string
 	NumberOfDays                int
 	TimeSlotDuration            int
@@ -19,6 +28,19 @@ type Configuration struct {
 	IterSoftLimit               int
 	DepartmentCongestionLimit   int
 	ActivityDay                 int
+	MaxLecturerDailyHours       int
+	MaxLecturerConsecutiveHours int
+	MaxLecturerDays             int
+	MinLecturerDays             int
+	NoEarlyAfterLate            bool
+	RoomOccupancyRatio          float64
+	SemesterStart               string          // YYYY-MM-DD, first day of the calendar export
+	SemesterEnd                 string          // YYYY-MM-DD, last day of the calendar export
+	Seed                        int64           // Random seed, 0 picks one at start
+	IgnoredCourses              []string        // Course codes that aren't loaded
+	Progress                    func(*Progress) `json:"-"` // Optional, called while generating or repairing
+	Cancelled                   func() bool     `json:"-"` // Optional, generating or repairing stops early once it returns true
+	Random                      *rand.Rand      `json:"-"` // Random source of the run, set by SeedRandom
 }
 
 func NewDefaultConfiguration() *Configuration {
@@ -31,6 +53,8 @@ func NewDefaultConfiguration() *Configuration {
 		ConflictsFile:               "./res/private/conflict.csv",
 		SplitFile:                   "./res/private/split.csv",
 		ExternalFile:                "./res/private/external.csv",
+		LecturerLimitsFile:          "./res/private/limits.csv",
+		LocksFile:                   "",
 		ExportFile:                  "schedule.csv",
 		NumberOfDays:                5,
 		TimeSlotDuration:            60,
@@ -39,12 +63,73 @@ func NewDefaultConfiguration() *Configuration {
 		IterSoftLimit:               5000,    // Feasible limit up until state 1
 		DepartmentCongestionLimit:   11,
 		ActivityDay:                 3,
+		MaxLecturerDailyHours:       0, // Lecturer limits are opt-in, 0 is no limit
+		MaxLecturerConsecutiveHours: 0,
+		MaxLecturerDays:             5,
+		MinLecturerDays:             0,
+		NoEarlyAfterLate:            false,
+		RoomOccupancyRatio:          0.8,
+		IgnoredCourses:              []string{"ENGR450", "IE101", "CENG404"},
+	}
+}
+
+// CheckConfiguration reports values the scheduler can't work with, one per line.
+func CheckConfiguration(cfg *Configuration) (bool, string) {
+	var errorExists bool = false
+	var errorString string = ""
+	check := func(ok bool, message string) {
+		if !ok {
+			errorExists = true
+			errorString = errorString + message + "\n"
+		}
+	}
+
+	check(cfg.NumberOfDays >= 1 && cfg.NumberOfDays <= model.WeekLength, fmt.Sprintf("NumberOfDays must be between 1 and %d", model.WeekLength))
+	check(cfg.TimeSlotDuration > 0, "TimeSlotDuration must be positive")
+	check(cfg.TimeSlotCount > 0, "TimeSlotCount must be positive")
+	check(model.FirstSlotStart+cfg.TimeSlotCount*cfg.TimeSlotDuration <= 24*60, "TimeSlotCount time slots of TimeSlotDuration minutes starting at 08:30 must end by midnight")
+	check(cfg.ActivityDay >= 0 && cfg.ActivityDay < cfg.NumberOfDays, "ActivityDay must be a day of the week, from 0 to NumberOfDays-1")
+	check(cfg.IterSoftLimit >= 2, "IterSoftLimit must be at least 2")
+	check(cfg.DepartmentCongestionLimit >= 0, "DepartmentCongestionLimit can't be negative")
+	check(cfg.RelativeConflictProbability >= 0 && cfg.RelativeConflictProbability <= 2, "RelativeConflictProbability must be between 0 and 2")
+	check(cfg.RoomOccupancyRatio > 0 && cfg.RoomOccupancyRatio <= 1, "RoomOccupancyRatio must be greater than 0 and at most 1")
+	check(cfg.MaxLecturerDailyHours >= 0 && cfg.MaxLecturerConsecutiveHours >= 0, "Lecturer hour limits can't be negative")
+	check(cfg.MinLecturerDays >= 0 && cfg.MaxLecturerDays >= 0 && (cfg.MaxLecturerDays == 0 || cfg.MinLecturerDays <= cfg.MaxLecturerDays), "MinLecturerDays can't exceed MaxLecturerDays")
+	for _, date := range []string{cfg.SemesterStart, cfg.SemesterEnd} {
+		_, err := time.Parse(time.DateOnly, date)
+		check(date == "" || err == nil, "SemesterStart and SemesterEnd must be YYYY-MM-DD dates")
+	}
+	for _, code := range cfg.IgnoredCourses {
+		check(strings.TrimSpace(code) != "", "IgnoredCourses can't contain empty course codes")
+	}
+	return errorExists, errorString
+}
+
+// Fast UINT64 RNG drawing from the random source of a run
+func Rand64(random *rand.Rand) uint64 {
+	return random.Uint64()
+}
+
+// SeedRandom gives the run its own random source seeded with the configured seed, picking
+// one if unset. Runs don't share a source so the seed repeats the run.
+func SeedRandom(cfg *Configuration) {
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	cfg.Random = rand.New(rand.NewSource(cfg.Seed))
+}
+
+// Random source of the run, seeded when SeedRandom wasn't called
+func (cfg *Configuration) random() *rand.Rand {
+	if cfg.Random == nil {
+		SeedRandom(cfg)
 	}
+	return cfg.Random
 }
 
-// Fast UINT64 RNG
-func Rand64() uint64 {
-	return new(maphash.Hash).Sum64()
+// Tells if the run should stop with the best schedule so far
+func (cfg *Configuration) cancelled() bool {
+	return cfg.Cancelled != nil && cfg.Cancelled()
 }
 
 func containsINT(s []int, e int) bool {
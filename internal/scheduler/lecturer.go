@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+const lateFinish = 16*60 + 30 // 16:30
+
+// Check lecturer load limits for placing course into given time interval
+func checkLecturerLimits(schedule *model.Schedule, day *model.Day, start int, needed int, course *model.Course) bool {
+	limits := course.Limits
+	if limits == nil || course.Lecturer == "" {
+		return true
+	}
+
+	occupied := lecturerSlots(day, course.Lecturer)
+	teachesToday := countSlots(occupied) > 0
+	for i := start; i < start+needed && i < len(occupied); i++ {
+		occupied[i] = true
+	}
+
+	if limits.MaxDailyHours > 0 && countSlots(occupied)*schedule.TimeSlotDuration > limits.MaxDailyHours*60 {
+		return false
+	}
+	if limits.MaxConsecutiveHours > 0 && longestRun(occupied)*schedule.TimeSlotDuration > limits.MaxConsecutiveHours*60 {
+		return false
+	}
+	if limits.MaxDays > 0 && !teachesToday && len(teachingDays(schedule, course.Lecturer)) >= limits.MaxDays {
+		return false
+	}
+	if limits.NoEarlyAfterLate {
+		// No 08:30 after finishing late the day before and vice versa
+		if prev := findDay(schedule, day.DayOfWeek-1); occupied[0] && prev != nil && finishesLate(schedule, lecturerSlots(prev, course.Lecturer)) {
+			return false
+		}
+		if next := findDay(schedule, day.DayOfWeek+1); finishesLate(schedule, occupied) && next != nil && lecturerSlots(next, course.Lecturer)[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// Order days so that lecturers below their minimum teaching days are spread over new days first
+func lecturerDayOrder(schedule *model.Schedule, course *model.Course, sessions map[string]int) []*model.Day {
+	if course.Limits == nil || course.Lecturer == "" {
+		return schedule.Days
+	}
+	minDays := min(course.Limits.MinDays, sessions[course.Lecturer])
+	taught := teachingDays(schedule, course.Lecturer)
+	if len(taught) >= minDays {
+		return schedule.Days
+	}
+
+	ordered := make([]*model.Day, 0, len(schedule.Days))
+	for _, day := range schedule.Days {
+		if !taught[day.DayOfWeek] {
+			ordered = append(ordered, day)
+		}
+	}
+	for _, day := range schedule.Days {
+		if taught[day.DayOfWeek] {
+			ordered = append(ordered, day)
+		}
+	}
+	return ordered
+}
+
+// Count sessions of each lecturer, minimum teaching days can't exceed it
+func countLecturerSessions(courses []*model.Course, labs []*model.Laboratory) map[string]int {
+	sessions := map[string]int{}
+	for _, c := range courses {
+		sessions[c.Lecturer]++
+	}
+	for _, l := range labs {
+		sessions[l.Lecturer]++
+	}
+	return sessions
+}
+
+// Find violated limits of a lecturer over the whole week. Minimum teaching days are only
+// preferred while placing, so falling short of them is a warning.
+func lecturerViolations(schedule *model.Schedule, lecturer string, limits *model.LecturerLimit, sessions int) []*Violation {
+	violations := []*Violation{}
+	add := func(day int, message string) *Violation {
+		violation := newViolation(ViolationLecturerLoad, message)
+		violation.Day, violation.Lecturer = day, lecturer
+		violations = append(violations, violation)
+		return violation
+	}
+	for dayOfWeek := 0; dayOfWeek < len(schedule.Days); dayOfWeek++ {
+		day := findDay(schedule, dayOfWeek)
+		occupied := lecturerSlots(day, lecturer)
+		hours := float64(countSlots(occupied)*schedule.TimeSlotDuration) / 60.0
+		if limits.MaxDailyHours > 0 && hours > float64(limits.MaxDailyHours) {
+			add(dayOfWeek, fmt.Sprintf("Lecturer %s teaches %g hours on %s, daily limit is %d", lecturer, hours, model.DayName(dayOfWeek), limits.MaxDailyHours))
+		}
+		consecutive := float64(longestRun(occupied)*schedule.TimeSlotDuration) / 60.0
+		if limits.MaxConsecutiveHours > 0 && consecutive > float64(limits.MaxConsecutiveHours) {
+			add(dayOfWeek, fmt.Sprintf("Lecturer %s teaches %g consecutive hours on %s, limit is %d", lecturer, consecutive, model.DayName(dayOfWeek), limits.MaxConsecutiveHours))
+		}
+		if next := findDay(schedule, dayOfWeek+1); limits.NoEarlyAfterLate && next != nil && finishesLate(schedule, occupied) && lecturerSlots(next, lecturer)[0] {
+			add(dayOfWeek+1, fmt.Sprintf("Lecturer %s teaches at 08:30 on %s after finishing late on %s", lecturer, model.DayName(dayOfWeek+1), model.DayName(dayOfWeek)))
+		}
+	}
+
+	days := len(teachingDays(schedule, lecturer))
+	if limits.MaxDays > 0 && days > limits.MaxDays {
+		add(-1, fmt.Sprintf("Lecturer %s teaches on %d days, maximum is %d", lecturer, days, limits.MaxDays))
+	}
+	if minDays := min(limits.MinDays, sessions); days < minDays {
+		add(-1, fmt.Sprintf("Lecturer %s teaches on %d days, minimum is %d", lecturer, days, minDays)).Severity = SeverityWarning
+	}
+	return violations
+}
+
+// Mark time slots of given day occupied by lecturer
+func lecturerSlots(day *model.Day, lecturer string) []bool {
+	occupied := make([]bool, len(day.Slots))
+	for i, slot := range day.Slots {
+		for _, c := range slot.CourseRefs {
+			if c.Lecturer == lecturer {
+				occupied[i] = true
+				break
+			}
+		}
+	}
+	return occupied
+}
+
+// Find days of the week on which lecturer teaches
+func teachingDays(schedule *model.Schedule, lecturer string) map[int]bool {
+	days := map[int]bool{}
+	for _, day := range schedule.Days {
+		if countSlots(lecturerSlots(day, lecturer)) > 0 {
+			days[day.DayOfWeek] = true
+		}
+	}
+	return days
+}
+
+// Find day of the week inside the shuffled schedule
+func findDay(schedule *model.Schedule, dayOfWeek int) *model.Day {
+	for _, day := range schedule.Days {
+		if day.DayOfWeek == dayOfWeek {
+			return day
+		}
+	}
+	return nil
+}
+
+// Check if any occupied slot ends at 16:30 or later
+func finishesLate(schedule *model.Schedule, occupied []bool) bool {
+	for i, o := range occupied {
+		if o && model.FirstSlotStart+(i+1)*schedule.TimeSlotDuration >= lateFinish {
+			return true
+		}
+	}
+	return false
+}
+
+func countSlots(occupied []bool) int {
+	count := 0
+	for _, o := range occupied {
+		if o {
+			count++
+		}
+	}
+	return count
+}
+
+func longestRun(occupied []bool) int {
+	longest, run := 0, 0
+	for _, o := range occupied {
+		if o {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
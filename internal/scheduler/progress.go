@@ -0,0 +1,38 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Progress of a running Generate or Repair, reported to Configuration.Progress.
+type Progress struct {
+	Iteration            int     `json:"iteration"`
+	State                int     `json:"state"`
+	PlacementProbability float64 `json:"placement_probability"`
+	BestUnassigned       int     `json:"best_unassigned"` // Unassigned courses of the best schedule so far
+	Cost                 int     `json:"cost"`            // Cost of the best schedule so far
+	ElapsedMs            float64 `json:"elapsed_ms"`
+	Done                 bool    `json:"done"`
+}
+
+// Iterations between progress reports, a better schedule is reported at once
+const ProgressInterval = 100
+
+// Report the progress if anyone listens
+func reportProgress(cfg *Configuration, iter int, state int, placementProbability float64, best *model.Schedule, bestUnassigned int, start time.Time, done bool) {
+	if cfg.Progress == nil || best == nil {
+		return
+	}
+	best.CalculateCost()
+	cfg.Progress(&Progress{
+		Iteration:            iter,
+		State:                state,
+		PlacementProbability: placementProbability,
+		BestUnassigned:       bestUnassigned,
+		Cost:                 best.Cost,
+		ElapsedMs:            float64(time.Since(start).Microseconds()) / 1000.0,
+		Done:                 done,
+	})
+}
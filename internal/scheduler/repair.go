@@ -0,0 +1,374 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Move is a course placed at a different time or classroom by a repair. Day is -1 for
+// courses that weren't placed before or couldn't be placed again.
+type Move struct {
+	CourseID   model.CourseID `json:"course_id"`
+	CourseCode string         `json:"course_code"`
+	Department string         `json:"department"`
+	Grade      int            `json:"grade"`
+	Reason     string         `json:"reason"`
+	FromDay    int            `json:"from_day"`
+	FromSlot   int            `json:"from_slot"`
+	FromRoom   string         `json:"from_room"`
+	ToDay      int            `json:"to_day"`
+	ToSlot     int            `json:"to_slot"`
+	ToRoom     string         `json:"to_room"`
+}
+
+// RepairResult holds the repaired schedule and the courses that had to move.
+type RepairResult struct {
+	Result
+	Pinned     int
+	Moves      []*Move
+	Unassigned int
+}
+
+// A placement of the previous schedule
+type pin struct {
+	course *model.Course
+	lab    *model.Laboratory
+	day    int
+	slot   int
+	room   *model.Classroom
+	reason string // Why the placement can't be kept, empty if pinned
+}
+
+// Repair re-places the courses of a previous schedule that no longer fit the current
+// inputs while keeping every other placement, including its classroom, where it was.
+// The previous schedule must be imported from the same courses, labs and classrooms.
+// Courses missing from the previous schedule are placed as new ones. If some courses
+// can't be placed the placements of their cohorts are released as well. Among the
+// attempts the one with the fewest unassigned and then moved courses is kept, a cancelled
+// run stops with the best attempt so far.
+func Repair(cfg *Configuration, previous *model.Schedule, courses []*model.Course, labs []*model.Laboratory, reserved []*model.Reserved, classrooms []*model.Classroom, conflicts []*model.Conflict, congestedDepartments map[string]int) *RepairResult {
+	start := time.Now()
+
+	// Remember where everything was before runtime properties are reset
+	labsByID := map[model.CourseID]*model.Laboratory{}
+	for _, l := range labs {
+		labsByID[l.CourseID] = l
+	}
+	pins := []*pin{}
+	seen := map[model.CourseID]bool{}
+	for dayOfWeek := 0; dayOfWeek < len(previous.Days); dayOfWeek++ {
+		day := findDay(previous, dayOfWeek)
+		for i, slot := range day.Slots {
+			for _, c := range slot.CourseRefs {
+				if seen[c.CourseID] {
+					continue
+				}
+				seen[c.CourseID] = true
+				p := &pin{course: c, day: dayOfWeek, slot: i, room: c.Classroom}
+				if len(c.TheoryIDs) != 0 {
+					p.lab = labsByID[c.CourseID]
+				}
+				pins = append(pins, p)
+			}
+		}
+	}
+	// Reserved courses claim their place first
+	slices.SortStableFunc(pins, func(p1 *pin, p2 *pin) int {
+		if p1.course.Reserved == p2.course.Reserved {
+			return 0
+		}
+		if p1.course.Reserved {
+			return -1
+		}
+		return 1
+	})
+
+	courses, labs = InitRuntimeProperties(cfg, courses, labs, 1, conflicts)
+	for _, c := range courses {
+		c.NeededSlots = int(math.Ceil(float64(c.Duration) / float64(cfg.TimeSlotDuration)))
+	}
+	for _, p := range pins {
+		p.course.NeededSlots = int(math.Ceil(float64(p.course.Duration) / float64(cfg.TimeSlotDuration)))
+		if p.lab != nil {
+			p.course.ConflictingCourses = p.lab.ConflictingCourses
+		}
+	}
+	checkPins(cfg, pins, courses, reserved, classrooms)
+
+	result := &RepairResult{}
+	bestUnassigned, bestMoved := math.MaxInt, math.MaxInt
+	for escalation := 0; escalation < 2; escalation++ {
+		if escalation == 1 {
+			if bestUnassigned == 0 {
+				break
+			}
+			// Release the cohorts of courses that still can't be placed
+			released := 0
+			for _, c := range result.Courses {
+				if c.Placed {
+					continue
+				}
+				for _, p := range pins {
+					if p.reason == "" && p.course.Department == c.Department && p.course.Class == c.Class {
+						p.reason = "released for " + c.DisplayName
+						released++
+					}
+				}
+			}
+			if released == 0 {
+				break
+			}
+		}
+
+		for iter := 1; iter <= cfg.IterSoftLimit; iter++ {
+			schedule := model.NewSchedule(cfg.NumberOfDays, cfg.TimeSlotDuration, cfg.TimeSlotCount)
+			schedule.ShuffleDays(cfg.random())
+			for _, r := range classrooms {
+				r.CreateSchedule(cfg.NumberOfDays, cfg.TimeSlotCount)
+			}
+			for _, c := range courses {
+				c.Placed = false
+				c.PlacedDay = -1
+				c.Classroom = nil
+			}
+			for _, l := range labs {
+				l.Placed = false
+				l.Classroom = nil
+			}
+			for _, p := range pins {
+				if p.reason == "" {
+					placePin(schedule, p)
+				}
+			}
+
+			cfg.random().Shuffle(len(courses), func(i, j int) {
+				courses[i], courses[j] = courses[j], courses[i]
+			})
+			PlaceReservedCourses(reserved, schedule, classrooms, cfg.RoomOccupancyRatio)
+			FillCourses(courses, labs, schedule, classrooms, cfg.RoomOccupancyRatio, 1.0, cfg.ActivityDay, congestedDepartments, cfg.DepartmentCongestionLimit, 1)
+
+			validation := ValidateSchedule(courses, labs, schedule)
+			valid, unassigned := validation.Valid, len(validation.Unassigned)
+			moved := countMoved(schedule, pins)
+			improved := unassigned < bestUnassigned || (unassigned == bestUnassigned && moved < bestMoved)
+			if improved {
+				bestUnassigned, bestMoved = unassigned, moved
+				result.Schedule = schedule.DeepCopy()
+				result.Courses = model.DeepCopyCourses(courses)
+				result.Labs = model.DeepCopyLaboratories(labs)
+				result.Iteration = iter
+			}
+			if improved || iter%ProgressInterval == 0 {
+				reportProgress(cfg, iter, 1, 1.0, result.Schedule, bestUnassigned, start, false)
+			}
+			if (valid && moved == 0) || cfg.cancelled() {
+				break
+			}
+		}
+		if cfg.cancelled() {
+			break
+		}
+	}
+
+	result.State = 1
+	result.PlacementProbability = 1.0
+	result.Elapsed = time.Since(start)
+	result.Unassigned = bestUnassigned
+	for _, p := range pins {
+		if p.reason == "" {
+			result.Pinned++
+		}
+	}
+	result.Moves = findMoves(result.Schedule, pins)
+	reportProgress(cfg, result.Iteration, 1, 1.0, result.Schedule, bestUnassigned, start, true)
+	return result
+}
+
+// Find placements of the previous schedule that can't be kept with the current inputs
+func checkPins(cfg *Configuration, pins []*pin, courses []*model.Course, reserved []*model.Reserved, classrooms []*model.Classroom) {
+	schedule := model.NewSchedule(cfg.NumberOfDays, cfg.TimeSlotDuration, cfg.TimeSlotCount)
+	for _, r := range classrooms {
+		r.CreateSchedule(cfg.NumberOfDays, cfg.TimeSlotCount)
+	}
+	coursesByID := make(map[model.CourseID]*model.Course, len(courses))
+	for _, c := range courses {
+		coursesByID[c.CourseID] = c
+	}
+	lockedRooms := map[model.CourseID]*model.Classroom{}
+	for _, r := range reserved {
+		if r.Locked && r.LabRef != nil {
+			lockedRooms[r.LabRef.CourseID] = r.Room
+		} else if r.Locked {
+			lockedRooms[r.CourseRef.CourseID] = r.Room
+		}
+	}
+
+	for _, p := range pins {
+		c := p.course
+		day := findDay(schedule, p.day)
+		switch {
+		case p.day >= cfg.NumberOfDays || p.slot+c.NeededSlots > cfg.TimeSlotCount:
+			p.reason = "doesn't fit into the day"
+		case slices.Contains(c.BusyDays, p.day):
+			p.reason = "lecturer is busy on " + model.DayName(p.day)
+		case c.Reserved && (c.ReservedDay != p.day || c.ReservedStartingTimeSlot != p.slot):
+			p.reason = "reserved time changed"
+		case lockedRooms[c.CourseID] != nil && lockedRooms[c.CourseID] != p.room:
+			p.reason = "locked classroom changed"
+		case !c.ServiceCourse && conflictsIn(day, p.slot, c):
+			p.reason = "conflicts with another course"
+		case !checkLecturerLimits(schedule, day, p.slot, c.NeededSlots, c):
+			p.reason = "exceeds lecturer limits"
+		case c.NeedsRoom && (p.room == nil || !roomFits(p.room, RequiredCapacity(c, cfg.RoomOccupancyRatio), p.day, p.slot, c.NeededSlots)):
+			p.reason = "classroom is unavailable or too small"
+		case c.HasBeenSplit && partPlacedOn(c, p.day, coursesByID):
+			p.reason = "another part is on the same day"
+		case p.lab != nil && slices.ContainsFunc(p.lab.TheoreticalCourseRef, func(t *model.Course) bool { return t.Placed && t.PlacedDay == p.day }):
+			p.reason = "theory course is on the same day"
+		}
+		if p.reason == "" {
+			placePin(schedule, p)
+		}
+	}
+}
+
+// Check for conflicting courses in the slots of a placement. Unlike checkSlots the break
+// of the lecturer isn't checked, the generator only checks it against earlier placements.
+func conflictsIn(day *model.Day, start int, course *model.Course) bool {
+	for r := start; r < start+course.NeededSlots; r++ {
+		for _, conflicting := range course.ConflictingCourses {
+			if slices.Contains(day.Slots[r].Courses, conflicting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Place a kept placement back where it was
+func placePin(schedule *model.Schedule, p *pin) {
+	var room *model.Classroom
+	if p.course.NeedsRoom {
+		room = p.room
+	}
+	PlaceCourse(p.course, findDay(schedule, p.day), p.slot, room)
+	p.course.PlacedDay = p.day
+	if p.lab != nil {
+		p.lab.Placed = true
+		p.lab.Classroom = room
+	}
+}
+
+// Count released placements that didn't end up where they were
+func countMoved(schedule *model.Schedule, pins []*pin) int {
+	moved := 0
+	for _, p := range pins {
+		if p.reason == "" {
+			continue
+		}
+		day, slot, room := findPlacement(schedule, p.course.CourseID)
+		if day != p.day || slot != p.slot || room != roomID(p.room) {
+			moved++
+		}
+	}
+	return moved
+}
+
+// List released placements that moved and courses that weren't placed before
+func findMoves(schedule *model.Schedule, pins []*pin) []*Move {
+	moves := []*Move{}
+	previous := map[model.CourseID]bool{}
+	for _, p := range pins {
+		previous[p.course.CourseID] = true
+		if p.reason == "" {
+			continue
+		}
+		day, slot, room := findPlacement(schedule, p.course.CourseID)
+		if day == p.day && slot == p.slot && room == roomID(p.room) {
+			continue
+		}
+		moves = append(moves, &Move{
+			CourseID:   p.course.CourseID,
+			CourseCode: p.course.DisplayName,
+			Department: p.course.Department,
+			Grade:      p.course.Class,
+			Reason:     p.reason,
+			FromDay:    p.day,
+			FromSlot:   p.slot,
+			FromRoom:   roomID(p.room),
+			ToDay:      day,
+			ToSlot:     slot,
+			ToRoom:     room,
+		})
+	}
+	for dayOfWeek := 0; dayOfWeek < len(schedule.Days); dayOfWeek++ {
+		for i, slot := range findDay(schedule, dayOfWeek).Slots {
+			for _, c := range slot.CourseRefs {
+				if previous[c.CourseID] {
+					continue
+				}
+				previous[c.CourseID] = true
+				moves = append(moves, &Move{
+					CourseID:   c.CourseID,
+					CourseCode: c.DisplayName,
+					Department: c.Department,
+					Grade:      c.Class,
+					Reason:     "not in previous schedule",
+					FromDay:    -1,
+					ToDay:      dayOfWeek,
+					ToSlot:     i,
+					ToRoom:     roomID(c.Classroom),
+				})
+			}
+		}
+	}
+	return moves
+}
+
+// Find the day, starting time slot and classroom of a course, day is -1 if it isn't placed
+func findPlacement(schedule *model.Schedule, id model.CourseID) (int, int, string) {
+	for _, day := range schedule.Days {
+		for i, slot := range day.Slots {
+			for _, c := range slot.CourseRefs {
+				if c.CourseID == id {
+					return day.DayOfWeek, i, roomID(c.Classroom)
+				}
+			}
+		}
+	}
+	return -1, 0, ""
+}
+
+func roomID(room *model.Classroom) string {
+	if room == nil {
+		return ""
+	}
+	return room.ID
+}
+
+// String formats the repair summary and the list of moved courses.
+func (r *RepairResult) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Repair kept %d placements and moved %d courses, %d courses are unassigned\n", r.Pinned, len(r.Moves), r.Unassigned))
+	at := func(day int, slot int, room string) string {
+		if day < 0 {
+			return "unassigned"
+		}
+		start := model.FirstSlotStart + slot*r.Schedule.TimeSlotDuration
+		text := fmt.Sprintf("%s %0.2d:%0.2d", model.DayName(day), start/60, start%60)
+		if room != "" {
+			text = text + " " + room
+		}
+		return text
+	}
+	for _, m := range r.Moves {
+		sb.WriteString(fmt.Sprintf("- %s %s %d: %s -> %s (%s)\n", m.CourseCode, m.Department, m.Grade,
+			at(m.FromDay, m.FromSlot, m.FromRoom), at(m.ToDay, m.ToSlot, m.ToRoom), m.Reason))
+	}
+	return sb.String()
+}
@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Two courses placed on Monday, CENG101 from 08:30 in R1 and CENG201 from 10:30 in R2
+type repairFixture struct {
+	cfg      *Configuration
+	previous *model.Schedule
+	courses  []*model.Course
+	rooms    []*model.Classroom
+}
+
+func newRepairFixture() *repairFixture {
+	cfg := NewDefaultConfiguration()
+	cfg.Seed = 1
+	cfg.IterSoftLimit = 50
+	course := func(id model.CourseID, code string, grade int, lecturer string) *model.Course {
+		return &model.Course{CourseID: id, Course_Code: code, DisplayName: code, Department: "CENG", Class: grade,
+			Lecturer: lecturer, Number_of_Students: 30, Duration: 120, NeededSlots: 2, NeedsRoom: true}
+	}
+	f := &repairFixture{
+		cfg:      cfg,
+		previous: model.NewSchedule(cfg.NumberOfDays, cfg.TimeSlotDuration, cfg.TimeSlotCount),
+		courses:  []*model.Course{course(1, "CENG101", 1, "Lect1"), course(2, "CENG201", 2, "Lect2")},
+		rooms: []*model.Classroom{
+			{ID: "R1", Capacity: 60, AvailabilityArray: []int{0, 1, 2, 3, 4}},
+			{ID: "R2", Capacity: 40, AvailabilityArray: []int{0, 1, 2, 3, 4}},
+		},
+	}
+	for _, r := range f.rooms {
+		r.CreateSchedule(cfg.NumberOfDays, cfg.TimeSlotCount)
+	}
+	PlaceCourse(f.courses[0], f.previous.Days[0], 0, f.rooms[0])
+	PlaceCourse(f.courses[1], f.previous.Days[0], 2, f.rooms[1])
+	return f
+}
+
+func (f *repairFixture) repair() *RepairResult {
+	return Repair(f.cfg, f.previous, f.courses, nil, nil, f.rooms, nil, map[string]int{})
+}
+
+func TestRepairKeepsPlacements(t *testing.T) {
+	result := newRepairFixture().repair()
+
+	if result.Pinned != 2 || len(result.Moves) != 0 || result.Unassigned != 0 {
+		t.Fatalf("unchanged inputs gave %s", result)
+	}
+	for _, want := range []struct {
+		id   model.CourseID
+		slot int
+		room string
+	}{
+		{1, 0, "R1"},
+		{2, 2, "R2"},
+	} {
+		if day, slot, room := findPlacement(result.Schedule, want.id); day != 0 || slot != want.slot || room != want.room {
+			t.Errorf("course %d is on day %d slot %d in %q, want day 0 slot %d in %s", want.id, day, slot, room, want.slot, want.room)
+		}
+	}
+}
+
+func TestRepairMovesCourses(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *model.Course)
+		check  func(m *Move) error
+	}{
+		{
+			"lecturer became busy",
+			func(c *model.Course) { c.BusyDays = []int{0} },
+			func(m *Move) error {
+				if m.Reason != "lecturer is busy on Monday" || m.ToDay <= 0 {
+					return fmt.Errorf("moved to day %d for %q, want another day because the lecturer is busy", m.ToDay, m.Reason)
+				}
+				return nil
+			},
+		},
+		{
+			"course outgrew its classroom",
+			func(c *model.Course) { c.Number_of_Students = 70 },
+			func(m *Move) error {
+				if m.Reason != "classroom is unavailable or too small" || m.ToRoom != "R1" {
+					return fmt.Errorf("moved to %q for %q, want R1 because R2 is too small", m.ToRoom, m.Reason)
+				}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newRepairFixture()
+			tt.change(f.courses[1])
+
+			result := f.repair()
+
+			if result.Pinned != 1 || result.Unassigned != 0 || len(result.Moves) != 1 {
+				t.Fatalf("got %s, want CENG201 moved and CENG101 kept", result)
+			}
+			m := result.Moves[0]
+			if m.CourseCode != "CENG201" || m.FromDay != 0 || m.FromSlot != 2 || m.FromRoom != "R2" {
+				t.Errorf("got move %+v, want CENG201 from Monday 10:30 in R2", *m)
+			}
+			if err := tt.check(m); err != nil {
+				t.Error(err)
+			}
+			if day, slot, room := findPlacement(result.Schedule, 1); day != 0 || slot != 0 || room != "R1" {
+				t.Errorf("CENG101 moved to day %d slot %d in %q", day, slot, room)
+			}
+		})
+	}
+}
+
+func TestRepairReportsUnplacedCourses(t *testing.T) {
+	f := newRepairFixture()
+	f.courses[1].Number_of_Students = 500
+
+	result := f.repair()
+
+	if result.Unassigned != 1 || len(result.Moves) != 1 || result.Moves[0].ToDay != -1 {
+		t.Fatalf("got %s, want CENG201 unassigned", result)
+	}
+	if day, _, _ := findPlacement(result.Schedule, 1); day != 0 {
+		t.Errorf("CENG101 moved to day %d while CENG201 couldn't be placed anywhere", day)
+	}
+}
@@ -0,0 +1,290 @@
+package scheduler
+
+import (
+	"math"
+	"strings"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Find a fitting classroom, preferring the room of other sessions of the
+// same course and then the floor of back-to-back courses of the same cohort
+func findRoom(rooms []*model.Classroom, capacity int, day int, slot int, neededSlots int, preferred *model.Classroom, hard bool, floor int) *model.Classroom {
+	if preferred != nil {
+		if roomFits(preferred, capacity, day, slot, neededSlots) {
+			return preferred
+		}
+		if hard {
+			return nil
+		}
+	}
+	if floor >= 0 {
+		for _, c := range rooms {
+			if c.FloorNumber == floor && roomFits(c, capacity, day, slot, neededSlots) {
+				return c
+			}
+		}
+	}
+	for _, c := range rooms {
+		if roomFits(c, capacity, day, slot, neededSlots) {
+			return c
+		}
+	}
+	return nil
+}
+
+// RequiredCapacity returns the seats a course needs in a classroom at given occupancy
+// ratio, rounded down.
+func RequiredCapacity(course *model.Course, occupancyRatio float64) int {
+	return int(float64(course.Number_of_Students) * occupancyRatio)
+}
+
+// Check capacity (see RequiredCapacity) and availability of a classroom for given time interval
+func roomFits(c *model.Classroom, capacity int, day int, slot int, neededSlots int) bool {
+	if capacity > c.Capacity || !containsINT(c.AvailabilityArray, day) {
+		return false
+	}
+	for i := slot; i < slot+neededSlots; i++ {
+		if !c.IsAvailable(day, i) {
+			return false
+		}
+	}
+	return true
+}
+
+// Find the classroom of an already placed session of the same course
+// (split parts and theory/lab pairs share Course_Code and Section)
+func sessionRoom(schedule *model.Schedule, course *model.Course) *model.Classroom {
+	for _, day := range schedule.Days {
+		for _, slot := range day.Slots {
+			for _, c := range slot.CourseRefs {
+				if c.CourseID != course.CourseID && c.Classroom != nil && isSameCourse(c, course) {
+					return c.Classroom
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Find the floor of a course of the same cohort right before or after given time interval.
+// Proximity only goes by floor number, classrooms don't have a building.
+func cohortFloor(day *model.Day, start int, neededSlots int, course *model.Course) int {
+	for _, i := range []int{start - 1, start + neededSlots} {
+		if i < 0 || i >= len(day.Slots) {
+			continue
+		}
+		for _, c := range day.Slots[i].CourseRefs {
+			if c.Classroom != nil && c.Department == course.Department && c.Class == course.Class {
+				return c.Classroom.FloorNumber
+			}
+		}
+	}
+	return -1
+}
+
+func isSameCourse(c1 *model.Course, c2 *model.Course) bool {
+	return c1.Course_Code == c2.Course_Code && c1.Section == c2.Section && c1.Department == c2.Department
+}
+
+func isHardRoomStability(course *model.Course) bool {
+	return strings.EqualFold(strings.TrimSpace(course.RoomStability), "hard")
+}
+
+// Room assignment costs are measured in wasted seats
+const (
+	infeasibleRoomCost = 1e9
+	sameRoomBonus      = 50.0 // Other sessions of the course use the room
+	otherFloorPenalty  = 10.0 // Back-to-back course of the cohort is on another floor
+)
+
+// AssignRooms reassigns classrooms of placed courses once days and time slots are fixed.
+// Courses starting at the same time slot are matched to free rooms with minimal wasted
+// seats, so small courses don't block large rooms. Days whose matching fails keep their
+// first-fit rooms. Labs are updated with their new classrooms.
+func AssignRooms(schedule *model.Schedule, labs []*model.Laboratory, rooms []*model.Classroom, occupancyRatio float64) {
+	for _, day := range schedule.Days {
+		starts := map[model.CourseID]int{}
+		startingAt := make([][]*model.Course, len(day.Slots))
+		for i, slot := range day.Slots {
+			for _, c := range slot.CourseRefs {
+				if _, seen := starts[c.CourseID]; seen || c.Classroom == nil || c.RoomLocked {
+					continue
+				}
+				starts[c.CourseID] = i
+				startingAt[i] = append(startingAt[i], c)
+			}
+		}
+
+		// Match slot by slot on a scratch occupancy, pinned classrooms stay occupied
+		occupied := map[*model.Classroom][]bool{}
+		for _, r := range rooms {
+			occupied[r] = make([]bool, len(day.Slots))
+		}
+		for i, slot := range day.Slots {
+			for _, c := range slot.CourseRefs {
+				if c.RoomLocked && occupied[c.Classroom] != nil {
+					occupied[c.Classroom][i] = true
+				}
+			}
+		}
+		assignment := map[*model.Course]*model.Classroom{}
+		ok := true
+		for slot, courses := range startingAt {
+			if len(courses) == 0 {
+				continue
+			}
+			if len(courses) > len(rooms) {
+				ok = false
+				break
+			}
+			cost := make([][]float64, len(courses))
+			for i, c := range courses {
+				cost[i] = make([]float64, len(rooms))
+				preferred := sessionRoom(schedule, c)
+				floor := cohortFloor(day, slot, c.NeededSlots, c)
+				for j, r := range rooms {
+					cost[i][j] = roomCost(r, c, day.DayOfWeek, slot, occupied[r], occupancyRatio, preferred, floor)
+				}
+			}
+			for i, j := range hungarian(cost) {
+				if cost[i][j] >= infeasibleRoomCost {
+					ok = false
+					break
+				}
+				assignment[courses[i]] = rooms[j]
+				for k := slot; k < slot+courses[i].NeededSlots && k < len(day.Slots); k++ {
+					occupied[rooms[j]][k] = true
+				}
+			}
+			if !ok {
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		// Move courses into their new classrooms
+		for c := range assignment {
+			for k := starts[c.CourseID]; k < starts[c.CourseID]+c.NeededSlots; k++ {
+				c.Classroom.RemoveCourse(day.DayOfWeek, k, c.CourseID)
+			}
+		}
+		for c, r := range assignment {
+			c.Classroom = r
+			for k := starts[c.CourseID]; k < starts[c.CourseID]+c.NeededSlots; k++ {
+				r.PlaceCourse(day.DayOfWeek, k, c.CourseID)
+			}
+		}
+	}
+
+	// Labs hold their own classroom reference
+	for _, l := range labs {
+		if !l.Placed || l.Classroom == nil {
+			continue
+		}
+		for _, day := range schedule.Days {
+			for _, slot := range day.Slots {
+				for _, c := range slot.CourseRefs {
+					if c.CourseID == l.CourseID {
+						l.Classroom = c.Classroom
+					}
+				}
+			}
+		}
+	}
+}
+
+// Cost of seating a course into a classroom for its whole duration
+func roomCost(room *model.Classroom, course *model.Course, day int, slot int, occupied []bool, occupancyRatio float64, preferred *model.Classroom, floor int) float64 {
+	if RequiredCapacity(course, occupancyRatio) > room.Capacity || !containsINT(room.AvailabilityArray, day) {
+		return infeasibleRoomCost
+	}
+	if slot+course.NeededSlots > len(occupied) {
+		return infeasibleRoomCost
+	}
+	for k := slot; k < slot+course.NeededSlots; k++ {
+		if occupied[k] {
+			return infeasibleRoomCost
+		}
+	}
+
+	cost := float64(max(room.Capacity-course.Number_of_Students, 0))
+	if preferred != nil {
+		if preferred.ID == room.ID {
+			cost -= sameRoomBonus
+		} else if isHardRoomStability(course) {
+			return infeasibleRoomCost
+		}
+	}
+	if floor >= 0 && room.FloorNumber != floor {
+		cost += otherFloorPenalty
+	}
+	return cost
+}
+
+// Solve the assignment problem for a rows <= columns cost matrix (Hungarian method).
+// Returns the assigned column of each row.
+func hungarian(cost [][]float64) []int {
+	n := len(cost)
+	m := len(cost[0])
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1)
+	way := make([]int, m+1)
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	assignment := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if p[j] != 0 {
+			assignment[p[j]-1] = j - 1
+		}
+	}
+	return assignment
+}
@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Course of a department and grade needing slots time slots and a classroom
+func newCourse(id model.CourseID, code string, students int, slots int) *model.Course {
+	return &model.Course{CourseID: id, Course_Code: code, DisplayName: code, Department: "CENG", Class: 1, Section: 1,
+		Number_of_Students: students, NeededSlots: slots, NeedsRoom: true}
+}
+
+// Classroom available on every day of a days x slots week
+func newRoom(id string, capacity int, floor int, days int, slots int) *model.Classroom {
+	room := &model.Classroom{ID: id, Capacity: capacity, FloorNumber: floor}
+	for d := 0; d < days; d++ {
+		room.AvailabilityArray = append(room.AvailabilityArray, d)
+	}
+	room.CreateSchedule(days, slots)
+	return room
+}
+
+// Lowest total cost of assigning every row to a distinct column, by trying all of them
+func bruteForceAssignment(cost [][]float64, row int, used []bool) float64 {
+	if row == len(cost) {
+		return 0
+	}
+	best := math.Inf(1)
+	for j := range cost[row] {
+		if used[j] {
+			continue
+		}
+		used[j] = true
+		best = min(best, cost[row][j]+bruteForceAssignment(cost, row+1, used))
+		used[j] = false
+	}
+	return best
+}
+
+func TestHungarian(t *testing.T) {
+	tests := []struct {
+		name string
+		cost [][]float64
+	}{
+		{"single", [][]float64{{3}}},
+		{"square", [][]float64{{4, 1, 3}, {2, 0, 5}, {3, 2, 2}}},
+		{"more rooms than courses", [][]float64{{10, 2, 8, 7}, {3, 9, 1, 6}}},
+		{"greedy is wrong", [][]float64{{1, 2}, {1, 100}}},
+		{"negative bonus", [][]float64{{5, -45, 20}, {0, 30, 70}}},
+		{"infeasible rooms", [][]float64{{infeasibleRoomCost, 5}, {3, infeasibleRoomCost}}},
+		{"ties", [][]float64{{1, 1, 1}, {1, 1, 1}, {1, 1, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignment := hungarian(tt.cost)
+			if len(assignment) != len(tt.cost) {
+				t.Fatalf("got %d assignments for %d rows", len(assignment), len(tt.cost))
+			}
+			used := map[int]bool{}
+			total := 0.0
+			for i, j := range assignment {
+				if used[j] {
+					t.Fatalf("column %d assigned twice in %v", j, assignment)
+				}
+				used[j] = true
+				total += tt.cost[i][j]
+			}
+			if want := bruteForceAssignment(tt.cost, 0, make([]bool, len(tt.cost[0]))); total != want {
+				t.Errorf("assignment %v costs %g, want %g", assignment, total, want)
+			}
+		})
+	}
+}
+
+func TestAssignRooms(t *testing.T) {
+	tests := []struct {
+		name     string
+		students []int  // Students of the courses, all starting at the first time slot
+		firstFit []int  // Classroom of each course before matching
+		locked   []bool // Classrooms pinned by locks
+		want     []int  // Classroom of each course after matching
+	}{
+		{"small course leaves the large room", []int{25, 90}, []int{1, 0}, nil, []int{0, 1}},
+		{"fewest wasted seats", []int{30}, []int{1}, nil, []int{0}},
+		{"locked room is kept", []int{25, 20}, []int{1, 0}, []bool{true, false}, []int{1, 0}},
+		{"no matching keeps first fit", []int{90, 90}, []int{1, 0}, nil, []int{1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := model.NewSchedule(1, 60, 4)
+			rooms := []*model.Classroom{newRoom("S", 40, 0, 1, 4), newRoom("L", 100, 0, 1, 4)}
+			courses := []*model.Course{}
+			for i, students := range tt.students {
+				c := newCourse(model.CourseID(i+1), "C"+string(rune('A'+i)), students, 2)
+				c.RoomLocked = tt.locked != nil && tt.locked[i]
+				PlaceCourse(c, schedule.Days[0], 0, rooms[tt.firstFit[i]])
+				courses = append(courses, c)
+			}
+
+			AssignRooms(schedule, nil, rooms, 1.0)
+
+			for i, c := range courses {
+				want := rooms[tt.want[i]]
+				if c.Classroom != want {
+					t.Errorf("%s is in %s, want %s", c.Course_Code, c.Classroom.ID, want.ID)
+				}
+				for slot := 0; slot < c.NeededSlots; slot++ {
+					if id := want.CourseAt(0, slot); id != c.CourseID {
+						t.Errorf("%s holds course %d at slot %d, want %s", want.ID, id, slot, c.Course_Code)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAssignRoomsKeepsHardRoomStability(t *testing.T) {
+	schedule := model.NewSchedule(2, 60, 4)
+	rooms := []*model.Classroom{newRoom("S", 40, 0, 2, 4), newRoom("L", 100, 0, 2, 4)}
+	first := newCourse(1, "CENG101", 30, 1)
+	second := newCourse(2, "CENG101", 30, 1)
+	first.RoomStability, second.RoomStability = "hard", "hard"
+	first.RoomLocked = true
+	PlaceCourse(first, schedule.Days[0], 0, rooms[1])
+	PlaceCourse(second, schedule.Days[1], 0, rooms[1])
+
+	AssignRooms(schedule, nil, rooms, 1.0)
+
+	if second.Classroom != rooms[1] {
+		t.Errorf("second session moved to %s, want the room of the first session", second.Classroom.ID)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	tests := []struct {
+		students int
+		ratio    float64
+		capacity int
+		fits     bool
+	}{
+		{40, 1.0, 40, true},
+		{41, 1.0, 40, false},
+		{50, 0.8, 40, true},
+		{51, 0.8, 40, true}, // 40.8 seats round down
+		{52, 0.8, 40, false},
+		{0, 0.8, 0, true},
+	}
+	for _, tt := range tests {
+		course := newCourse(1, "CENG101", tt.students, 1)
+		room := newRoom("R", tt.capacity, 0, 1, 2)
+		occupied := make([]bool, 2)
+		// Room search and matching must agree on which rooms are large enough
+		if got := roomFits(room, RequiredCapacity(course, tt.ratio), 0, 0, 1); got != tt.fits {
+			t.Errorf("roomFits of %d students at %g in %d seats is %t, want %t", tt.students, tt.ratio, tt.capacity, got, tt.fits)
+		}
+		if got := roomCost(room, course, 0, 0, occupied, tt.ratio, nil, -1) < infeasibleRoomCost; got != tt.fits {
+			t.Errorf("roomCost of %d students at %g in %d seats is feasible %t, want %t", tt.students, tt.ratio, tt.capacity, got, tt.fits)
+		}
+	}
+}
@@ -4,24 +4,39 @@ import (
 	"math"
 	"math/rand"
 	"slices"
-	"sort"
 
 	"github.com/rhyrak/go-schedule/pkg/model"
 )
 
 // FillCourses tries to assign a time and room for all unassigned courses.
+// Rooms are expected to be sorted by capacity.
 // Returns the number of newly assigned courses.
 // TODO: insert labs after theory
-func FillCourses(courses []*model.Course, labs []*model.Laboratory, schedule *model.Schedule, rooms []*model.Classroom, placementProbability float64, freeDayIndex int, congestedDepartments map[string]int, congestionLimit int, state int) (bool, int) {
-	sort.Slice(rooms, func(i, j int) bool {
-		return rooms[i].Capacity < rooms[j].Capacity
-	})
+func FillCourses(courses []*model.Course, labs []*model.Laboratory, schedule *model.Schedule, rooms []*model.Classroom, occupancyRatio float64, placementProbability float64, freeDayIndex int, congestedDepartments map[string]int, congestionLimit int, state int) (bool, int) {
 
 	// start at 8:30 or 9:30 according to congestion and class
 	var startSlot int
 
 	placedCount := 0
 
+	// Index courses to look up the other parts of split courses
+	coursesByID := make(map[model.CourseID]*model.Course, len(courses))
+	for _, course := range courses {
+		coursesByID[course.CourseID] = course
+	}
+	lecturerSessions := countLecturerSessions(courses, labs)
+
+	// Days of locked laboratories, their theory courses go on other days
+	lockedLabDays := map[model.CourseID][]int{}
+	for _, lab := range labs {
+		if !lab.Reserved || !lab.Placed {
+			continue
+		}
+		for _, theory := range lab.TheoreticalCourseRef {
+			lockedLabDays[theory.CourseID] = append(lockedLabDays[theory.CourseID], lab.ReservedDay)
+		}
+	}
+
 	// Iterate over courses
 	for _, course := range courses {
 		// Skip course if it has been placed
@@ -41,7 +56,15 @@ func FillCourses(courses []*model.Course, labs []*model.Laboratory, schedule *mo
 		ignoreAKTSLimit := shouldIgnoreAKTSLimit(schedule.Days, course.Department, course.Class)
 
 		// Iterate over days
-		for _, day := range schedule.Days {
+		for _, day := range lecturerDayOrder(schedule, course, lecturerSessions) {
+			// Keep parts of a split course on separate days
+			if course.HasBeenSplit && partPlacedOn(course, day.DayOfWeek, coursesByID) {
+				continue
+			}
+			if slices.Contains(lockedLabDays[course.CourseID], day.DayOfWeek) {
+				continue
+			}
+
 			// Try to leave Activity Day empty (opsiyonel)
 			if course.Compulsory && day.DayOfWeek == freeDayIndex && course.ConflictProbability > placementProbability {
 				continue
@@ -54,11 +77,11 @@ func FillCourses(courses []*model.Course, labs []*model.Laboratory, schedule *mo
 					if course.Duration == 180 { // (3*60=180) Put at 14:30 if course duration is 3 hours, otherwise 13:30
 						slotIndex = schedule.TimeSlotCount/2 + 2
 					}
-					placed = tryPlaceIntoDay(course, schedule, day.DayOfWeek, day, rooms, slotIndex, false)
+					placed = tryPlaceIntoDay(course, schedule, day.DayOfWeek, day, rooms, occupancyRatio, slotIndex, false)
 				}
 				// Otherwise try and place it in the morning hours
 				if !placed {
-					placed = tryPlaceIntoDay(course, schedule, day.DayOfWeek, day, rooms, startSlot, false)
+					placed = tryPlaceIntoDay(course, schedule, day.DayOfWeek, day, rooms, occupancyRatio, startSlot, false)
 				}
 				if placed {
 					placedCount++
@@ -100,11 +123,11 @@ func FillCourses(courses []*model.Course, labs []*model.Laboratory, schedule *mo
 						if course.Duration == 180 { // (3*60=180) Put at 14:30 if course duration is 3 hours, otherwise 13:30
 							slotIndex = schedule.TimeSlotCount/2 + 2
 						}
-						placed = tryPlaceIntoDay(course, schedule, day.DayOfWeek, day, rooms, slotIndex, false)
+						placed = tryPlaceIntoDay(course, schedule, day.DayOfWeek, day, rooms, occupancyRatio, slotIndex, false)
 					}
 					// Otherwise try and place it in the morning hours
 					if !placed {
-						placed = tryPlaceIntoDay(course, schedule, day.DayOfWeek, day, rooms, startSlot, false)
+						placed = tryPlaceIntoDay(course, schedule, day.DayOfWeek, day, rooms, occupancyRatio, startSlot, false)
 					}
 					if placed {
 						placedCount++
@@ -118,71 +141,76 @@ func FillCourses(courses []*model.Course, labs []*model.Laboratory, schedule *mo
 			return false, -1
 		}
 	}
-	return true, placedCount + PlaceLaboratories(labs, schedule, rooms, placementProbability, congestedDepartments, congestionLimit)
+	return true, placedCount + PlaceLaboratories(labs, schedule, rooms, occupancyRatio, placementProbability, congestedDepartments, congestionLimit)
 }
 
-func PlaceLaboratories(labs []*model.Laboratory, schedule *model.Schedule, rooms []*model.Classroom, placementProbability float64, congestedDepartments map[string]int, congestionLimit int) int {
-	sort.Slice(rooms, func(i, j int) bool {
-		return rooms[i].Capacity < rooms[j].Capacity
-	})
+// LabCourse creates the course a laboratory is placed into the schedule as.
+func LabCourse(lab *model.Laboratory) *model.Course {
+	labCourse := &model.Course{
+		Section:                  lab.Section,
+		Course_Code:              lab.Course_Code,
+		Course_Name:              lab.Course_Name,
+		Number_of_Students:       lab.Number_of_Students,
+		Course_Environment:       "lab",
+		TplusU:                   lab.TplusU,
+		AKTS:                     lab.AKTS,
+		Class:                    lab.Class,
+		Department:               lab.Department,
+		Lecturer:                 lab.Lecturer,
+		RoomStability:            lab.RoomStability,
+		Duration:                 lab.Duration,
+		CourseID:                 lab.CourseID,
+		ConflictingCourses:       lab.ConflictingCourses,
+		Placed:                   false,
+		Classroom:                nil,
+		NeedsRoom:                lab.NeedsRoom,
+		NeededSlots:              lab.NeededSlots,
+		Reserved:                 lab.Reserved,
+		ReservedStartingTimeSlot: lab.ReservedStartingTimeSlot,
+		ReservedDay:              lab.ReservedDay,
+		BusyDays:                 lab.BusyDays,
+		Limits:                   lab.Limits,
+		Compulsory:               lab.Compulsory,
+		ConflictProbability:      0.0,
+		DisplayName:              lab.DisplayName,
+		ServiceCourse:            false,
+		HasBeenSplit:             false,
+		HasLab:                   false,
+		PlacedDay:                -1,
+		AreEqual:                 true,
+		TheoryIDs:                []model.CourseID{},
+	}
+	for _, theory := range lab.TheoreticalCourseRef {
+		labCourse.TheoryIDs = append(labCourse.TheoryIDs, theory.CourseID)
+	}
+	return labCourse
+}
+
+func PlaceLaboratories(labs []*model.Laboratory, schedule *model.Schedule, rooms []*model.Classroom, occupancyRatio float64, placementProbability float64, congestedDepartments map[string]int, congestionLimit int) int {
 	var startSlot int
 
 	placedCount := 0
 	for _, lab := range labs {
-		if lab.Placed {
+		if lab.Placed || lab.Reserved {
 			continue
 		}
-		dummyCourse := model.Course{
-			Section:                  lab.Section,
-			Course_Code:              lab.Course_Code,
-			Course_Name:              lab.Course_Name,
-			Number_of_Students:       lab.Number_of_Students,
-			Course_Environment:       "lab",
-			TplusU:                   lab.TplusU,
-			AKTS:                     lab.AKTS,
-			Class:                    lab.Class,
-			Department:               lab.Department,
-			Lecturer:                 lab.Lecturer,
-			Duration:                 lab.Duration,
-			CourseID:                 lab.CourseID,
-			ConflictingCourses:       lab.ConflictingCourses,
-			Placed:                   false,
-			Classroom:                nil,
-			NeedsRoom:                lab.NeedsRoom,
-			NeededSlots:              lab.NeededSlots,
-			Reserved:                 false,
-			ReservedStartingTimeSlot: 0,
-			ReservedDay:              0,
-			BusyDays:                 lab.BusyDays,
-			Compulsory:               lab.Compulsory,
-			ConflictProbability:      0.0,
-			DisplayName:              lab.DisplayName,
-			ServiceCourse:            false,
-			HasBeenSplit:             false,
-			IsFirstHalf:              false,
-			HasLab:                   false,
-			PlacedDay:                -1,
-			AreEqual:                 true,
-			IsBiggerHalf:             false,
-		}
+		dummyCourse := LabCourse(lab)
 
 		isCongested := congestedDepartments[dummyCourse.Department] >= congestionLimit
 		dummyCourse.NeededSlots = int(math.Ceil(float64(dummyCourse.Duration) / float64(schedule.TimeSlotDuration)))
 		ignoreDailyLimit := shouldIgnoreDailyLimit(schedule.Days, dummyCourse.Department, dummyCourse.Class)
 		ignoreAKTSLimit := shouldIgnoreAKTSLimit(schedule.Days, dummyCourse.Department, dummyCourse.Class)
 
-		var day1, day2 int
-		if lab.TheoreticalCourseRef[0].HasBeenSplit {
-			day1 = lab.TheoreticalCourseRef[0].PlacedDay
-			day2 = lab.TheoreticalCourseRef[1].PlacedDay
-		} else {
-			day1 = lab.TheoreticalCourseRef[0].PlacedDay
-			day2 = day1
-		}
-
 		for _, day := range schedule.Days {
 			// Skip day(s) of theoretical course
-			if day.DayOfWeek == day1 || day.DayOfWeek == day2 {
+			theoryDay := false
+			for _, theory := range lab.TheoreticalCourseRef {
+				if theory.Placed && theory.PlacedDay == day.DayOfWeek {
+					theoryDay = true
+					break
+				}
+			}
+			if theoryDay {
 				continue
 			}
 			// If less than congestionLimit, put maximum 3 courses per day
@@ -217,10 +245,10 @@ func PlaceLaboratories(labs []*model.Laboratory, schedule *model.Schedule, rooms
 					if dummyCourse.Duration == 180 { // (3*60=180) Put at 14:30 if course duration is 3 hours, otherwise 13:30
 						slotIndex = schedule.TimeSlotCount/2 + 2
 					}
-					placed = tryPlaceIntoDay(&dummyCourse, schedule, day.DayOfWeek, day, rooms, slotIndex, false)
+					placed = tryPlaceIntoDay(dummyCourse, schedule, day.DayOfWeek, day, rooms, occupancyRatio, slotIndex, false)
 				}
 				if !placed {
-					placed = tryPlaceIntoDay(&dummyCourse, schedule, day.DayOfWeek, day, rooms, startSlot, false)
+					placed = tryPlaceIntoDay(dummyCourse, schedule, day.DayOfWeek, day, rooms, occupancyRatio, startSlot, false)
 				}
 				if placed {
 					placedCount++
@@ -234,14 +262,46 @@ func PlaceLaboratories(labs []*model.Laboratory, schedule *model.Schedule, rooms
 	return placedCount
 }
 
-// Place reserved courses whilst ignoring some checks (mostly same logic as previous function)
-func PlaceReservedCourses(courses []*model.Reserved, schedule *model.Schedule, rooms []*model.Classroom) int {
-	sort.Slice(rooms, func(i, j int) bool {
-		return rooms[i].Capacity < rooms[j].Capacity
-	})
+// Place reserved courses whilst ignoring some checks (mostly same logic as previous function).
+// Locked sessions are placed first, exactly at their time and into their pinned classroom if
+// any. Locks breaking hard constraints are left unplaced and get a Violation.
+func PlaceReservedCourses(courses []*model.Reserved, schedule *model.Schedule, rooms []*model.Classroom, occupancyRatio float64) int {
 	placedCount := 0
+
+	for _, course := range courses {
+		if !course.Locked {
+			continue
+		}
+		ref := course.CourseRef
+		if course.LabRef != nil {
+			if course.LabRef.Placed {
+				continue
+			}
+			ref = LabCourse(course.LabRef)
+		} else if ref.Placed {
+			continue
+		}
+		ref.NeededSlots = int(math.Ceil(float64(ref.Duration) / float64(schedule.TimeSlotDuration)))
+		ref.RoomLocked = course.Room != nil
+		shouldIgnoreDailyLimit(schedule.Days, ref.Department, ref.Class)
+		shouldIgnoreAKTSLimit(schedule.Days, ref.Department, ref.Class)
+
+		var classroom *model.Classroom
+		classroom, course.Violation = checkLock(course, ref, schedule, rooms, occupancyRatio)
+		if course.Violation != "" {
+			continue
+		}
+		PlaceCourse(ref, findDay(schedule, ref.ReservedDay), ref.ReservedStartingTimeSlot, classroom)
+		ref.PlacedDay = ref.ReservedDay
+		if course.LabRef != nil {
+			course.LabRef.Placed = true
+			course.LabRef.Classroom = classroom
+		}
+		placedCount++
+	}
+
 	for _, course := range courses {
-		if course.CourseRef.Placed {
+		if course.Locked || course.CourseRef.Placed {
 			continue
 		}
 		course.CourseRef.NeededSlots = int(math.Ceil(float64(course.CourseRef.Duration) / float64(schedule.TimeSlotDuration)))
@@ -257,32 +317,23 @@ func PlaceReservedCourses(courses []*model.Reserved, schedule *model.Schedule, r
 		}
 
 		// Attempt to place into desired time period if possible
-		placed := tryPlaceIntoDay(course.CourseRef, schedule, day.DayOfWeek, day, rooms, course.CourseRef.ReservedStartingTimeSlot, course.CourseRef.ServiceCourse)
+		placed := tryPlaceIntoDay(course.CourseRef, schedule, day.DayOfWeek, day, rooms, occupancyRatio, course.CourseRef.ReservedStartingTimeSlot, course.CourseRef.ServiceCourse)
 		if placed {
 			placedCount++
+			course.CourseRef.PlacedDay = day.DayOfWeek
 		}
 	}
 	return placedCount
 }
 
-// Find a fitting classroom
-func findRoom(rooms []*model.Classroom, capacity int, day int, slot int, neededSlots int, department string) *model.Classroom {
-	for _, c := range rooms {
-		if capacity > c.Capacity || !containsINT(c.AvailabilityArray, day) {
-			continue
-		}
-		roomOk := true
-		for i := slot; i < slot+neededSlots; i++ {
-			if !c.IsAvailable(day, i) {
-				roomOk = false
-				break
-			}
-		}
-		if roomOk {
-			return c
-		}
+// Check the hard constraints of a locked session, returns the classroom to place it into
+// or the first broken constraint
+func checkLock(lock *model.Reserved, course *model.Course, schedule *model.Schedule, rooms []*model.Classroom, occupancyRatio float64) (*model.Classroom, string) {
+	classroom, violations := PlacementViolations(course, schedule, course.ReservedDay, course.ReservedStartingTimeSlot, lock.Room, rooms, occupancyRatio)
+	if len(violations) != 0 {
+		return nil, violations[0]
 	}
-	return nil
+	return classroom, ""
 }
 
 // Daily course limit
@@ -349,51 +400,70 @@ func checkSlots(day *model.Day, start int, max int, needed int, course *model.Co
 
 // Place course into desired time interval if all conditions are met
 func tryPlaceIntoDay(course *model.Course, schedule *model.Schedule,
-	dayIndex int, day *model.Day, rooms []*model.Classroom, startingSlot int, isService bool) bool {
+	dayIndex int, day *model.Day, rooms []*model.Classroom, occupancyRatio float64, startingSlot int, isService bool) bool {
+	// Other sessions of the course don't move while it is placed, their room is looked up once
+	var preferred *model.Classroom
+	if course.NeedsRoom {
+		preferred = sessionRoom(schedule, course)
+	}
 	for start := startingSlot; start < schedule.TimeSlotCount; start++ {
 		var canFit bool
 		if isService {
 			canFit = true
 		} else {
-			canFit = checkSlots(day, start, schedule.TimeSlotCount, course.NeededSlots, course)
+			canFit = checkSlots(day, start, schedule.TimeSlotCount, course.NeededSlots, course) &&
+				checkLecturerLimits(schedule, day, start, course.NeededSlots, course)
 		}
 		var classroom *model.Classroom = nil
-		if course.NeedsRoom {
-			expectedPopulation := float32(course.Number_of_Students) * 0.8
-			classroom = findRoom(rooms, int(expectedPopulation), dayIndex, start, course.NeededSlots, course.Department)
+		if canFit && course.NeedsRoom {
+			floor := cohortFloor(day, start, course.NeededSlots, course)
+			classroom = findRoom(rooms, RequiredCapacity(course, occupancyRatio), dayIndex, start, course.NeededSlots, preferred, isHardRoomStability(course), floor)
 		}
 		if canFit && (classroom != nil || !course.NeedsRoom) {
-			course.Placed = true
-			day.GradeCounter[course.Department][course.Class]++
-			day.GradeCreditCounter[course.Department][course.Class] = day.GradeCreditCounter[course.Department][course.Class] + course.AKTS
-			if classroom != nil {
-				course.Classroom = classroom
-			}
-			for i := start; i < start+course.NeededSlots; i++ {
-				day.Slots[i].Courses = append(day.Slots[i].Courses, course.CourseID)
-				day.Slots[i].CourseRefs = append(day.Slots[i].CourseRefs, course)
-				if classroom != nil {
-					classroom.PlaceCourse(dayIndex, i, course.CourseID)
-				}
-			}
+			PlaceCourse(course, day, start, classroom)
 			break
 		}
 	}
 	return course.Placed
 }
 
+// PlaceCourse puts course into given day from starting time slot on and occupies classroom
+// if it isn't nil. Constraints are not checked.
+func PlaceCourse(course *model.Course, day *model.Day, start int, classroom *model.Classroom) {
+	if _, ok := day.GradeCounter[course.Department]; !ok {
+		day.GradeCounter[course.Department] = make([]int, 5)
+	}
+	if _, ok := day.GradeCreditCounter[course.Department]; !ok {
+		day.GradeCreditCounter[course.Department] = make([]float32, 5)
+	}
+	course.Placed = true
+	day.GradeCounter[course.Department][course.Class]++
+	day.GradeCreditCounter[course.Department][course.Class] = day.GradeCreditCounter[course.Department][course.Class] + course.AKTS
+	if classroom != nil {
+		course.Classroom = classroom
+	}
+	for i := start; i < start+course.NeededSlots && i < len(day.Slots); i++ {
+		day.Slots[i].Courses = append(day.Slots[i].Courses, course.CourseID)
+		day.Slots[i].CourseRefs = append(day.Slots[i].CourseRefs, course)
+		if classroom != nil {
+			classroom.PlaceCourse(day.DayOfWeek, i, course.CourseID)
+		}
+	}
+}
+
 // Assign properties according to state
-func InitRuntimeProperties(courses []*model.Course, labs []*model.Laboratory, state int, conflicts []*model.Conflict, relativeConflictProbability float64) ([]*model.Course, []*model.Laboratory) {
+func InitRuntimeProperties(cfg *Configuration, courses []*model.Course, labs []*model.Laboratory, state int, conflicts []*model.Conflict) ([]*model.Course, []*model.Laboratory) {
+	random := cfg.random()
 	// Assign placement probability according to state
 	if state == 0 {
 		for _, c := range courses {
 			// Random float if compulsory
 			if c.Compulsory {
-				c.ConflictProbability = float64(Rand64()) / 18446744073709551615.0 // Divide by UINT64.MAX to obtain 0-1 range
+				c.ConflictProbability = float64(Rand64(random)) / 18446744073709551615.0 // Divide by UINT64.MAX to obtain 0-1 range
 			}
 		}
 		for _, l := range labs {
-			l.ConflictProbability = float64(Rand64()) / 18446744073709551615.0 // Divide by UINT64.MAX to obtain 0-1 range
+			l.ConflictProbability = float64(Rand64(random)) / 18446744073709551615.0 // Divide by UINT64.MAX to obtain 0-1 range
 		}
 	} else {
 		for _, c := range courses {
@@ -411,7 +481,8 @@ func InitRuntimeProperties(courses []*model.Course, labs []*model.Laboratory, st
 	for _, c := range courses {
 		c.ConflictingCourses = []model.CourseID{}
 		c.Placed = false
-		if !c.AreEqual {
+		c.PlacedDay = -1
+		if !c.AreEqual && !c.Reserved {
 			c.ReservedDay = -1
 		}
 
@@ -446,7 +517,7 @@ func InitRuntimeProperties(courses []*model.Course, labs []*model.Laboratory, st
 				}
 			}
 
-			if state == 0 && (c1.Department == c2.Department) && (c1.Class-c2.Class == 1 || c1.Class-c2.Class == -1) && (c1.Compulsory && c2.Compulsory) && (c1.ConflictProbability+c2.ConflictProbability > relativeConflictProbability) {
+			if state == 0 && (c1.Department == c2.Department) && (c1.Class-c2.Class == 1 || c1.Class-c2.Class == -1) && (c1.Compulsory && c2.Compulsory) && (c1.ConflictProbability+c2.ConflictProbability > cfg.RelativeConflictProbability) {
 				conflict = true
 			}
 
@@ -477,37 +548,8 @@ func InitRuntimeProperties(courses []*model.Course, labs []*model.Laboratory, st
 
 	// Handle Inequal duration split courses
 	for _, c := range courses {
-		if c.HasBeenSplit && !c.AreEqual && c.IsBiggerHalf {
-			for {
-				randNum := rand.Intn(4)
-				if !slices.Contains(c.BusyDays, randNum) {
-					c.ReservedDay = randNum
-					break
-				}
-			}
-		}
-	}
-	// Handle Inequal duration split courses
-	for _, c1 := range courses {
-		if c1.HasBeenSplit && !c1.AreEqual && !c1.IsBiggerHalf {
-			// Search for twin course
-			for _, c2 := range courses {
-				if c1.CourseID == c2.CourseID {
-					continue
-				}
-				if c2.CourseID == c1.OtherHalfID {
-					twinDay := c2.ReservedDay
-					twinRandom := rand.Intn(4 - twinDay)
-					twinRandom = twinRandom + twinDay + 1
-					for {
-						if !slices.Contains(c1.BusyDays, twinRandom) {
-							c1.ReservedDay = twinRandom
-							break
-						}
-					}
-					break
-				}
-			}
+		if c.HasBeenSplit && !c.AreEqual && c.PartIndex == 0 {
+			assignPartDays(findParts(courses, c.ParentID), cfg.NumberOfDays, random)
 		}
 	}
 
@@ -569,7 +611,7 @@ func InitRuntimeProperties(courses []*model.Course, labs []*model.Laboratory, st
 				conflict = true
 			}
 			// Conflicting neighbour course
-			if state == 0 && (c.Department == l.Department) && (c.Class-l.Class == 1 || c.Class-l.Class == -1) && (c.Compulsory && l.Compulsory) && (c.ConflictProbability+l.ConflictProbability > relativeConflictProbability) {
+			if state == 0 && (c.Department == l.Department) && (c.Class-l.Class == 1 || c.Class-l.Class == -1) && (c.Compulsory && l.Compulsory) && (c.ConflictProbability+l.ConflictProbability > cfg.RelativeConflictProbability) {
 				conflict = true
 			}
 
@@ -583,3 +625,58 @@ func InitRuntimeProperties(courses []*model.Course, labs []*model.Laboratory, st
 	return courses, labs
 
 }
+
+// Find all parts of a split course
+func findParts(courses []*model.Course, parentID model.CourseID) []*model.Course {
+	parts := []*model.Course{}
+	for _, c := range courses {
+		if c.HasBeenSplit && c.ParentID == parentID {
+			parts = append(parts, c)
+		}
+	}
+	return parts
+}
+
+// Reserve a distinct day of the week's days for each part of a split course, bigger parts
+// earlier in the week
+func assignPartDays(parts []*model.Course, days int, random *rand.Rand) {
+	freeDays := []int{}
+	for d := 0; d < days; d++ {
+		if !slices.Contains(parts[0].BusyDays, d) && !slices.ContainsFunc(parts, func(p *model.Course) bool { return p.Reserved && p.ReservedDay == d }) {
+			freeDays = append(freeDays, d)
+		}
+	}
+	// Locked parts keep their day
+	parts = slices.DeleteFunc(slices.Clone(parts), func(p *model.Course) bool { return p.Reserved })
+	random.Shuffle(len(freeDays), func(i, j int) {
+		freeDays[i], freeDays[j] = freeDays[j], freeDays[i]
+	})
+	if len(freeDays) > len(parts) {
+		freeDays = freeDays[:len(parts)]
+	}
+	slices.Sort(freeDays)
+
+	slices.SortStableFunc(parts, func(p1 *model.Course, p2 *model.Course) int {
+		return p2.Duration - p1.Duration
+	})
+	for i, p := range parts {
+		// Parts left without a day are placed like any other course
+		p.ReservedDay = -1
+		if i < len(freeDays) {
+			p.ReservedDay = freeDays[i]
+		}
+	}
+}
+
+// Check if another part of a split course is already placed on given day
+func partPlacedOn(course *model.Course, dayOfWeek int, coursesByID map[model.CourseID]*model.Course) bool {
+	for _, id := range course.SiblingIDs {
+		if id == course.CourseID {
+			continue
+		}
+		if part, ok := coursesByID[id]; ok && part.Placed && part.PlacedDay == dayOfWeek {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+func TestAssignPartDays(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []int
+		days      int
+		busyDays  []int
+		reserved  map[int]int // Day of each locked part by index
+		assigned  int         // Parts that should get a day
+	}{
+		{"two parts", []int{2, 1}, 5, nil, nil, 2},
+		{"three parts", []int{2, 1, 1}, 5, nil, nil, 3},
+		{"short week", []int{1, 1, 1, 1}, 3, nil, nil, 3},
+		{"busy days are skipped", []int{1, 1, 1}, 5, []int{0, 2}, nil, 3},
+		{"busy week", []int{1, 1, 1}, 5, []int{0, 1, 2}, nil, 2},
+		{"locked part keeps its day", []int{2, 1, 1}, 4, nil, map[int]int{1: 3}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := int64(0); seed < 20; seed++ {
+				parts := []*model.Course{}
+				for i, d := range tt.durations {
+					p := &model.Course{CourseID: model.CourseID(i + 1), Course_Code: "CENG101", Duration: d, NeededSlots: d,
+						HasBeenSplit: true, ParentID: 1, PartIndex: i, BusyDays: tt.busyDays}
+					if day, found := tt.reserved[i]; found {
+						p.Reserved, p.ReservedDay = true, day
+					}
+					parts = append(parts, p)
+				}
+
+				assignPartDays(parts, tt.days, rand.New(rand.NewSource(seed)))
+
+				days := map[int]bool{}
+				assigned := 0
+				for i, p := range parts {
+					if p.Reserved {
+						if p.ReservedDay != tt.reserved[i] {
+							t.Fatalf("locked part moved to day %d", p.ReservedDay)
+						}
+						days[p.ReservedDay] = true
+						continue
+					}
+					if p.ReservedDay < 0 {
+						continue
+					}
+					assigned++
+					if p.ReservedDay >= tt.days {
+						t.Fatalf("part on day %d of a %d day week", p.ReservedDay, tt.days)
+					}
+					if slices.Contains(tt.busyDays, p.ReservedDay) {
+						t.Fatalf("part on busy day %d", p.ReservedDay)
+					}
+					if days[p.ReservedDay] {
+						t.Fatalf("two parts on day %d", p.ReservedDay)
+					}
+					days[p.ReservedDay] = true
+					// Bigger parts come earlier in the week
+					for _, other := range parts {
+						if !other.Reserved && other.ReservedDay > p.ReservedDay && other.Duration > p.Duration {
+							t.Fatalf("part of %d slots on day %d after a part of %d slots on day %d", other.Duration, other.ReservedDay, p.Duration, p.ReservedDay)
+						}
+					}
+				}
+				if assigned != tt.assigned {
+					t.Fatalf("seed %d gave %d parts a day, want %d", seed, assigned, tt.assigned)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateStopsWhenCancelled(t *testing.T) {
+	cfg := NewDefaultConfiguration()
+	cfg.Seed = 1
+	checks := 0
+	cfg.Cancelled = func() bool {
+		checks++
+		return checks == 3
+	}
+	// No classroom fits the course, so no iteration finds a valid schedule
+	course := &model.Course{CourseID: 1, Course_Code: "CENG101", DisplayName: "CENG101", Department: "CENG", Class: 1,
+		Number_of_Students: 500, Duration: 120, NeedsRoom: true}
+	rooms := []*model.Classroom{{ID: "R1", Capacity: 40, AvailabilityArray: []int{0, 1, 2, 3, 4}}}
+
+	result := Generate(cfg, []*model.Course{course}, nil, nil, rooms, nil, map[string]int{})
+
+	if result.Iteration != 3 {
+		t.Errorf("stopped at iteration %d, want 3", result.Iteration)
+	}
+	if result.Schedule == nil {
+		t.Error("cancelled run has no schedule")
+	}
+}
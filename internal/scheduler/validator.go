@@ -2,136 +2,332 @@ package scheduler
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/rhyrak/go-schedule/pkg/model"
 )
 
-// Validate checks schedule for conflicts and unassigned courses.
-// Returns false and a message for invalid schedules.
-func Validate(courses []*model.Course, labs []*model.Laboratory, schedule *model.Schedule, rooms []*model.Classroom, congestedDepartments map[string]int, CongestionLimit int) ([]*model.Course, bool, bool, string, int) {
-	var message string
-	var valid bool = true
-	var allAssigned bool
-	var hasCourseCollision bool
-	var hasClassroomCollision bool
+// Kinds of violations found by the built-in checks
+const (
+	ViolationUnassigned         = "unassigned"          // Course didn't get a time slot or classroom
+	ViolationCourseCollision    = "course_collision"    // Conflicting courses placed at the same time
+	ViolationClassroomCollision = "classroom_collision" // Classroom assigned to courses at the same time
+	ViolationLecturerLoad       = "lecturer_load"       // Lecturer limit exceeded
+	ViolationRoomStability      = "room_stability"      // Sessions of a hard room stability course in different classrooms
+)
+
+// Severities of violations, only errors fail a check
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ViolationCourse is a course involved in a violation.
+type ViolationCourse struct {
+	CourseID   model.CourseID `json:"course_id"`
+	CourseCode string         `json:"course_code"` // Display name, with the part of split courses
+	Department string         `json:"department"`
+	Grade      int            `json:"grade"`
+	Section    int            `json:"section"`
+	Lecturer   string         `json:"lecturer"`
+}
+
+// Violation is a broken constraint of a schedule. Day and slot are -1 when it isn't tied
+// to a day or time slot, room and lecturer are empty when it isn't tied to one.
+type Violation struct {
+	Kind     string             `json:"kind"`
+	Severity string             `json:"severity"`
+	Message  string             `json:"message"`
+	Courses  []*ViolationCourse `json:"courses"`
+	Day      int                `json:"day"` // Day of the week, 0 is Monday
+	Slot     int                `json:"slot"`
+	Room     string             `json:"room"`
+	Lecturer string             `json:"lecturer"`
+}
+
+// Check is the outcome of one validation rule with its violations.
+type Check struct {
+	Name       string       `json:"name"`
+	Passed     bool         `json:"passed"` // No violation is an error
+	Violations []*Violation `json:"violations"`
+}
+
+// UnassignedCourse is a course or laboratory left out of the schedule.
+type UnassignedCourse struct {
+	CourseCode string `json:"course_code"`
+	Department string `json:"department"`
+	Students   int    `json:"students"`
+	Lecturer   string `json:"lecturer"`
+	Compulsory bool   `json:"compulsory"`
+	Laboratory bool   `json:"laboratory"`
+}
+
+// Validation holds the checks of a schedule and its unassigned courses.
+type Validation struct {
+	Valid           bool                `json:"valid"`
+	SufficientRooms bool                `json:"sufficient_rooms"` // Every course got a time slot and classroom
+	Unassigned      []*UnassignedCourse `json:"unassigned"`
+	Checks          []*Check            `json:"checks"`
+}
+
+// Rule checks a schedule and returns its violations. Rules must not change the schedule,
+// the courses or the laboratories.
+type Rule func(schedule *model.Schedule, courses []*model.Course, labs []*model.Laboratory) []*Violation
+
+type namedRule struct {
+	name  string
+	check Rule
+}
+
+// Validator runs its rules on schedules, the built-in checks first.
+type Validator struct {
+	rules []*namedRule
+}
+
+// NewValidator returns a validator with the built-in checks: unassigned courses, course
+// and classroom collisions, lecturer load and room stability.
+func NewValidator() *Validator {
+	v := &Validator{}
+	v.AddRule("Course has classroom", checkUnassigned)
+	v.AddRule("Course collision", checkCourseCollision)
+	v.AddRule("Classroom collision", checkClassroomCollision)
+	v.AddRule("Lecturer load", checkLecturerLoad)
+	v.AddRule("Room stability", checkRoomStability)
+	return v
+}
+
+// AddRule registers a rule run after the rules added before it. Its check is named name
+// in the validation.
+func (v *Validator) AddRule(name string, rule Rule) {
+	v.rules = append(v.rules, &namedRule{name: name, check: rule})
+}
+
+// Validate runs the rules on schedule. A schedule is valid if no rule found an error.
+func (v *Validator) Validate(courses []*model.Course, labs []*model.Laboratory, schedule *model.Schedule) *Validation {
+	validation := &Validation{Valid: true, SufficientRooms: true, Unassigned: []*UnassignedCourse{}, Checks: []*Check{}}
 
 	// Find and store unassigned courses
-	unassignedCount := 0
-	var unassignedCourses []*model.Course
-	var unassignedLabs []*model.Laboratory
 	for _, c := range courses {
 		if !c.Placed {
-			unassignedCount++
-			unassignedCourses = append(unassignedCourses, c)
+			validation.Unassigned = append(validation.Unassigned, &UnassignedCourse{CourseCode: c.Course_Code, Department: c.Department,
+				Students: c.Number_of_Students, Lecturer: c.Lecturer, Compulsory: c.Compulsory})
 		}
 	}
-
 	for _, l := range labs {
 		if !l.Placed {
-			unassignedCount++
-			unassignedLabs = append(unassignedLabs, l)
+			validation.Unassigned = append(validation.Unassigned, &UnassignedCourse{CourseCode: l.Course_Code, Department: l.Department,
+				Students: l.Number_of_Students, Lecturer: l.Lecturer, Compulsory: l.Compulsory, Laboratory: true})
 		}
 	}
+	validation.SufficientRooms = len(validation.Unassigned) == 0
 
-	// Display error message if any course remains unassigned
-	if unassignedCount > 0 {
-		message = fmt.Sprintf("- There are %d unassigned courses:\n", unassignedCount)
-		for _, un := range unassignedCourses {
-			var ce string = "Compulsory"
-			if !un.Compulsory {
-				ce = "Elective"
+	for _, r := range v.rules {
+		check := &Check{Name: r.name, Passed: true, Violations: []*Violation{}}
+		for _, violation := range r.check(schedule, courses, labs) {
+			if violation.Courses == nil {
+				violation.Courses = []*ViolationCourse{}
 			}
-			message += fmt.Sprintf("IN-CLASS    %s %s %s %d %s\n", ce, un.Course_Code, un.Department, un.Number_of_Students, un.Lecturer)
-		}
-		for _, un := range unassignedLabs {
-			var ce string = "Compulsory"
-			if !un.Compulsory {
-				ce = "Elective"
+			check.Violations = append(check.Violations, violation)
+			if violation.Severity != SeverityWarning {
+				check.Passed = false
 			}
-			message += fmt.Sprintf("LABORATORY    %s %s %s %d %s\n", ce, un.Course_Code, un.Department, un.Number_of_Students, un.Lecturer)
 		}
-		message += "\n"
+		validation.Checks = append(validation.Checks, check)
+		validation.Valid = validation.Valid && check.Passed
 	}
+	return validation
+}
 
-	allAssigned = unassignedCount == 0
-
-	// Check for course conflict
-	ok, msg := checkCourseCollision(schedule)
-	hasCourseCollision = !ok
-	message += msg
+// ValidateSchedule checks schedule with the built-in checks of NewValidator.
+func ValidateSchedule(courses []*model.Course, labs []*model.Laboratory, schedule *model.Schedule) *Validation {
+	return NewValidator().Validate(courses, labs, schedule)
+}
 
-	// Check for classroom conflict
-	ok, msg = checkClassroomCollision(schedule)
-	hasClassroomCollision = !ok
-	message += msg
+// Violations lists the violations of every check.
+func (v *Validation) Violations() []*Violation {
+	violations := []*Violation{}
+	for _, c := range v.Checks {
+		violations = append(violations, c.Violations...)
+	}
+	return violations
+}
 
-	var sufficientRooms bool = true
-	message = "\n" + message
+// Message formats the checks and their violations, unassigned courses first.
+func (v *Validation) Message() string {
+	var sb strings.Builder
+	for _, c := range v.Checks {
+		if c.Passed {
+			sb.WriteString("[  OK]: " + c.Name + " check.\n")
+		} else {
+			sb.WriteString("[FAIL]: " + c.Name + " check.\n")
+		}
+	}
+	sb.WriteString("\n")
+	if len(v.Unassigned) > 0 {
+		sb.WriteString(fmt.Sprintf("- There are %d unassigned courses:\n", len(v.Unassigned)))
+		for _, un := range v.Unassigned {
+			sb.WriteString(un.String() + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	for _, violation := range v.Violations() {
+		if violation.Kind == ViolationUnassigned {
+			continue
+		}
+		sb.WriteString("- " + violation.Message + "\n")
+	}
+	return sb.String()
+}
 
-	// Display messages accordingly
-	if hasClassroomCollision {
-		message = "[FAIL]: Classroom collision check.\n" + message
-		valid = false
-	} else {
-		message = "[  OK]: Classroom collision check.\n" + message
+func (un *UnassignedCourse) String() string {
+	kind, ce := "IN-CLASS", "Compulsory"
+	if un.Laboratory {
+		kind = "LABORATORY"
 	}
-	if hasCourseCollision {
-		message = "[FAIL]: Course collision check.\n" + message
-		valid = false
-	} else {
-		message = "[  OK]: Course collision check.\n" + message
+	if !un.Compulsory {
+		ce = "Elective"
 	}
-	if !allAssigned {
-		message = "[FAIL]: Course has classroom check.\n" + message
-		valid = false
-		sufficientRooms = false
-	} else {
-		message = "[  OK]: Course has classroom check.\n" + message
+	return fmt.Sprintf("%s    %s %s %s %d %s", kind, ce, un.CourseCode, un.Department, un.Students, un.Lecturer)
+}
+
+func (c *ViolationCourse) String() string {
+	return fmt.Sprintf("%s %s %d", c.CourseCode, c.Department, c.Grade)
+}
+
+// Error violation of given kind not tied to a day, time slot, classroom or lecturer
+func newViolation(kind string, message string, courses ...*model.Course) *Violation {
+	violation := &Violation{Kind: kind, Severity: SeverityError, Message: message, Courses: []*ViolationCourse{}, Day: -1, Slot: -1}
+	for _, c := range courses {
+		violation.Courses = append(violation.Courses, &ViolationCourse{CourseID: c.CourseID, CourseCode: c.DisplayName,
+			Department: c.Department, Grade: c.Class, Section: c.Section, Lecturer: c.Lecturer})
 	}
+	return violation
+}
 
-	return unassignedCourses, valid, sufficientRooms, message, unassignedCount
+// Day and starting time of a time slot, e.g. Monday 08:30
+func slotName(schedule *model.Schedule, dayOfWeek int, slot int) string {
+	start := model.FirstSlotStart + slot*schedule.TimeSlotDuration
+	return fmt.Sprintf("%s %0.2d:%0.2d", model.DayName(dayOfWeek), start/60, start%60)
 }
 
-func checkCourseCollision(schedule *model.Schedule) (bool, string) {
-	valid := true
-	message := ""
+func checkUnassigned(schedule *model.Schedule, courses []*model.Course, labs []*model.Laboratory) []*Violation {
+	violations := []*Violation{}
+	for _, c := range courses {
+		if !c.Placed {
+			un := &UnassignedCourse{CourseCode: c.Course_Code, Department: c.Department, Students: c.Number_of_Students,
+				Lecturer: c.Lecturer, Compulsory: c.Compulsory}
+			violation := newViolation(ViolationUnassigned, un.String(), c)
+			violation.Lecturer = c.Lecturer
+			violations = append(violations, violation)
+		}
+	}
+	for _, l := range labs {
+		if !l.Placed {
+			un := &UnassignedCourse{CourseCode: l.Course_Code, Department: l.Department, Students: l.Number_of_Students,
+				Lecturer: l.Lecturer, Compulsory: l.Compulsory, Laboratory: true}
+			violation := newViolation(ViolationUnassigned, un.String())
+			violation.Courses = append(violation.Courses, &ViolationCourse{CourseID: l.CourseID, CourseCode: l.DisplayName, Department: l.Department,
+				Grade: l.Class, Section: l.Section, Lecturer: l.Lecturer})
+			violation.Lecturer = l.Lecturer
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+func checkCourseCollision(schedule *model.Schedule, courses []*model.Course, labs []*model.Laboratory) []*Violation {
+	violations := []*Violation{}
 	for _, day := range schedule.Days {
-		for _, slot := range day.Slots {
+		for s, slot := range day.Slots {
+			// Courses conflicting both ways are reported once
+			reported := map[[2]model.CourseID]bool{}
 			for _, c1 := range slot.CourseRefs {
-				for _, c2 := range slot.Courses {
-					if contains(c1.ConflictingCourses, c2) && !c1.ServiceCourse {
-						valid = false
-						message += "Conflicting courses placed at the same time\n"
+				if c1.ServiceCourse {
+					continue
+				}
+				for _, c2 := range slot.CourseRefs {
+					pair := [2]model.CourseID{min(c1.CourseID, c2.CourseID), max(c1.CourseID, c2.CourseID)}
+					if !contains(c1.ConflictingCourses, c2.CourseID) || reported[pair] {
+						continue
 					}
+					reported[pair] = true
+					violation := newViolation(ViolationCourseCollision, fmt.Sprintf("Conflicting courses %s %s %d and %s %s %d placed at the same time on %s",
+						c1.DisplayName, c1.Department, c1.Class, c2.DisplayName, c2.Department, c2.Class, slotName(schedule, day.DayOfWeek, s)), c1, c2)
+					violation.Day, violation.Slot = day.DayOfWeek, s
+					violations = append(violations, violation)
 				}
 			}
 		}
 	}
-	return valid, message
+	return violations
 }
 
-func checkClassroomCollision(schedule *model.Schedule) (bool, string) {
-	valid := true
-	message := ""
+func checkClassroomCollision(schedule *model.Schedule, courses []*model.Course, labs []*model.Laboratory) []*Violation {
+	violations := []*Violation{}
 	for _, day := range schedule.Days {
-		for _, slot := range day.Slots {
-			var usedRooms map[string]bool = make(map[string]bool)
+		for s, slot := range day.Slots {
+			var usedRooms map[string]*model.Course = make(map[string]*model.Course)
 			for _, c := range slot.CourseRefs {
 				if c.Classroom == nil {
 					continue
 				}
-				_, usedBefore := usedRooms[c.Classroom.ID]
+				other, usedBefore := usedRooms[c.Classroom.ID]
 				if usedBefore {
-					schedule.Cost++
-					valid = false
-					message += "- Classroom " + c.Classroom.ID + " assigned multiple times\n"
+					violation := newViolation(ViolationClassroomCollision, fmt.Sprintf("Classroom %s assigned to %s %s %d and %s %s %d on %s",
+						c.Classroom.ID, other.DisplayName, other.Department, other.Class, c.DisplayName, c.Department, c.Class, slotName(schedule, day.DayOfWeek, s)), other, c)
+					violation.Day, violation.Slot, violation.Room = day.DayOfWeek, s, c.Classroom.ID
+					violations = append(violations, violation)
 				} else {
-					usedRooms[c.Classroom.ID] = true
+					usedRooms[c.Classroom.ID] = c
+				}
+			}
+		}
+	}
+	return violations
+}
+
+func checkLecturerLoad(schedule *model.Schedule, courses []*model.Course, labs []*model.Laboratory) []*Violation {
+	violations := []*Violation{}
+	sessions := countLecturerSessions(courses, labs)
+	checked := map[string]bool{}
+	for _, day := range schedule.Days {
+		for _, slot := range day.Slots {
+			for _, c := range slot.CourseRefs {
+				if c.Limits == nil || c.Lecturer == "" || checked[c.Lecturer] {
+					continue
+				}
+				checked[c.Lecturer] = true
+				violations = append(violations, lecturerViolations(schedule, c.Lecturer, c.Limits, sessions[c.Lecturer])...)
+			}
+		}
+	}
+	return violations
+}
+
+func checkRoomStability(schedule *model.Schedule, courses []*model.Course, labs []*model.Laboratory) []*Violation {
+	violations := []*Violation{}
+	sessionRooms := map[string]string{}
+	reported := map[string]bool{}
+	for _, day := range schedule.Days {
+		for s, slot := range day.Slots {
+			for _, c := range slot.CourseRefs {
+				if c.Classroom == nil || !isHardRoomStability(c) {
+					continue
+				}
+				key := fmt.Sprintf("%s %s %d", c.Department, c.Course_Code, c.Section)
+				room, seen := sessionRooms[key]
+				if !seen {
+					sessionRooms[key] = c.Classroom.ID
+				} else if room != c.Classroom.ID && !reported[key+c.Classroom.ID] {
+					reported[key+c.Classroom.ID] = true
+					violation := newViolation(ViolationRoomStability, "Sessions of "+key+" placed in classrooms "+room+" and "+c.Classroom.ID, c)
+					violation.Day, violation.Slot, violation.Room = day.DayOfWeek, s, c.Classroom.ID
+					violations = append(violations, violation)
 				}
 			}
 		}
 	}
-	return valid, message
+	return violations
 }
 
 func contains(s []model.CourseID, e model.CourseID) bool {
@@ -0,0 +1,199 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Two courses of different cohorts in their own classrooms, placed by the cases
+func validationFixture() (*model.Schedule, []*model.Course, []*model.Classroom) {
+	schedule := model.NewSchedule(5, 60, 9)
+	rooms := []*model.Classroom{newRoom("R1", 50, 0, 5, 9), newRoom("R2", 50, 0, 5, 9)}
+	a := newCourse(1, "CENG101", 30, 1)
+	b := newCourse(2, "CENG201", 30, 1)
+	a.Lecturer, b.Lecturer = "Lect1", "Lect2"
+	b.Class = 2
+	return schedule, []*model.Course{a, b}, rooms
+}
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		place   func(schedule *model.Schedule, courses []*model.Course, rooms []*model.Classroom)
+		failing string // Name of the failing check, empty if the schedule is valid
+		kind    string
+		count   int // Violations of the failing check
+	}{
+		{"valid", func(schedule *model.Schedule, courses []*model.Course, rooms []*model.Classroom) {
+			PlaceCourse(courses[0], schedule.Days[0], 0, rooms[0])
+			PlaceCourse(courses[1], schedule.Days[0], 0, rooms[1])
+		}, "", "", 0},
+		{"unassigned", func(schedule *model.Schedule, courses []*model.Course, rooms []*model.Classroom) {
+			PlaceCourse(courses[0], schedule.Days[0], 0, rooms[0])
+		}, "Course has classroom", ViolationUnassigned, 1},
+		{"course collision reported once", func(schedule *model.Schedule, courses []*model.Course, rooms []*model.Classroom) {
+			courses[0].ConflictingCourses = []model.CourseID{2}
+			courses[1].ConflictingCourses = []model.CourseID{1}
+			PlaceCourse(courses[0], schedule.Days[1], 3, rooms[0])
+			PlaceCourse(courses[1], schedule.Days[1], 3, rooms[1])
+		}, "Course collision", ViolationCourseCollision, 1},
+		{"service courses don't collide", func(schedule *model.Schedule, courses []*model.Course, rooms []*model.Classroom) {
+			courses[0].ConflictingCourses = []model.CourseID{2}
+			courses[0].ServiceCourse = true
+			PlaceCourse(courses[0], schedule.Days[1], 3, rooms[0])
+			PlaceCourse(courses[1], schedule.Days[1], 3, rooms[1])
+		}, "", "", 0},
+		{"classroom collision", func(schedule *model.Schedule, courses []*model.Course, rooms []*model.Classroom) {
+			PlaceCourse(courses[0], schedule.Days[2], 0, rooms[0])
+			PlaceCourse(courses[1], schedule.Days[2], 0, rooms[0])
+		}, "Classroom collision", ViolationClassroomCollision, 1},
+		{"lecturer daily hours", func(schedule *model.Schedule, courses []*model.Course, rooms []*model.Classroom) {
+			courses[1].Lecturer = "Lect1"
+			courses[0].Limits = &model.LecturerLimit{Lecturer: "Lect1", MaxDailyHours: 1}
+			courses[1].Limits = courses[0].Limits
+			PlaceCourse(courses[0], schedule.Days[0], 0, rooms[0])
+			PlaceCourse(courses[1], schedule.Days[0], 4, rooms[1])
+		}, "Lecturer load", ViolationLecturerLoad, 1},
+		{"room stability", func(schedule *model.Schedule, courses []*model.Course, rooms []*model.Classroom) {
+			courses[1].Course_Code, courses[1].Class = courses[0].Course_Code, courses[0].Class
+			courses[0].RoomStability, courses[1].RoomStability = "hard", "hard"
+			PlaceCourse(courses[0], schedule.Days[0], 0, rooms[0])
+			PlaceCourse(courses[1], schedule.Days[3], 0, rooms[1])
+		}, "Room stability", ViolationRoomStability, 1},
+		{"soft room stability", func(schedule *model.Schedule, courses []*model.Course, rooms []*model.Classroom) {
+			courses[1].Course_Code, courses[1].Class = courses[0].Course_Code, courses[0].Class
+			PlaceCourse(courses[0], schedule.Days[0], 0, rooms[0])
+			PlaceCourse(courses[1], schedule.Days[3], 0, rooms[1])
+		}, "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, courses, rooms := validationFixture()
+			tt.place(schedule, courses, rooms)
+
+			validation := ValidateSchedule(courses, nil, schedule)
+
+			if validation.Valid != (tt.failing == "") {
+				t.Errorf("valid is %t:\n%s", validation.Valid, validation.Message())
+			}
+			if len(validation.Checks) != 5 {
+				t.Fatalf("got %d checks, want the 5 built-in ones", len(validation.Checks))
+			}
+			for _, check := range validation.Checks {
+				if check.Name != tt.failing {
+					if !check.Passed || len(check.Violations) != 0 {
+						t.Errorf("%s check failed with %d violations", check.Name, len(check.Violations))
+					}
+					continue
+				}
+				if check.Passed || len(check.Violations) != tt.count {
+					t.Errorf("%s check passed %t with %d violations, want %d", check.Name, check.Passed, len(check.Violations), tt.count)
+				}
+				for _, violation := range check.Violations {
+					if violation.Kind != tt.kind || violation.Severity != SeverityError {
+						t.Errorf("got %s %s violation, want %s error", violation.Severity, violation.Kind, tt.kind)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestValidatorViolationDetails(t *testing.T) {
+	schedule, courses, rooms := validationFixture()
+	PlaceCourse(courses[0], schedule.Days[2], 4, rooms[0])
+	PlaceCourse(courses[1], schedule.Days[2], 4, rooms[0])
+
+	violations := ValidateSchedule(courses, nil, schedule).Violations()
+	if len(violations) != 1 {
+		t.Fatalf("got %d violations, want 1", len(violations))
+	}
+	v := violations[0]
+	if v.Day != 2 || v.Slot != 4 || v.Room != "R1" || len(v.Courses) != 2 {
+		t.Errorf("got violation on day %d slot %d in %s with %d courses, want day 2 slot 4 in R1 with 2 courses", v.Day, v.Slot, v.Room, len(v.Courses))
+	}
+	if !strings.Contains(v.Message, "Wednesday 12:30") {
+		t.Errorf("message %q doesn't name the time slot", v.Message)
+	}
+}
+
+func TestValidatorLecturerDaysOutsideWeek(t *testing.T) {
+	// Lecturer checks name days past Friday by index instead of panicking or wrapping
+	schedule := model.NewSchedule(6, 60, 9)
+	rooms := []*model.Classroom{newRoom("R1", 50, 0, 6, 9)}
+	limits := &model.LecturerLimit{Lecturer: "Lect1", NoEarlyAfterLate: true}
+	late := newCourse(1, "CENG101", 30, 1)
+	early := newCourse(2, "CENG201", 30, 1)
+	late.Lecturer, late.Limits = "Lect1", limits
+	early.Lecturer, early.Limits = "Lect1", limits
+	PlaceCourse(late, schedule.Days[4], 8, rooms[0])
+	PlaceCourse(early, schedule.Days[5], 0, rooms[0])
+
+	violations := ValidateSchedule([]*model.Course{late, early}, nil, schedule).Violations()
+	if len(violations) != 1 || violations[0].Kind != ViolationLecturerLoad || violations[0].Day != 5 {
+		t.Fatalf("got %d violations, want a lecturer load violation on day 5", len(violations))
+	}
+	if !strings.Contains(violations[0].Message, "on Day(5) after finishing late on Friday") {
+		t.Errorf("got message %q", violations[0].Message)
+	}
+}
+
+func TestValidatorAddRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		severity string
+		valid    bool
+	}{
+		{"warning", SeverityWarning, true},
+		{"error", SeverityError, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, courses, rooms := validationFixture()
+			PlaceCourse(courses[0], schedule.Days[0], 0, rooms[0])
+			PlaceCourse(courses[1], schedule.Days[0], 0, rooms[1])
+
+			validator := NewValidator()
+			validator.AddRule("Morning courses", func(schedule *model.Schedule, courses []*model.Course, labs []*model.Laboratory) []*Violation {
+				violation := &Violation{Kind: "morning", Severity: tt.severity, Message: "course at 08:30", Day: 0, Slot: 0}
+				return []*Violation{violation}
+			})
+			validation := validator.Validate(courses, nil, schedule)
+
+			if validation.Valid != tt.valid {
+				t.Errorf("valid is %t, want %t", validation.Valid, tt.valid)
+			}
+			last := validation.Checks[len(validation.Checks)-1]
+			if len(validation.Checks) != 6 || last.Name != "Morning courses" {
+				t.Fatalf("custom rule isn't the last of %d checks", len(validation.Checks))
+			}
+			if last.Passed != tt.valid || len(last.Violations) != 1 || last.Violations[0].Courses == nil {
+				t.Errorf("got check %+v", *last)
+			}
+		})
+	}
+}
+
+func TestValidatorMinLecturerDaysIsWarning(t *testing.T) {
+	schedule, courses, rooms := validationFixture()
+	courses[1].Lecturer = "Lect1"
+	courses[0].Limits = &model.LecturerLimit{Lecturer: "Lect1", MinDays: 2}
+	courses[1].Limits = courses[0].Limits
+	PlaceCourse(courses[0], schedule.Days[0], 0, rooms[0])
+	PlaceCourse(courses[1], schedule.Days[0], 2, rooms[0])
+
+	validation := ValidateSchedule(courses, nil, schedule)
+
+	if !validation.Valid {
+		t.Errorf("schedule below the minimum teaching days is invalid:\n%s", validation.Message())
+	}
+	violations := validation.Violations()
+	if len(violations) != 1 || violations[0].Severity != SeverityWarning || violations[0].Kind != ViolationLecturerLoad {
+		t.Fatalf("got %d violations, want a lecturer load warning", len(violations))
+	}
+	if want := "Lecturer Lect1 teaches on 1 days, minimum is 2"; violations[0].Message != want {
+		t.Errorf("got %q, want %q", violations[0].Message, want)
+	}
+}
@@ -0,0 +1,580 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Filesystem stores every record as a file under a directory:
+//
+//	schedules/<id>.json           schedule with its data
+//	history/<schedule id>.json    confirmed edits with the data before them
+//	jobs/<schedule id>.json
+//	projects/<id>.json
+//	inputs/<version id>/version.json and the data of its files as inputs/<version id>/<kind>
+//
+// Files are replaced by renaming, a directory should only be used by one server.
+type Filesystem struct {
+	mu  sync.Mutex
+	dir string
+}
+
+var _ ScheduleStore = (*Filesystem)(nil)
+
+// OpenFilesystem opens the store in the directory, creating it if needed.
+func OpenFilesystem(dir string) (*Filesystem, error) {
+	for _, sub := range []string{"schedules", "history", "jobs", "projects", "inputs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &Filesystem{dir: dir}, nil
+}
+
+func (f *Filesystem) Close() error {
+	return nil
+}
+
+func (f *Filesystem) ListSchedules(projectID int64) ([]*Schedule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names, err := f.names("schedules")
+	if err != nil {
+		return nil, err
+	}
+	schedules := []*Schedule{}
+	for _, name := range names {
+		sc := &Schedule{}
+		if err = f.read(filepath.Join("schedules", name), sc); err != nil {
+			return nil, err
+		}
+		if projectID == 0 || sc.ProjectID == projectID {
+			schedules = append(schedules, &Schedule{ID: sc.ID, Status: sc.Status, Report: sc.Report, ProjectID: sc.ProjectID, VersionID: sc.VersionID})
+		}
+	}
+	sortSchedules(schedules)
+	return schedules, nil
+}
+
+func (f *Filesystem) GetSchedule(id string) (*Schedule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.schedule(id)
+}
+
+func (f *Filesystem) CreateSchedule(sc *Schedule) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !validName(sc.ID) {
+		return fmt.Errorf("invalid schedule id %s", sc.ID)
+	}
+	if _, err := f.schedule(sc.ID); err == nil {
+		return fmt.Errorf("schedule %s already exists", sc.ID)
+	}
+	return f.write(filepath.Join("schedules", sc.ID+".json"), sc)
+}
+
+func (f *Filesystem) FinishSchedule(id string, data string, status string, report string) error {
+	return f.update(id, func(sc *Schedule) {
+		sc.Data, sc.Status, sc.Report = data, status, report
+	})
+}
+
+func (f *Filesystem) SetScheduleStatus(id string, status string) error {
+	return f.update(id, func(sc *Schedule) {
+		sc.Status = status
+	})
+}
+
+func (f *Filesystem) SetScheduleLocks(id string, locks string) error {
+	return f.update(id, func(sc *Schedule) {
+		sc.Locks = locks
+	})
+}
+
+func (f *Filesystem) SetScheduleConfig(id string, config string) error {
+	return f.update(id, func(sc *Schedule) {
+		sc.Config = config
+	})
+}
+
+func (f *Filesystem) DeleteSchedule(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.schedule(id); err != nil {
+		return err
+	}
+	if err := os.Remove(f.path("schedules", id+".json")); err != nil {
+		return err
+	}
+	for _, sub := range []string{"history", "jobs"} {
+		if err := os.Remove(f.path(sub, id+".json")); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Filesystem) CreateJob(job *Job) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !validName(job.ScheduleID) {
+		return fmt.Errorf("invalid schedule id %s", job.ScheduleID)
+	}
+	jobs, err := f.jobs()
+	if err != nil {
+		return err
+	}
+	job.ID = 1
+	for _, j := range jobs {
+		if j.ScheduleID == job.ScheduleID {
+			return fmt.Errorf("job of schedule %s already exists", job.ScheduleID)
+		}
+		job.ID = max(job.ID, j.ID+1)
+	}
+	job.Created = time.Now().Unix()
+	job.Updated = job.Created
+	return f.writeJob(job)
+}
+
+func (f *Filesystem) GetJob(scheduleID string) (*Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.job(scheduleID)
+}
+
+func (f *Filesystem) ListJobs(state string) ([]*Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	jobs, err := f.jobs()
+	if err != nil {
+		return nil, err
+	}
+	listed := []*Job{}
+	for _, job := range jobs {
+		if state == "" || job.State == state {
+			listed = append(listed, job)
+		}
+	}
+	return listed, nil
+}
+
+func (f *Filesystem) ClaimJob() (*Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	jobs, err := f.jobs()
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.State == JobQueued {
+			job.State = JobRunning
+			job.Attempts++
+			job.Updated = time.Now().Unix()
+			return job, f.writeJob(job)
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (f *Filesystem) UpdateJob(scheduleID string, state string, errorMessage string, from ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	job, err := f.job(scheduleID)
+	if err != nil {
+		return err
+	}
+	if len(from) > 0 && !slices.Contains(from, job.State) {
+		return ErrNotFound
+	}
+	job.State, job.Error, job.Updated = state, errorMessage, time.Now().Unix()
+	return f.writeJob(job)
+}
+
+func (f *Filesystem) CommitEdit(scheduleID string, previous string, data string, action string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sc, err := f.schedule(scheduleID)
+	if err != nil {
+		return err
+	}
+	records, err := f.history(scheduleID)
+	if err != nil {
+		return err
+	}
+	var lastID int64
+	if len(records) > 0 {
+		lastID = records[len(records)-1].ID
+	}
+	records = append(records, &historyRecord{
+		HistoryEntry: HistoryEntry{ID: lastID + 1, Action: action, Created: time.Now().Unix()},
+		Data:         previous,
+	})
+	if err = f.write(filepath.Join("history", scheduleID+".json"), records); err != nil {
+		return err
+	}
+	sc.Data = data
+	return f.write(filepath.Join("schedules", scheduleID+".json"), sc)
+}
+
+func (f *Filesystem) UndoEdit(scheduleID string) (*HistoryEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sc, err := f.schedule(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	records, err := f.history(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrNotFound
+	}
+	last := records[len(records)-1]
+	sc.Data = last.Data
+	if err = f.write(filepath.Join("schedules", scheduleID+".json"), sc); err != nil {
+		return nil, err
+	}
+	if err = f.write(filepath.Join("history", scheduleID+".json"), records[:len(records)-1]); err != nil {
+		return nil, err
+	}
+	entry := last.HistoryEntry
+	return &entry, nil
+}
+
+func (f *Filesystem) ListHistory(scheduleID string) ([]*HistoryEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !validName(scheduleID) {
+		return []*HistoryEntry{}, nil
+	}
+	records, err := f.history(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	return historyEntries(records), nil
+}
+
+func (f *Filesystem) ListProjects() ([]*Project, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.projects()
+}
+
+func (f *Filesystem) GetProject(id int64) (*Project, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := &Project{}
+	if err := f.read(filepath.Join("projects", strconv.FormatInt(id, 10)+".json"), p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (f *Filesystem) FindProject(name string) (*Project, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	projects, err := f.projects()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (f *Filesystem) CreateProject(name string, semester string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	projects, err := f.projects()
+	if err != nil {
+		return 0, err
+	}
+	var id int64 = 1
+	for _, p := range projects {
+		if p.Name == name {
+			return 0, fmt.Errorf("project %s already exists", name)
+		}
+		id = max(id, p.ID+1)
+	}
+	p := &Project{ID: id, Name: name, Semester: semester, Created: time.Now().Unix()}
+	return id, f.write(filepath.Join("projects", strconv.FormatInt(id, 10)+".json"), p)
+}
+
+func (f *Filesystem) CreateInputVersion(projectID int64, parentID int64, note string, files []*InputFile) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names, err := f.names("inputs")
+	if err != nil {
+		return 0, err
+	}
+	var id int64 = 1
+	for _, name := range names {
+		if n, err := strconv.ParseInt(name, 10, 64); err == nil {
+			id = max(id, n+1)
+		}
+	}
+
+	// The version is written last, a version without it is an interrupted one
+	dir := filepath.Join("inputs", strconv.FormatInt(id, 10))
+	if err = os.MkdirAll(f.path(dir), 0755); err != nil {
+		return 0, err
+	}
+	v := &InputVersion{ID: id, ProjectID: projectID, ParentID: parentID, Note: note, Created: time.Now().Unix(), Files: []*InputFile{}}
+	for _, file := range files {
+		if !validName(file.Kind) {
+			return 0, fmt.Errorf("invalid input kind %s", file.Kind)
+		}
+		if err = os.WriteFile(f.path(dir, file.Kind), file.Data, 0644); err != nil {
+			return 0, err
+		}
+		v.Files = append(v.Files, &InputFile{Kind: file.Kind, Filename: file.Filename, Size: len(file.Data)})
+	}
+	sortFiles(v.Files)
+	return id, f.write(filepath.Join(dir, "version.json"), v)
+}
+
+func (f *Filesystem) ListInputVersions(projectID int64) ([]*InputVersion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.versions(projectID)
+}
+
+func (f *Filesystem) GetInputVersion(projectID int64, versionID int64) (*InputVersion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, err := f.version(versionID)
+	if err != nil {
+		return nil, err
+	}
+	if v.ProjectID != projectID {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+func (f *Filesystem) LatestInputVersion(projectID int64) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	versions, err := f.versions(projectID)
+	if err != nil || len(versions) == 0 {
+		return 0, err
+	}
+	return versions[len(versions)-1].ID, nil
+}
+
+func (f *Filesystem) InputFiles(versionID int64) ([]*InputFile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, err := f.version(versionID)
+	if err == ErrNotFound {
+		return []*InputFile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range v.Files {
+		if file.Data, err = os.ReadFile(f.path("inputs", strconv.FormatInt(versionID, 10), file.Kind)); err != nil {
+			return nil, err
+		}
+	}
+	return v.Files, nil
+}
+
+func (f *Filesystem) GetInputFile(versionID int64, kind string) (*InputFile, error) {
+	files, err := f.InputFiles(versionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.Kind == kind {
+			return file, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (f *Filesystem) update(id string, change func(sc *Schedule)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sc, err := f.schedule(id)
+	if err != nil {
+		return err
+	}
+	change(sc)
+	return f.write(filepath.Join("schedules", id+".json"), sc)
+}
+
+func (f *Filesystem) schedule(id string) (*Schedule, error) {
+	if !validName(id) {
+		return nil, ErrNotFound
+	}
+	sc := &Schedule{}
+	if err := f.read(filepath.Join("schedules", id+".json"), sc); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+func (f *Filesystem) history(scheduleID string) ([]*historyRecord, error) {
+	records := []*historyRecord{}
+	if err := f.read(filepath.Join("history", scheduleID+".json"), &records); err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (f *Filesystem) job(scheduleID string) (*Job, error) {
+	if !validName(scheduleID) {
+		return nil, ErrNotFound
+	}
+	job := &jobRecord{}
+	if err := f.read(filepath.Join("jobs", scheduleID+".json"), job); err != nil {
+		return nil, err
+	}
+	job.Job.Previous = job.Previous
+	return &job.Job, nil
+}
+
+// Jobs ordered by id
+func (f *Filesystem) jobs() ([]*Job, error) {
+	names, err := f.names("jobs")
+	if err != nil {
+		return nil, err
+	}
+	jobs := []*Job{}
+	for _, name := range names {
+		job, err := f.job(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	sortByID(jobs, func(job *Job) int64 { return job.ID })
+	return jobs, nil
+}
+
+func (f *Filesystem) writeJob(job *Job) error {
+	return f.write(filepath.Join("jobs", job.ScheduleID+".json"), &jobRecord{Job: *job, Previous: job.Previous})
+}
+
+// Projects ordered by id
+func (f *Filesystem) projects() ([]*Project, error) {
+	names, err := f.names("projects")
+	if err != nil {
+		return nil, err
+	}
+	projects := []*Project{}
+	for _, name := range names {
+		p := &Project{}
+		if err = f.read(filepath.Join("projects", name), p); err != nil {
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+	sortByID(projects, func(p *Project) int64 { return p.ID })
+	return projects, nil
+}
+
+// Input versions of the project ordered by id, without data
+func (f *Filesystem) versions(projectID int64) ([]*InputVersion, error) {
+	names, err := f.names("inputs")
+	if err != nil {
+		return nil, err
+	}
+	versions := []*InputVersion{}
+	for _, name := range names {
+		id, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		v, err := f.version(id)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if v.ProjectID == projectID {
+			versions = append(versions, v)
+		}
+	}
+	sortByID(versions, func(v *InputVersion) int64 { return v.ID })
+	return versions, nil
+}
+
+func (f *Filesystem) version(id int64) (*InputVersion, error) {
+	v := &InputVersion{}
+	if err := f.read(filepath.Join("inputs", strconv.FormatInt(id, 10), "version.json"), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Names of the entries of the subdirectory
+func (f *Filesystem) names(sub string) ([]string, error) {
+	entries, err := os.ReadDir(f.path(sub))
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (f *Filesystem) path(elem ...string) string {
+	return filepath.Join(append([]string{f.dir}, elem...)...)
+}
+
+// Decode the JSON file, ErrNotFound if it doesn't exist
+func (f *Filesystem) read(name string, v any) error {
+	data, err := os.ReadFile(f.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Encode v into the file, replacing it at once so readers never see a partial file
+func (f *Filesystem) write(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := f.path(filepath.Dir(name), "."+filepath.Base(name)+".tmp")
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path(name))
+}
+
+// Job with the data of the schedule to repair, which isn't part of its JSON
+type jobRecord struct {
+	Job
+	Previous string `json:"previous"`
+}
+
+// Ids used as file names can't leave their directory
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`) && !strings.HasPrefix(name, ".")
+}
@@ -0,0 +1,381 @@
+package store
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Memory keeps everything in memory until the process exits, for tests and trying out
+// the servers.
+type Memory struct {
+	mu        sync.Mutex
+	schedules map[string]*Schedule
+	history   map[string][]*historyRecord
+	jobs      []*Job
+	projects  []*Project
+	versions  []*InputVersion // Files with their data
+	lastIDs   [4]int64        // Last ids of history entries, projects, input versions and jobs
+}
+
+// Confirmed edit with the data before it
+type historyRecord struct {
+	HistoryEntry
+	Data string `json:"data"`
+}
+
+var _ ScheduleStore = (*Memory)(nil)
+
+// NewMemory returns an empty store.
+func NewMemory() *Memory {
+	return &Memory{schedules: map[string]*Schedule{}, history: map[string][]*historyRecord{}}
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func (m *Memory) ListSchedules(projectID int64) ([]*Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	schedules := []*Schedule{}
+	for _, sc := range m.schedules {
+		if projectID == 0 || sc.ProjectID == projectID {
+			schedules = append(schedules, &Schedule{ID: sc.ID, Status: sc.Status, Report: sc.Report, ProjectID: sc.ProjectID, VersionID: sc.VersionID})
+		}
+	}
+	sortSchedules(schedules)
+	return schedules, nil
+}
+
+func (m *Memory) GetSchedule(id string) (*Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sc, found := m.schedules[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	copied := *sc
+	return &copied, nil
+}
+
+func (m *Memory) CreateSchedule(sc *Schedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, found := m.schedules[sc.ID]; found {
+		return fmt.Errorf("schedule %s already exists", sc.ID)
+	}
+	copied := *sc
+	m.schedules[sc.ID] = &copied
+	return nil
+}
+
+func (m *Memory) FinishSchedule(id string, data string, status string, report string) error {
+	return m.update(id, func(sc *Schedule) {
+		sc.Data, sc.Status, sc.Report = data, status, report
+	})
+}
+
+func (m *Memory) SetScheduleStatus(id string, status string) error {
+	return m.update(id, func(sc *Schedule) {
+		sc.Status = status
+	})
+}
+
+func (m *Memory) SetScheduleLocks(id string, locks string) error {
+	return m.update(id, func(sc *Schedule) {
+		sc.Locks = locks
+	})
+}
+
+func (m *Memory) SetScheduleConfig(id string, config string) error {
+	return m.update(id, func(sc *Schedule) {
+		sc.Config = config
+	})
+}
+
+func (m *Memory) DeleteSchedule(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, found := m.schedules[id]; !found {
+		return ErrNotFound
+	}
+	delete(m.schedules, id)
+	delete(m.history, id)
+	m.jobs = slices.DeleteFunc(m.jobs, func(job *Job) bool { return job.ScheduleID == id })
+	return nil
+}
+
+func (m *Memory) CreateJob(job *Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.jobs, func(j *Job) bool { return j.ScheduleID == job.ScheduleID }) {
+		return fmt.Errorf("job of schedule %s already exists", job.ScheduleID)
+	}
+	m.lastIDs[3]++
+	job.ID = m.lastIDs[3]
+	job.Created = time.Now().Unix()
+	job.Updated = job.Created
+	copied := *job
+	m.jobs = append(m.jobs, &copied)
+	return nil
+}
+
+func (m *Memory) GetJob(scheduleID string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.jobs, func(job *Job) bool { return job.ScheduleID == scheduleID })
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	copied := *m.jobs[i]
+	return &copied, nil
+}
+
+func (m *Memory) ListJobs(state string) ([]*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := []*Job{}
+	for _, job := range m.jobs {
+		if state == "" || job.State == state {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	return jobs, nil
+}
+
+func (m *Memory) ClaimJob() (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.jobs, func(job *Job) bool { return job.State == JobQueued })
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	job := m.jobs[i]
+	job.State = JobRunning
+	job.Attempts++
+	job.Updated = time.Now().Unix()
+	copied := *job
+	return &copied, nil
+}
+
+func (m *Memory) UpdateJob(scheduleID string, state string, errorMessage string, from ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.jobs, func(job *Job) bool { return job.ScheduleID == scheduleID })
+	if i < 0 || (len(from) > 0 && !slices.Contains(from, m.jobs[i].State)) {
+		return ErrNotFound
+	}
+	m.jobs[i].State, m.jobs[i].Error, m.jobs[i].Updated = state, errorMessage, time.Now().Unix()
+	return nil
+}
+
+func (m *Memory) CommitEdit(scheduleID string, previous string, data string, action string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sc, found := m.schedules[scheduleID]
+	if !found {
+		return ErrNotFound
+	}
+	m.lastIDs[0]++
+	m.history[scheduleID] = append(m.history[scheduleID], &historyRecord{
+		HistoryEntry: HistoryEntry{ID: m.lastIDs[0], Action: action, Created: time.Now().Unix()},
+		Data:         previous,
+	})
+	sc.Data = data
+	return nil
+}
+
+func (m *Memory) UndoEdit(scheduleID string) (*HistoryEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records := m.history[scheduleID]
+	sc, found := m.schedules[scheduleID]
+	if !found || len(records) == 0 {
+		return nil, ErrNotFound
+	}
+	last := records[len(records)-1]
+	m.history[scheduleID] = records[:len(records)-1]
+	sc.Data = last.Data
+	entry := last.HistoryEntry
+	return &entry, nil
+}
+
+func (m *Memory) ListHistory(scheduleID string) ([]*HistoryEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return historyEntries(m.history[scheduleID]), nil
+}
+
+func (m *Memory) ListProjects() ([]*Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	projects := []*Project{}
+	for _, p := range m.projects {
+		copied := *p
+		projects = append(projects, &copied)
+	}
+	return projects, nil
+}
+
+func (m *Memory) GetProject(id int64) (*Project, error) {
+	return m.project(func(p *Project) bool { return p.ID == id })
+}
+
+func (m *Memory) FindProject(name string) (*Project, error) {
+	return m.project(func(p *Project) bool { return p.Name == name })
+}
+
+func (m *Memory) CreateProject(name string, semester string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.projects, func(p *Project) bool { return p.Name == name }) {
+		return 0, fmt.Errorf("project %s already exists", name)
+	}
+	m.lastIDs[1]++
+	m.projects = append(m.projects, &Project{ID: m.lastIDs[1], Name: name, Semester: semester, Created: time.Now().Unix()})
+	return m.lastIDs[1], nil
+}
+
+func (m *Memory) CreateInputVersion(projectID int64, parentID int64, note string, files []*InputFile) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastIDs[2]++
+	v := &InputVersion{ID: m.lastIDs[2], ProjectID: projectID, ParentID: parentID, Note: note, Created: time.Now().Unix(), Files: []*InputFile{}}
+	for _, f := range files {
+		v.Files = append(v.Files, &InputFile{Kind: f.Kind, Filename: f.Filename, Size: len(f.Data), Data: slices.Clone(f.Data)})
+	}
+	sortFiles(v.Files)
+	m.versions = append(m.versions, v)
+	return v.ID, nil
+}
+
+func (m *Memory) ListInputVersions(projectID int64) ([]*InputVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	versions := []*InputVersion{}
+	for _, v := range m.versions {
+		if v.ProjectID == projectID {
+			versions = append(versions, copyVersion(v, false))
+		}
+	}
+	return versions, nil
+}
+
+func (m *Memory) GetInputVersion(projectID int64, versionID int64) (*InputVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v := m.version(versionID)
+	if v == nil || v.ProjectID != projectID {
+		return nil, ErrNotFound
+	}
+	return copyVersion(v, false), nil
+}
+
+func (m *Memory) LatestInputVersion(projectID int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var latest int64
+	for _, v := range m.versions {
+		if v.ProjectID == projectID {
+			latest = max(latest, v.ID)
+		}
+	}
+	return latest, nil
+}
+
+func (m *Memory) InputFiles(versionID int64) ([]*InputFile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v := m.version(versionID)
+	if v == nil {
+		return []*InputFile{}, nil
+	}
+	return copyVersion(v, true).Files, nil
+}
+
+func (m *Memory) GetInputFile(versionID int64, kind string) (*InputFile, error) {
+	files, _ := m.InputFiles(versionID)
+	for _, f := range files {
+		if f.Kind == kind {
+			return f, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) update(id string, change func(sc *Schedule)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sc, found := m.schedules[id]
+	if !found {
+		return ErrNotFound
+	}
+	change(sc)
+	return nil
+}
+
+func (m *Memory) project(match func(p *Project) bool) (*Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.projects, match)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	copied := *m.projects[i]
+	return &copied, nil
+}
+
+func (m *Memory) version(id int64) *InputVersion {
+	i := slices.IndexFunc(m.versions, func(v *InputVersion) bool { return v.ID == id })
+	if i < 0 {
+		return nil
+	}
+	return m.versions[i]
+}
+
+// Copy of the version, file data is only copied if asked for
+func copyVersion(v *InputVersion, withData bool) *InputVersion {
+	copied := *v
+	copied.Files = []*InputFile{}
+	for _, f := range v.Files {
+		file := &InputFile{Kind: f.Kind, Filename: f.Filename, Size: f.Size}
+		if withData {
+			file.Data = slices.Clone(f.Data)
+		}
+		copied.Files = append(copied.Files, file)
+	}
+	return &copied
+}
+
+// Edits without their data, the latest first
+func historyEntries(records []*historyRecord) []*HistoryEntry {
+	history := []*HistoryEntry{}
+	for i := len(records) - 1; i >= 0; i-- {
+		entry := records[i].HistoryEntry
+		history = append(history, &entry)
+	}
+	return history
+}
+
+// Order schedules by id like the databases do, ids are unix timestamps
+func sortSchedules(schedules []*Schedule) {
+	slices.SortFunc(schedules, func(s1 *Schedule, s2 *Schedule) int {
+		return cmp.Or(cmp.Compare(len(s1.ID), len(s2.ID)), cmp.Compare(s1.ID, s2.ID))
+	})
+}
+
+func sortFiles(files []*InputFile) {
+	slices.SortFunc(files, func(f1 *InputFile, f2 *InputFile) int {
+		return cmp.Compare(f1.Kind, f2.Kind)
+	})
+}
+
+func sortByID[T any](list []T, id func(T) int64) {
+	slices.SortFunc(list, func(a T, b T) int {
+		return cmp.Compare(id(a), id(b))
+	})
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*/*.sql
+var migrations embed.FS
+
+// Migration is an embedded SQL file named migrations/<dialect>/<version>_<name>.sql.
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+// Migrations lists the embedded migrations of the dialect (sqlite or postgres) ordered by
+// version. Both dialects keep the same versions.
+func Migrations(dialect string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(migrations, "migrations/"+dialect)
+	if err != nil {
+		return nil, err
+	}
+	list := []*Migration{}
+	for _, e := range entries {
+		prefix, name, found := strings.Cut(strings.TrimSuffix(e.Name(), ".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s", e.Name())
+		}
+		data, err := migrations.ReadFile("migrations/" + dialect + "/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, &Migration{Version: version, Name: name, Statements: splitStatements(string(data))})
+	}
+	slices.SortFunc(list, func(m1 *Migration, m2 *Migration) int {
+		return m1.Version - m2.Version
+	})
+	for i := 1; i < len(list); i++ {
+		if list[i].Version == list[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", list[i].Version)
+		}
+	}
+	return list, nil
+}
+
+// Migrate applies the migrations newer than the schema version of the database, each in
+// its own transaction. Databases created before migrations already have some of the
+// columns, adding those again is skipped.
+func Migrate(db *sql.DB, dialect string) error {
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_version (version INTEGER PRIMARY KEY, name TEXT, applied BIGINT)"); err != nil {
+		return err
+	}
+	var current int
+	if err := db.QueryRow("SELECT coalesce(max(version), 0) FROM schema_version").Scan(&current); err != nil {
+		return err
+	}
+	list, err := Migrations(dialect)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range list {
+		if m.Version <= current {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		for _, statement := range m.Statements {
+			if _, err = tx.Exec(statement); err != nil && !isDuplicateColumn(statement, err) {
+				tx.Rollback()
+				return fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err)
+			}
+		}
+		if _, err = tx.Exec(rebind(dialect, "INSERT INTO schema_version (version, name, applied) VALUES (?, ?, ?)"), m.Version, m.Name, time.Now().Unix()); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SchemaVersion returns the latest applied migration of the database.
+func SchemaVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("SELECT coalesce(max(version), 0) FROM schema_version").Scan(&version)
+	return version, err
+}
+
+// Split the migration into statements, dropping comments
+func splitStatements(data string) []string {
+	var sb strings.Builder
+	for _, line := range strings.Split(data, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		sb.WriteString(line + "\n")
+	}
+	statements := []string{}
+	for _, s := range strings.Split(sb.String(), ";") {
+		if s = strings.TrimSpace(s); s != "" {
+			statements = append(statements, s)
+		}
+	}
+	return statements
+}
+
+// Replace the ? placeholders of the query with $1, $2, ... for postgres
+func rebind(dialect string, query string) string {
+	if dialect != "postgres" {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func isDuplicateColumn(statement string, err error) bool {
+	return strings.Contains(strings.ToUpper(statement), "ADD COLUMN") && strings.Contains(err.Error(), "duplicate column name")
+}
@@ -0,0 +1,2 @@
+-- Generated schedules with their generation report
+CREATE TABLE IF NOT EXISTS schedule (id TEXT PRIMARY KEY, data TEXT, status TEXT, report TEXT);
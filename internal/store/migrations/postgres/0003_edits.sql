@@ -0,0 +1,3 @@
+-- Configuration a schedule was created with and the history of its manual edits
+ALTER TABLE schedule ADD COLUMN IF NOT EXISTS config TEXT;
+CREATE TABLE IF NOT EXISTS history (id BIGSERIAL PRIMARY KEY, schedule_id TEXT, data TEXT, action TEXT, created BIGINT);
@@ -0,0 +1,6 @@
+-- Projects with versioned input files, schedules are runs of an input version
+CREATE TABLE IF NOT EXISTS project (id BIGSERIAL PRIMARY KEY, name TEXT UNIQUE, semester TEXT, created BIGINT);
+CREATE TABLE IF NOT EXISTS input_version (id BIGSERIAL PRIMARY KEY, project_id BIGINT, parent_id BIGINT, note TEXT, created BIGINT);
+CREATE TABLE IF NOT EXISTS input (version_id BIGINT, kind TEXT, filename TEXT, data BYTEA, PRIMARY KEY (version_id, kind));
+ALTER TABLE schedule ADD COLUMN IF NOT EXISTS project_id BIGINT;
+ALTER TABLE schedule ADD COLUMN IF NOT EXISTS version_id BIGINT;
@@ -0,0 +1,3 @@
+-- Queued and running jobs of the server, a job runs a schedule
+CREATE TABLE IF NOT EXISTS job (id BIGSERIAL PRIMARY KEY, schedule_id TEXT UNIQUE, state TEXT, attempts INTEGER, error TEXT, previous TEXT, created BIGINT, updated BIGINT);
+CREATE INDEX IF NOT EXISTS job_state ON job (state, id);
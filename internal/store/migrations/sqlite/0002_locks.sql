@@ -0,0 +1,2 @@
+-- Locked sessions of a schedule as a JSON array
+ALTER TABLE schedule ADD COLUMN locks TEXT;
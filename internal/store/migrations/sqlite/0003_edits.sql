@@ -0,0 +1,3 @@
+-- Configuration a schedule was created with and the history of its manual edits
+ALTER TABLE schedule ADD COLUMN config TEXT;
+CREATE TABLE IF NOT EXISTS history (id INTEGER PRIMARY KEY AUTOINCREMENT, schedule_id INTEGER, data TEXT, action TEXT, created INTEGER);
@@ -0,0 +1,6 @@
+-- Projects with versioned input files, schedules are runs of an input version
+CREATE TABLE IF NOT EXISTS project (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE, semester TEXT, created INTEGER);
+CREATE TABLE IF NOT EXISTS input_version (id INTEGER PRIMARY KEY AUTOINCREMENT, project_id INTEGER, parent_id INTEGER, note TEXT, created INTEGER);
+CREATE TABLE IF NOT EXISTS input (version_id INTEGER, kind TEXT, filename TEXT, data BLOB, PRIMARY KEY (version_id, kind));
+ALTER TABLE schedule ADD COLUMN project_id INTEGER;
+ALTER TABLE schedule ADD COLUMN version_id INTEGER;
@@ -0,0 +1,3 @@
+-- Queued and running jobs of the server, a job runs a schedule
+CREATE TABLE IF NOT EXISTS job (id INTEGER PRIMARY KEY AUTOINCREMENT, schedule_id TEXT UNIQUE, state TEXT, attempts INTEGER, error TEXT, previous TEXT, created INTEGER, updated INTEGER);
+CREATE INDEX IF NOT EXISTS job_state ON job (state, id);
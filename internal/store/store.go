@@ -0,0 +1,159 @@
+// Package store persists schedules, their edit history and the versioned inputs of
+// projects for the servers. Open picks the backend: a SQLite file, a PostgreSQL
+// database, a directory of files or memory.
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned when the requested record doesn't exist.
+var ErrNotFound = errors.New("not found")
+
+// Schedule is a stored run. Data is its JSON export, or CSV for schedules created before
+// the JSON export.
+type Schedule struct {
+	ID        string
+	Data      string
+	Status    string
+	Report    string
+	Locks     string // JSON array of locked sessions
+	Config    string // JSON configuration the schedule was created with
+	ProjectID int64  // 0 for schedules created before projects
+	VersionID int64  // Input version the schedule was created from
+}
+
+// HistoryEntry is a confirmed manual edit of a schedule.
+type HistoryEntry struct {
+	ID      int64  `json:"id"`
+	Action  string `json:"action"`
+	Created int64  `json:"created"`
+}
+
+// Project groups the input versions and runs of a semester.
+type Project struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Semester string `json:"semester"`
+	Created  int64  `json:"created"`
+}
+
+// InputVersion is an immutable set of input files of a project.
+type InputVersion struct {
+	ID        int64        `json:"id"`
+	ProjectID int64        `json:"project"`
+	ParentID  int64        `json:"parent"` // Version the files that weren't uploaded are taken from, 0 for none
+	Note      string       `json:"note"`
+	Created   int64        `json:"created"`
+	Files     []*InputFile `json:"files"`
+}
+
+// InputFile is an input file of a version by its kind (courses, classrooms, busy, ...).
+type InputFile struct {
+	Kind     string `json:"kind"`
+	Filename string `json:"filename"`
+	Size     int    `json:"size"`
+	Data     []byte `json:"-"`
+}
+
+// States of a job
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Job is a queued run of a schedule, run by the workers of the server in order of id.
+type Job struct {
+	ID         int64  `json:"id"`
+	ScheduleID string `json:"schedule"`
+	State      string `json:"state"`
+	Attempts   int    `json:"attempts"`
+	Error      string `json:"error"` // Error of the last attempt
+	Previous   string `json:"-"`     // Data of the schedule to repair, empty to generate a new one
+	Created    int64  `json:"created"`
+	Updated    int64  `json:"updated"`
+}
+
+// ScheduleStore is the storage of run metadata, input files and results used by the
+// handlers of the servers.
+type ScheduleStore interface {
+	// Schedules without their data, optionally limited to the runs of a project
+	ListSchedules(projectID int64) ([]*Schedule, error)
+	GetSchedule(id string) (*Schedule, error)
+	CreateSchedule(s *Schedule) error
+	// Store the result of a run
+	FinishSchedule(id string, data string, status string, report string) error
+	SetScheduleStatus(id string, status string) error
+	SetScheduleLocks(id string, locks string) error
+	SetScheduleConfig(id string, config string) error
+	// Delete the schedule with its edit history and job
+	DeleteSchedule(id string) error
+
+	// Queue a job, its id and timestamps are set by the store
+	CreateJob(job *Job) error
+	GetJob(scheduleID string) (*Job, error)
+	// Jobs in the state, or all of them if empty, the oldest first
+	ListJobs(state string) ([]*Job, error)
+	// Move the oldest queued job to running and count the attempt, ErrNotFound if none is queued
+	ClaimJob() (*Job, error)
+	// Move the job to state. If from states are given, only a job in one of them is
+	// updated and ErrNotFound returned otherwise.
+	UpdateJob(scheduleID string, state string, errorMessage string, from ...string) error
+
+	// Replace the data of the schedule and keep the previous data to undo the edit
+	CommitEdit(scheduleID string, previous string, data string, action string) error
+	// Restore the data before the last edit and return the undone edit
+	UndoEdit(scheduleID string) (*HistoryEntry, error)
+	// Edits of the schedule, the latest first
+	ListHistory(scheduleID string) ([]*HistoryEntry, error)
+
+	ListProjects() ([]*Project, error)
+	GetProject(id int64) (*Project, error)
+	FindProject(name string) (*Project, error)
+	CreateProject(name string, semester string) (int64, error)
+
+	// Store the files as a new input version of the project and return its id
+	CreateInputVersion(projectID int64, parentID int64, note string, files []*InputFile) (int64, error)
+	// Input versions of the project with their files, without data
+	ListInputVersions(projectID int64) ([]*InputVersion, error)
+	GetInputVersion(projectID int64, versionID int64) (*InputVersion, error)
+	// Latest input version of the project, 0 if it has none
+	LatestInputVersion(projectID int64) (int64, error)
+	// Files of the version with their data
+	InputFiles(versionID int64) ([]*InputFile, error)
+	GetInputFile(versionID int64, kind string) (*InputFile, error)
+
+	Close() error
+}
+
+// Config selects the backend of the store.
+type Config struct {
+	Driver string // sqlite, postgres, fs or memory
+	DSN    string // Database file, connection string or directory, unused by memory
+}
+
+// Open opens the store of the configured backend. An empty DSN uses ./scheduler.db for
+// sqlite and ./db/store for fs.
+func Open(cfg Config) (ScheduleStore, error) {
+	switch cfg.Driver {
+	case "", "sqlite":
+		if cfg.DSN == "" {
+			cfg.DSN = "./scheduler.db"
+		}
+		return OpenSQLite(cfg.DSN)
+	case "postgres":
+		return OpenPostgres(cfg.DSN)
+	case "fs":
+		if cfg.DSN == "" {
+			cfg.DSN = "./db/store"
+		}
+		return OpenFilesystem(cfg.DSN)
+	case "memory":
+		return NewMemory(), nil
+	}
+	return nil, fmt.Errorf("unknown store driver %s", cfg.Driver)
+}
@@ -0,0 +1,374 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Backends the ScheduleStore contract is checked against, each opened empty
+var backends = []struct {
+	name string
+	open func(t *testing.T) ScheduleStore
+}{
+	{"memory", func(t *testing.T) ScheduleStore {
+		return NewMemory()
+	}},
+	{"filesystem", func(t *testing.T) ScheduleStore {
+		f, err := OpenFilesystem(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}},
+	{"sqlite", func(t *testing.T) ScheduleStore {
+		s, err := OpenSQLite(filepath.Join(t.TempDir(), "scheduler.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}},
+	{"postgres", func(t *testing.T) ScheduleStore {
+		s, err := OpenPostgres(postgresSchema(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}},
+}
+
+// Connection string of an empty schema of the database given by SCHEDULE_TEST_POSTGRES_DSN,
+// dropped when the test ends. Skips the test if the variable is unset.
+func postgresSchema(t *testing.T) string {
+	dsn := os.Getenv("SCHEDULE_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("SCHEDULE_TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("store_test_%d", time.Now().UnixNano())
+	if _, err = db.Exec("CREATE SCHEMA " + schema); err != nil {
+		db.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		defer db.Close()
+		if _, err := db.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// Unknown connection parameters are set for the session by the driver
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + schema
+	}
+	return dsn + " search_path=" + schema
+}
+
+// Run the test against every backend
+func forEachBackend(t *testing.T, test func(t *testing.T, s ScheduleStore)) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			s := b.open(t)
+			defer s.Close()
+			test(t, s)
+		})
+	}
+}
+
+func TestSchedules(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s ScheduleStore) {
+		projectID, err := s.CreateProject("fall", "2026 fall")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sc := range []*Schedule{
+			{ID: "1001", Status: "queued", Config: `{"seed":1}`, ProjectID: projectID},
+			{ID: "1002", Status: "queued"},
+		} {
+			if err = s.CreateSchedule(sc); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = s.CreateSchedule(&Schedule{ID: "1001"}); err == nil {
+			t.Error("creating an existing schedule succeeded")
+		}
+
+		if err = s.FinishSchedule("1001", "data", "success", "report"); err != nil {
+			t.Fatal(err)
+		}
+		if err = s.SetScheduleLocks("1001", "[]"); err != nil {
+			t.Fatal(err)
+		}
+		sc, err := s.GetSchedule("1001")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Schedule{ID: "1001", Data: "data", Status: "success", Report: "report", Locks: "[]", Config: `{"seed":1}`, ProjectID: projectID}
+		if *sc != want {
+			t.Errorf("got schedule %+v, want %+v", *sc, want)
+		}
+
+		tests := []struct {
+			projectID int64
+			ids       []string
+		}{
+			{0, []string{"1001", "1002"}},
+			{projectID, []string{"1001"}},
+			{projectID + 1, []string{}},
+		}
+		for _, tt := range tests {
+			listed, err := s.ListSchedules(tt.projectID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, sc := range listed {
+				if sc.Data != "" {
+					t.Errorf("listed schedule %s has its data", sc.ID)
+				}
+				ids = append(ids, sc.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.ids) {
+				t.Errorf("schedules of project %d are %v, want %v", tt.projectID, ids, tt.ids)
+			}
+		}
+
+		if err = s.DeleteSchedule("1001"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.GetSchedule("1001"); err != ErrNotFound {
+			t.Errorf("got %v for a deleted schedule, want ErrNotFound", err)
+		}
+		if err = s.DeleteSchedule("1001"); err != ErrNotFound {
+			t.Errorf("got %v deleting a deleted schedule, want ErrNotFound", err)
+		}
+		if err = s.SetScheduleStatus("1001", "failed"); err != ErrNotFound {
+			t.Errorf("got %v updating a deleted schedule, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestClaimJob(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s ScheduleStore) {
+		const jobCount = 20
+		for i := 0; i < jobCount; i++ {
+			id := fmt.Sprint(2000 + i)
+			if err := s.CreateSchedule(&Schedule{ID: id}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.CreateJob(&Job{ScheduleID: id, State: JobQueued}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		first, err := s.ClaimJob()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first.ScheduleID != "2000" || first.State != JobRunning || first.Attempts != 1 {
+			t.Errorf("claimed %+v, want the oldest job running on its first attempt", *first)
+		}
+
+		// Workers claiming at once never get the same job
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		claimed := map[string]int{first.ScheduleID: 1}
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					job, err := s.ClaimJob()
+					if err == ErrNotFound {
+						return
+					}
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					mu.Lock()
+					claimed[job.ScheduleID]++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if len(claimed) != jobCount {
+			t.Errorf("claimed %d jobs, want %d", len(claimed), jobCount)
+		}
+		for id, n := range claimed {
+			if n != 1 {
+				t.Errorf("job of schedule %s claimed %d times", id, n)
+			}
+		}
+
+		// A job queued again is claimed on its next attempt
+		if err = s.UpdateJob("2005", JobQueued, "store error"); err != nil {
+			t.Fatal(err)
+		}
+		job, err := s.ClaimJob()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.ScheduleID != "2005" || job.Attempts != 2 || job.Error != "store error" {
+			t.Errorf("claimed %+v, want the job of 2005 on its second attempt", *job)
+		}
+		if _, err = s.ClaimJob(); err != ErrNotFound {
+			t.Errorf("got %v with no queued job, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestUpdateJobFromState(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s ScheduleStore) {
+		if err := s.CreateSchedule(&Schedule{ID: "3000"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.CreateJob(&Job{ScheduleID: "3000", State: JobQueued}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.ClaimJob(); err != nil {
+			t.Fatal(err)
+		}
+
+		// A job cancelled while running is not finished by its worker
+		steps := []struct {
+			state string
+			from  []string
+			err   error
+			want  string
+		}{
+			{JobCancelled, []string{JobQueued, JobRunning}, nil, JobCancelled},
+			{JobSucceeded, []string{JobRunning}, ErrNotFound, JobCancelled},
+			{JobCancelled, []string{JobQueued, JobRunning}, ErrNotFound, JobCancelled},
+			{JobQueued, nil, nil, JobQueued},
+		}
+		for i, step := range steps {
+			if err := s.UpdateJob("3000", step.state, "", step.from...); err != step.err {
+				t.Errorf("step %d: moving to %s from %v returned %v, want %v", i+1, step.state, step.from, err, step.err)
+			}
+			job, err := s.GetJob("3000")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.State != step.want {
+				t.Errorf("step %d: job is %s, want %s", i+1, job.State, step.want)
+			}
+		}
+		if err := s.UpdateJob("3001", JobFailed, "", JobRunning); err != ErrNotFound {
+			t.Errorf("got %v for a schedule without a job, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestEditHistory(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s ScheduleStore) {
+		if err := s.CreateSchedule(&Schedule{ID: "3000", Data: "v1"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.UndoEdit("3000"); err != ErrNotFound {
+			t.Errorf("got %v undoing without edits, want ErrNotFound", err)
+		}
+		for _, edit := range []struct{ previous, data, action string }{
+			{"v1", "v2", "move"},
+			{"v2", "v3", "swap"},
+		} {
+			if err := s.CommitEdit("3000", edit.previous, edit.data, edit.action); err != nil {
+				t.Fatal(err)
+			}
+		}
+		history, err := s.ListHistory("3000")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 2 || history[0].Action != "swap" || history[1].Action != "move" {
+			t.Errorf("got history %v, want swap then move", history)
+		}
+
+		tests := []struct {
+			action string
+			data   string
+		}{
+			{"swap", "v2"},
+			{"move", "v1"},
+		}
+		for _, tt := range tests {
+			entry, err := s.UndoEdit("3000")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sc, err := s.GetSchedule("3000")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.Action != tt.action || sc.Data != tt.data {
+				t.Errorf("undid %s to %s, want %s to %s", entry.Action, sc.Data, tt.action, tt.data)
+			}
+		}
+		if history, err = s.ListHistory("3000"); err != nil || len(history) != 0 {
+			t.Errorf("got history %v, %v after undoing every edit", history, err)
+		}
+	})
+}
+
+func TestMigrateTwice(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "scheduler.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	list, err := Migrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err = Migrate(db, "sqlite"); err != nil {
+			t.Fatalf("migration %d: %v", i+1, err)
+		}
+		version, err := SchemaVersion(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if latest := list[len(list)-1].Version; version != latest {
+			t.Errorf("schema version is %d after migration %d, want %d", version, i+1, latest)
+		}
+	}
+	var applied int
+	if err = db.QueryRow("SELECT count(*) FROM schema_version").Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(list) {
+		t.Errorf("%d migrations recorded, want %d", applied, len(list))
+	}
+}
+
+func TestMigrationsMatch(t *testing.T) {
+	sqlite, err := Migrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres, err := Migrations("postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("%d sqlite and %d postgres migrations", len(sqlite), len(postgres))
+	}
+	for i := range sqlite {
+		if sqlite[i].Version != postgres[i].Version || sqlite[i].Name != postgres[i].Name {
+			t.Errorf("migration %d is %d_%s for sqlite and %d_%s for postgres", i, sqlite[i].Version, sqlite[i].Name, postgres[i].Version, postgres[i].Name)
+		}
+	}
+}
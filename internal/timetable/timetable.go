@@ -0,0 +1,254 @@
+package timetable
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rhyrak/go-schedule/pkg/model"
+)
+
+// Entry is a course session shown inside a timetable cell.
+type Entry struct {
+	CourseCode string `json:"course_code"`
+	CourseName string `json:"course_name"`
+	Department string `json:"department"`
+	Grade      int    `json:"grade"`
+	Lecturer   string `json:"lecturer"`
+	Classroom  string `json:"classroom"`
+	Day        int    `json:"day"`
+	Slot       int    `json:"slot"`     // Starting time slot
+	Duration   int    `json:"duration"` // Minutes
+}
+
+// Grid is a weekly timetable of a lecturer, classroom or cohort.
+type Grid struct {
+	Kind  string       `json:"kind"` // lecturer, classroom or cohort
+	Name  string       `json:"name"`
+	Days  []string     `json:"days"`
+	Times []string     `json:"times"` // Starting time of each slot
+	Cells [][][]*Entry `json:"cells"` // Cells[Day][Slot] = sessions running at that time
+}
+
+// Timetable indexes placed sessions of a schedule for rendering grids.
+type Timetable struct {
+	Entries          []*Entry
+	Days             int
+	TimeSlotCount    int
+	TimeSlotDuration int
+}
+
+// New creates a timetable from a generated schedule.
+func New(schedule *model.Schedule) *Timetable {
+	t := &Timetable{
+		Entries:          []*Entry{},
+		Days:             len(schedule.Days),
+		TimeSlotCount:    schedule.TimeSlotCount,
+		TimeSlotDuration: schedule.TimeSlotDuration,
+	}
+	seen := map[model.CourseID]bool{}
+	for _, day := range schedule.Days {
+		for i, slot := range day.Slots {
+			for _, c := range slot.CourseRefs {
+				if seen[c.CourseID] {
+					continue
+				}
+				seen[c.CourseID] = true
+				classroom := c.Course_Environment
+				if c.NeedsRoom && c.Classroom != nil {
+					classroom = c.Classroom.ID
+				}
+				t.Entries = append(t.Entries, &Entry{
+					CourseCode: c.DisplayName,
+					CourseName: c.Course_Name,
+					Department: c.Department,
+					Grade:      c.Class,
+					Lecturer:   c.Lecturer,
+					Classroom:  classroom,
+					Day:        day.DayOfWeek,
+					Slot:       i,
+					Duration:   c.Duration,
+				})
+			}
+		}
+	}
+	return t
+}
+
+// FromRows creates a timetable from exported schedule rows.
+func FromRows(rows []*model.ScheduleCSVRow, days int, timeSlotDuration int, timeSlotCount int) *Timetable {
+	t := &Timetable{
+		Entries:          []*Entry{},
+		Days:             days,
+		TimeSlotCount:    timeSlotCount,
+		TimeSlotDuration: timeSlotDuration,
+	}
+	for _, r := range rows {
+		t.Entries = append(t.Entries, &Entry{
+			CourseCode: r.CourseCode,
+			CourseName: r.CourseName,
+			Department: r.Department,
+			Grade:      r.Class,
+			Lecturer:   r.Lecturer,
+			Classroom:  r.Classrooms,
+			Day:        r.Day,
+			Slot:       r.Time / timeSlotDuration,
+			Duration:   r.Duration,
+		})
+	}
+	return t
+}
+
+// Lecturer renders the weekly grid of a lecturer.
+func (t *Timetable) Lecturer(name string) *Grid {
+	return t.grid("lecturer", name, func(e *Entry) bool { return e.Lecturer == name })
+}
+
+// Classroom renders the weekly grid of a classroom.
+func (t *Timetable) Classroom(id string) *Grid {
+	return t.grid("classroom", id, func(e *Entry) bool { return e.Classroom == id })
+}
+
+// Cohort renders the weekly grid of a department and grade.
+func (t *Timetable) Cohort(department string, grade int) *Grid {
+	return t.grid("cohort", fmt.Sprintf("%s %d", department, grade), func(e *Entry) bool {
+		return e.Department == department && e.Grade == grade
+	})
+}
+
+// Lecturers lists all lecturers with at least one session.
+func (t *Timetable) Lecturers() []string {
+	return t.unique(func(e *Entry) string { return e.Lecturer })
+}
+
+// Classrooms lists all classrooms with at least one session.
+func (t *Timetable) Classrooms() []string {
+	return t.unique(func(e *Entry) string { return e.Classroom })
+}
+
+// Cohorts lists all department and grade pairs with at least one session.
+func (t *Timetable) Cohorts() []string {
+	return t.unique(func(e *Entry) string { return fmt.Sprintf("%s %d", e.Department, e.Grade) })
+}
+
+// SlotTime formats the starting time of a time slot.
+func (t *Timetable) SlotTime(slot int) string {
+	start := model.FirstSlotStart + slot*t.TimeSlotDuration
+	return fmt.Sprintf("%0.2d:%0.2d", start/60, start%60)
+}
+
+func (t *Timetable) grid(kind string, name string, match func(e *Entry) bool) *Grid {
+	g := &Grid{
+		Kind:  kind,
+		Name:  name,
+		Days:  []string{},
+		Times: []string{},
+		Cells: make([][][]*Entry, t.Days),
+	}
+	for d := 0; d < t.Days; d++ {
+		g.Days = append(g.Days, model.DayName(d))
+		g.Cells[d] = make([][]*Entry, t.TimeSlotCount)
+		for s := range g.Cells[d] {
+			g.Cells[d][s] = []*Entry{}
+		}
+	}
+	for s := 0; s < t.TimeSlotCount; s++ {
+		g.Times = append(g.Times, t.SlotTime(s))
+	}
+	for _, e := range t.Entries {
+		if !match(e) || e.Day < 0 || e.Day >= t.Days {
+			continue
+		}
+		slots := (e.Duration + t.TimeSlotDuration - 1) / t.TimeSlotDuration
+		for s := e.Slot; s < e.Slot+slots && s < t.TimeSlotCount; s++ {
+			g.Cells[e.Day][s] = append(g.Cells[e.Day][s], e)
+		}
+	}
+	return g
+}
+
+func (t *Timetable) unique(key func(e *Entry) string) []string {
+	values := []string{}
+	for _, e := range t.Entries {
+		if k := key(e); k != "" && !slices.Contains(values, k) {
+			values = append(values, k)
+		}
+	}
+	slices.Sort(values)
+	return values
+}
+
+// Sessions lists the sessions of the grid once each, ordered by day and starting time.
+func (g *Grid) Sessions() []*Entry {
+	sessions := []*Entry{}
+	seen := map[*Entry]bool{}
+	for _, day := range g.Cells {
+		for _, cell := range day {
+			for _, e := range cell {
+				if !seen[e] {
+					seen[e] = true
+					sessions = append(sessions, e)
+				}
+			}
+		}
+	}
+	return sessions
+}
+
+// String formats the grid as a plain text table with time slots as rows.
+func (g *Grid) String() string {
+	const width = 18
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s\n", strings.ToUpper(g.Kind[:1])+g.Kind[1:], g.Name))
+	sb.WriteString(fmt.Sprintf("%-6s", ""))
+	for _, d := range g.Days {
+		sb.WriteString(fmt.Sprintf(" %-*s", width, d))
+	}
+	sb.WriteString("\n")
+	for s, time := range g.Times {
+		sb.WriteString(fmt.Sprintf("%-6s", time))
+		for d := range g.Days {
+			sb.WriteString(fmt.Sprintf(" %-*s", width, g.cellText(d, s, width)))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Describe sessions of a cell with the detail the grid kind doesn't already show
+func (g *Grid) cellText(day int, slot int, width int) string {
+	texts := []string{}
+	for _, e := range g.Cells[day][slot] {
+		detail := e.Classroom
+		if g.Kind == "classroom" {
+			detail = e.Lecturer
+		}
+		texts = append(texts, e.CourseCode+" "+detail)
+	}
+	text := strings.Join(texts, ", ")
+	if len([]rune(text)) > width {
+		text = string([]rune(text)[:width-1]) + "…"
+	}
+	return text
+}
+
+// View renders the grid described by "lecturer:<name>", "classroom:<id>" or
+// "cohort:<department>:<grade>".
+func (t *Timetable) View(spec string) (*Grid, error) {
+	kind, name, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "lecturer":
+		return t.Lecturer(name), nil
+	case "classroom":
+		return t.Classroom(name), nil
+	case "cohort":
+		department, gradeSTR, _ := strings.Cut(name, ":")
+		grade, err := strconv.Atoi(gradeSTR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grade in %q", spec)
+		}
+		return t.Cohort(department, grade), nil
+	}
+	return nil, fmt.Errorf("unknown timetable %q, expected lecturer:<name>, classroom:<id> or cohort:<department>:<grade>", spec)
+}
@@ -23,6 +23,14 @@ func (c *Classroom) IsAvailable(day int, slot int) bool {
 	return c.schedule[day][slot] == 0
 }
 
+// CourseAt returns the course occupying the classroom at given time, 0 if free.
+func (c *Classroom) CourseAt(day int, slot int) CourseID {
+	if day < 0 || day >= c.days || slot < 0 || slot >= c.slots {
+		return 0
+	}
+	return c.schedule[day][slot]
+}
+
 // CreateSchedule creates an empty schedule.
 func (c *Classroom) CreateSchedule(day int, slot int) {
 	c.days = day
@@ -48,6 +56,16 @@ func (c *Classroom) PlaceCourse(day int, slot int, course CourseID) bool {
 	return false
 }
 
+// RemoveCourse frees given time if it is occupied by course.
+// Returns false if the course wasn't placed there.
+func (c *Classroom) RemoveCourse(day int, slot int, course CourseID) bool {
+	if day < 0 || day >= c.days || slot < 0 || slot >= c.slots || c.schedule[day][slot] != course {
+		return false
+	}
+	c.schedule[day][slot] = 0
+	return true
+}
+
 func (c *Classroom) AssignAvailableDays() bool {
 	days := strings.Split(c.AvailableDays, "-")
 
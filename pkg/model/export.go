@@ -0,0 +1,92 @@
+package model
+
+// ScheduleJSONVersion is the version of the JSON export schema. It is increased on
+// changes that break readers of older exports.
+const ScheduleJSONVersion = 1
+
+// ScheduleJSON is the JSON export of a schedule.
+type ScheduleJSON struct {
+	Version          int        `json:"version"`
+	Cost             int        `json:"cost"`
+	TimeSlotDuration int        `json:"time_slot_duration"` // Minutes
+	TimeSlotCount    int        `json:"time_slot_count"`
+	Days             []*DayJSON `json:"days"` // Ordered by day of week
+}
+
+// DayJSON is a day of the JSON export.
+type DayJSON struct {
+	DayOfWeek int         `json:"day_of_week"` // Monday = 0
+	Name      string      `json:"name"`
+	Slots     []*SlotJSON `json:"slots"`
+}
+
+// SlotJSON is a time slot of the JSON export. Placements are listed in the slot they
+// start at, Occupied lists every course running during the slot.
+type SlotJSON struct {
+	Slot       int              `json:"slot"`
+	Start      string           `json:"start"` // HH:MM
+	End        string           `json:"end"`   // HH:MM
+	Placements []*PlacementJSON `json:"placements"`
+	Occupied   []CourseID       `json:"occupied"`
+}
+
+// PlacementJSON is a course placed into a day and time interval.
+type PlacementJSON struct {
+	Course    *CourseJSON `json:"course"`
+	Classroom string      `json:"classroom"` // Classroom ID, or the environment of courses that don't need a room
+	StartSlot int         `json:"start_slot"`
+	EndSlot   int         `json:"end_slot"` // Exclusive
+	Start     string      `json:"start"`    // HH:MM
+	End       string      `json:"end"`      // HH:MM
+	Duration  int         `json:"duration"` // Minutes
+}
+
+// CourseJSON holds the details of a placed course.
+type CourseJSON struct {
+	CourseID           CourseID   `json:"course_id"`
+	CourseCode         string     `json:"course_code"`
+	DisplayName        string     `json:"display_name"`
+	Name               string     `json:"name"`
+	Section            int        `json:"section"`
+	Department         string     `json:"department"`
+	Grade              int        `json:"grade"`
+	Lecturer           string     `json:"lecturer"`
+	Students           int        `json:"students"`
+	Environment        string     `json:"environment"`
+	TplusU             string     `json:"t_plus_u"`
+	AKTS               float32    `json:"akts"`
+	Compulsory         bool       `json:"compulsory"`
+	Reserved           bool       `json:"reserved"`
+	External           bool       `json:"external"`
+	ServiceCourse      bool       `json:"service_course"`
+	ParentID           CourseID   `json:"parent_id"`  // Course the split part belongs to
+	PartIndex          int        `json:"part_index"` // Split part, 0 for the first
+	PartCount          int        `json:"part_count"`
+	SiblingIDs         []CourseID `json:"sibling_ids"`
+	Laboratory         bool       `json:"laboratory"`
+	TheoryIDs          []CourseID `json:"theory_ids"` // Theory courses of a laboratory
+	ConflictingCourses []CourseID `json:"conflicting_courses"`
+}
+
+// Rows flattens the JSON export into schedule CSV rows.
+func (s *ScheduleJSON) Rows() []*ScheduleCSVRow {
+	rows := []*ScheduleCSVRow{}
+	for _, day := range s.Days {
+		for _, slot := range day.Slots {
+			for _, p := range slot.Placements {
+				rows = append(rows, &ScheduleCSVRow{
+					CourseCode: p.Course.DisplayName,
+					Day:        day.DayOfWeek,
+					Time:       p.StartSlot * s.TimeSlotDuration,
+					Duration:   p.Duration,
+					Classrooms: p.Classroom,
+					Class:      p.Course.Grade,
+					Department: p.Course.Department,
+					CourseName: p.Course.Name,
+					Lecturer:   p.Course.Lecturer,
+				})
+			}
+		}
+	}
+	return rows
+}
@@ -0,0 +1,11 @@
+package model
+
+type LecturerLimit struct {
+	Lecturer            string `csv:"Lecturer"`
+	MaxDailyHours       int    `csv:"Max_Daily_Hours"`
+	MaxConsecutiveHours int    `csv:"Max_Consecutive_Hours"`
+	MaxDays             int    `csv:"Max_Days"`
+	MinDays             int    `csv:"Min_Days"`
+	NoEarlyAfterLateSTR string `csv:"No_Early_After_Late"`
+	NoEarlyAfterLate    bool   `csv:"-"`
+}
@@ -0,0 +1,14 @@
+package model
+
+// Lock pins a course session, a single split part or a laboratory, to a day and starting
+// time and optionally to a classroom.
+type Lock struct {
+	Department      string `csv:"Department" json:"department"`
+	CourseCodeSTR   string `csv:"Course_Code" json:"course_code"`
+	Section         int    `csv:"Section" json:"section"`             // 0 matches any section
+	Part            int    `csv:"Part" json:"part"`                   // Split part starting from 1, 0 for the first
+	LabSTR          string `csv:"Lab" json:"lab"`                     // Yes pins the laboratory instead of the theory course
+	DaySTR          string `csv:"Day" json:"day"`                     // Monday-Friday
+	StartingTimeSTR string `csv:"Starting_Time" json:"starting_time"` // HH:MM
+	ClassroomSTR    string `csv:"Classroom" json:"classroom"`         // Empty lets the scheduler pick a classroom
+}
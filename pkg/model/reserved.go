@@ -1,9 +1,13 @@
 package model
 
 type Reserved struct {
-	Department      string  `csv:"Department"`
-	CourseCodeSTR   string  `csv:"Course_Code"`
-	StartingTimeSTR string  `csv:"Starting_Time"`
-	DaySTR          string  `csv:"Day"`
-	CourseRef       *Course `csv:"_"`
+	Department      string      `csv:"Department"`
+	CourseCodeSTR   string      `csv:"Course_Code"`
+	StartingTimeSTR string      `csv:"Starting_Time"`
+	DaySTR          string      `csv:"Day"`
+	CourseRef       *Course     `csv:"_"`
+	LabRef          *Laboratory `csv:"-"` // Locked laboratory, CourseRef is nil
+	Room            *Classroom  `csv:"-"` // Pinned classroom of a lock
+	Locked          bool        `csv:"-"` // Placed exactly at the reserved time instead of the first fit after it
+	Violation       string      `csv:"-"` // Hard constraint a lock breaks, empty if placed
 }
@@ -1,10 +1,29 @@
 package model
 
 import (
+	"fmt"
 	"math/rand"
 	"slices"
 )
 
+// Days a schedule can span, Monday to Friday
+const WeekLength = 5
+
+// FirstSlotStart is the starting time of the first time slot of a day, in minutes after
+// midnight (08:30).
+const FirstSlotStart = 8*60 + 30
+
+var dayNames = [WeekLength]string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
+
+// DayName returns the name of a day of the week, 0 is Monday. Days outside the week are
+// named by their index, e.g. Day(5).
+func DayName(dayOfWeek int) string {
+	if dayOfWeek < 0 || dayOfWeek >= WeekLength {
+		return fmt.Sprintf("Day(%d)", dayOfWeek)
+	}
+	return dayNames[dayOfWeek]
+}
+
 type TimeSlot struct {
 	Courses    []CourseID
 	CourseRefs []*Course
@@ -36,7 +55,7 @@ type ScheduleCSVRow struct {
 	Lecturer   string `csv:"lecturer"`
 }
 
-// NewSchedule creates an empty schedule.
+// NewSchedule creates an empty schedule with days in the order of the week.
 func NewSchedule(days int, timeSlotDuration int, timeSlotCount int) *Schedule {
 	schedule := Schedule{Days: make([]*Day, days), TimeSlotDuration: timeSlotDuration, TimeSlotCount: timeSlotCount}
 	for i := range schedule.Days {
@@ -49,12 +68,16 @@ func NewSchedule(days int, timeSlotDuration int, timeSlotCount int) *Schedule {
 		schedule.Days[i].GradeCounter = make(map[string][]int)
 		schedule.Days[i].GradeCreditCounter = make(map[string][]float32)
 	}
-	rand.Shuffle(len(schedule.Days), func(i, j int) {
-		schedule.Days[i], schedule.Days[j] = schedule.Days[j], schedule.Days[i]
-	})
 	return &schedule
 }
 
+// ShuffleDays shuffles the order days are filled in with random.
+func (s *Schedule) ShuffleDays(random *rand.Rand) {
+	random.Shuffle(len(s.Days), func(i, j int) {
+		s.Days[i], s.Days[j] = s.Days[j], s.Days[i]
+	})
+}
+
 // CalculateCost calculates cost based on conflicting course proximity.
 func (s *Schedule) CalculateCost() {
 	s.Cost = 0
@@ -115,6 +138,7 @@ func (s *Schedule) DeepCopy() *Schedule {
 						Class:                    course.Class,
 						Department:               course.Department,
 						Lecturer:                 course.Lecturer,
+						RoomStability:            course.RoomStability,
 						Duration:                 course.Duration,
 						CourseID:                 course.CourseID,
 						ConflictingCourses:       append([]CourseID(nil), course.ConflictingCourses...),
@@ -126,17 +150,22 @@ func (s *Schedule) DeepCopy() *Schedule {
 						ReservedStartingTimeSlot: course.ReservedStartingTimeSlot,
 						ReservedDay:              course.ReservedDay,
 						BusyDays:                 append([]int(nil), course.BusyDays...),
+						Limits:                   course.Limits,
 						Compulsory:               course.Compulsory,
 						ConflictProbability:      course.ConflictProbability,
 						DisplayName:              course.DisplayName,
 						ServiceCourse:            course.ServiceCourse,
 						HasBeenSplit:             course.HasBeenSplit,
-						IsFirstHalf:              course.IsFirstHalf,
 						HasLab:                   course.HasLab,
 						PlacedDay:                course.PlacedDay,
 						AreEqual:                 course.AreEqual,
-						IsBiggerHalf:             course.IsBiggerHalf,
-						OtherHalfID:              course.OtherHalfID,
+						ParentID:                 course.ParentID,
+						PartIndex:                course.PartIndex,
+						PartCount:                course.PartCount,
+						SiblingIDs:               append([]CourseID(nil), course.SiblingIDs...),
+						External:                 course.External,
+						TheoryIDs:                append([]CourseID(nil), course.TheoryIDs...),
+						RoomLocked:               course.RoomLocked,
 					}
 					newSlot.CourseRefs[k] = newCourse
 				}
@@ -201,6 +230,7 @@ func DeepCopyCourses(courses []*Course) []*Course {
 			Class:                    course.Class,
 			Department:               course.Department,
 			Lecturer:                 course.Lecturer,
+			RoomStability:            course.RoomStability,
 			Duration:                 course.Duration,
 			CourseID:                 course.CourseID,
 			ConflictingCourses:       append([]CourseID(nil), course.ConflictingCourses...),
@@ -212,17 +242,22 @@ func DeepCopyCourses(courses []*Course) []*Course {
 			ReservedStartingTimeSlot: course.ReservedStartingTimeSlot,
 			ReservedDay:              course.ReservedDay,
 			BusyDays:                 append([]int(nil), course.BusyDays...),
+			Limits:                   course.Limits,
 			Compulsory:               course.Compulsory,
 			ConflictProbability:      course.ConflictProbability,
 			DisplayName:              course.DisplayName,
 			ServiceCourse:            course.ServiceCourse,
 			HasBeenSplit:             course.HasBeenSplit,
-			IsFirstHalf:              course.IsFirstHalf,
 			HasLab:                   course.HasLab,
 			PlacedDay:                course.PlacedDay,
 			AreEqual:                 course.AreEqual,
-			IsBiggerHalf:             course.IsBiggerHalf,
-			OtherHalfID:              course.OtherHalfID,
+			ParentID:                 course.ParentID,
+			PartIndex:                course.PartIndex,
+			PartCount:                course.PartCount,
+			SiblingIDs:               append([]CourseID(nil), course.SiblingIDs...),
+			External:                 course.External,
+			TheoryIDs:                append([]CourseID(nil), course.TheoryIDs...),
+			RoomLocked:               course.RoomLocked,
 		}
 		copiedCourses[i] = copiedCourse
 	}
@@ -243,6 +278,7 @@ func DeepCopyLaboratories(labs []*Laboratory) []*Laboratory {
 			Class:                    lab.Class,
 			Department:               lab.Department,
 			Lecturer:                 lab.Lecturer,
+			RoomStability:            lab.RoomStability,
 			Duration:                 lab.Duration,
 			CourseID:                 lab.CourseID,
 			ConflictingCourses:       append([]CourseID(nil), lab.ConflictingCourses...),
@@ -254,6 +290,7 @@ func DeepCopyLaboratories(labs []*Laboratory) []*Laboratory {
 			ReservedStartingTimeSlot: lab.ReservedStartingTimeSlot,
 			ReservedDay:              lab.ReservedDay,
 			BusyDays:                 append([]int(nil), lab.BusyDays...),
+			Limits:                   lab.Limits,
 			Compulsory:               lab.Compulsory,
 			ConflictProbability:      lab.ConflictProbability,
 			DisplayName:              lab.DisplayName,
@@ -4,4 +4,5 @@ type Split struct {
 	Course_Department string `csv:"Department"`
 	Course_Code       string `csv:"Course_Code"`
 	Half_Duration     int    `csv:"Half_Duration"`
+	Durations         string `csv:"Durations"`
 }